@@ -0,0 +1,443 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// variableType is the type of the value of the mysqld variable.
+type variableType string
+
+const (
+	varBool   variableType = "bool"
+	varInt    variableType = "int"
+	varSize   variableType = "size"
+	varFloat  variableType = "float"
+	varEnum   variableType = "enum"
+	varSet    variableType = "set"
+	varString variableType = "string"
+)
+
+// mysqlVariable describes a mysqld variable or option in my.cnf.
+// +kubebuilder:object:generate=false
+type mysqlVariable struct {
+	typ variableType
+	// The range of the int, size and float values.
+	min, max float64
+	// The allowed values of the enum and set values.
+	values []string
+	// Dynamic is true if the variable can be changed by SET GLOBAL.
+	dynamic bool
+	// Deprecated is true if the variable is deprecated in the version.
+	deprecated bool
+}
+
+const maxUint64 = float64(math.MaxUint64)
+
+func boolVar(dynamic bool) mysqlVariable {
+	return mysqlVariable{typ: varBool, dynamic: dynamic}
+}
+func stringVar(dynamic bool) mysqlVariable {
+	return mysqlVariable{typ: varString, dynamic: dynamic}
+}
+func intVar(min, max float64, dynamic bool) mysqlVariable {
+	return mysqlVariable{typ: varInt, min: min, max: max, dynamic: dynamic}
+}
+func sizeVar(min, max float64, dynamic bool) mysqlVariable {
+	return mysqlVariable{typ: varSize, min: min, max: max, dynamic: dynamic}
+}
+func floatVar(min, max float64, dynamic bool) mysqlVariable {
+	return mysqlVariable{typ: varFloat, min: min, max: max, dynamic: dynamic}
+}
+func enumVar(dynamic bool, values ...string) mysqlVariable {
+	return mysqlVariable{typ: varEnum, values: values, dynamic: dynamic}
+}
+func setVar(dynamic bool, values ...string) mysqlVariable {
+	return mysqlVariable{typ: varSet, values: values, dynamic: dynamic}
+}
+func deprecated(v mysqlVariable) mysqlVariable {
+	v.deprecated = true
+	return v
+}
+
+var sqlModes = []string{"ALLOW_INVALID_DATES", "ANSI_QUOTES", "ERROR_FOR_DIVISION_BY_ZERO", "HIGH_NOT_PRECEDENCE",
+	"IGNORE_SPACE", "NO_AUTO_VALUE_ON_ZERO", "NO_BACKSLASH_ESCAPES", "NO_DIR_IN_CREATE", "NO_ENGINE_SUBSTITUTION",
+	"NO_UNSIGNED_SUBTRACTION", "NO_ZERO_DATE", "NO_ZERO_IN_DATE", "ONLY_FULL_GROUP_BY", "PAD_CHAR_TO_FULL_LENGTH",
+	"PIPES_AS_CONCAT", "REAL_AS_FLOAT", "STRICT_ALL_TABLES", "STRICT_TRANS_TABLES", "TIME_TRUNCATE_FRACTIONAL",
+	"ANSI", "TRADITIONAL"}
+
+// commonVariables is the catalogue of the variables in both MySQL 5.7 and 8.0.
+var commonVariables = map[string]mysqlVariable{
+	"audit_log_buffer_size":      sizeVar(4096, maxUint64, false),
+	"audit_log_exclude_accounts": stringVar(true),
+	"audit_log_file":             stringVar(false),
+	"audit_log_format":           enumVar(false, "OLD", "NEW", "JSON", "CSV"),
+	"audit_log_include_accounts": stringVar(true),
+	"audit_log_policy":           enumVar(true, "ALL", "LOGINS", "QUERIES", "NONE"),
+	"audit_log_rotate_on_size":   sizeVar(0, maxUint64, true),
+	"audit_log_rotations":        intVar(0, 999, true),
+	"audit_log_strategy":         enumVar(false, "ASYNCHRONOUS", "PERFORMANCE", "SEMISYNCHRONOUS", "SYNCHRONOUS"),
+	"autocommit":                 boolVar(true),
+	"back_log":                   intVar(1, 65535, false),
+	"binlog_cache_size":          sizeVar(4096, maxUint64, true),
+	"binlog_do_db":               stringVar(false),
+	"binlog_format":              enumVar(true, "ROW", "STATEMENT", "MIXED"),
+	"binlog_ignore_db":           stringVar(false),
+	"binlog_row_image":           enumVar(true, "FULL", "MINIMAL", "NOBLOB"),
+	"binlog_stmt_cache_size":     sizeVar(4096, maxUint64, true),
+	"character_set_server":       stringVar(true),
+	"collation_server":           stringVar(true),
+	"connect_timeout":            intVar(2, 31536000, true),
+	"connection_control_failed_connections_threshold": intVar(0, 2147483647, true),
+	"connection_control_max_connection_delay":         intVar(1000, 2147483647, true),
+	"connection_control_min_connection_delay":         intVar(1000, 2147483647, true),
+	"core_file":                       boolVar(false),
+	"default_storage_engine":          stringVar(true),
+	"default_time_zone":               stringVar(false),
+	"event_scheduler":                 enumVar(true, "ON", "OFF", "DISABLED", "1", "0"),
+	"explicit_defaults_for_timestamp": boolVar(false),
+	"federated":                       boolVar(false),
+	"ft_min_word_len":                 intVar(1, 84, false),
+	"group_concat_max_len":            intVar(4, maxUint64, true),
+	"innodb_adaptive_hash_index":      boolVar(true),
+	"innodb_autoinc_lock_mode":        intVar(0, 2, false),
+	"innodb_buffer_pool_instances":    intVar(1, 64, false),
+	"innodb_buffer_pool_size":         sizeVar(5242880, maxUint64, true),
+	"innodb_flush_log_at_trx_commit":  intVar(0, 2, true),
+	"innodb_flush_method":             enumVar(false, "fsync", "O_DSYNC", "littlesync", "nosync", "O_DIRECT", "O_DIRECT_NO_FSYNC"),
+	"innodb_ft_max_token_size":        intVar(10, 84, false),
+	"innodb_ft_min_token_size":        intVar(0, 16, false),
+	"innodb_io_capacity":              intVar(100, maxUint64, true),
+	"innodb_io_capacity_max":          intVar(100, maxUint64, true),
+	"innodb_lock_wait_timeout":        intVar(1, 1073741824, true),
+	"innodb_log_file_size":            sizeVar(4194304, 549755813888, false),
+	"innodb_log_files_in_group":       intVar(2, 100, false),
+	"innodb_max_dirty_pages_pct":      floatVar(0, 99.999, true),
+	"innodb_open_files":               intVar(10, 4294967295, false),
+	"innodb_print_all_deadlocks":      boolVar(true),
+	"innodb_read_io_threads":          intVar(1, 64, false),
+	"innodb_thread_concurrency":       intVar(0, 1000, true),
+	"innodb_use_native_aio":           boolVar(false),
+	"innodb_write_io_threads":         intVar(1, 64, false),
+	"interactive_timeout":             intVar(1, 31536000, true),
+	"join_buffer_size":                sizeVar(128, maxUint64, true),
+	"key_buffer_size":                 sizeVar(8, maxUint64, true),
+	"local_infile":                    boolVar(true),
+	"lock_wait_timeout":               intVar(1, 31536000, true),
+	"log_bin_trust_function_creators": boolVar(true),
+	"log_error":                       stringVar(false),
+	"log_queries_not_using_indexes":   boolVar(true),
+	"log_timestamps":                  enumVar(true, "UTC", "SYSTEM"),
+	"long_query_time":                 floatVar(0, 31536000, true),
+	"lower_case_table_names":          intVar(0, 2, false),
+	"max_allowed_packet":              sizeVar(1024, 1073741824, true),
+	"max_binlog_size":                 sizeVar(4096, 1073741824, true),
+	"max_connect_errors":              intVar(1, maxUint64, true),
+	"max_connections":                 intVar(1, 100000, true),
+	"max_execution_time":              intVar(0, 4294967295, true),
+	"max_heap_table_size":             sizeVar(16384, maxUint64, true),
+	"net_read_timeout":                intVar(1, 31536000, true),
+	"net_write_timeout":               intVar(1, 31536000, true),
+	"open_files_limit":                intVar(0, 4294967295, false),
+	"performance_schema":              boolVar(false),
+	"plugin_load":                     stringVar(false),
+	"plugin_load_add":                 stringVar(false),
+	"read_buffer_size":                sizeVar(8192, 2147479552, true),
+	"read_rnd_buffer_size":            sizeVar(1, 2147483647, true),
+	"relay_log":                       stringVar(false),
+	"relay_log_index":                 stringVar(false),
+	"replicate_do_db":                 stringVar(false),
+	"replicate_do_table":              stringVar(false),
+	"replicate_ignore_db":             stringVar(false),
+	"replicate_ignore_table":          stringVar(false),
+	"replicate_wild_do_table":         stringVar(false),
+	"replicate_wild_ignore_table":     stringVar(false),
+	"skip_name_resolve":               boolVar(false),
+	"slave_net_timeout":               intVar(1, 31536000, true),
+	"slave_parallel_type":             enumVar(true, "DATABASE", "LOGICAL_CLOCK"),
+	"slave_parallel_workers":          intVar(0, 1024, true),
+	"slave_pending_jobs_size_max":     sizeVar(1024, maxUint64, true),
+	"slow_query_log":                  boolVar(true),
+	"slow_query_log_file":             stringVar(true),
+	"sort_buffer_size":                sizeVar(32768, maxUint64, true),
+	"sql_mode":                        setVar(true, sqlModes...),
+	"ssl_ca":                          stringVar(false),
+	"ssl_cert":                        stringVar(false),
+	"ssl_key":                         stringVar(false),
+	"sync_binlog":                     intVar(0, 4294967295, true),
+	"sync_master_info":                intVar(0, 4294967295, true),
+	"sync_relay_log":                  intVar(0, 4294967295, true),
+	"sync_relay_log_info":             intVar(0, 4294967295, true),
+	"table_definition_cache":          intVar(400, 524288, true),
+	"table_open_cache":                intVar(1, 524288, true),
+	"thread_cache_size":               intVar(0, 16384, true),
+	"tmp_table_size":                  sizeVar(1024, maxUint64, true),
+	"tmpdir":                          stringVar(false),
+	"transaction_isolation":           enumVar(true, "READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE"),
+	"wait_timeout":                    intVar(1, 31536000, true),
+}
+
+// mysql57Variables is the catalogue of the variables only in MySQL 5.7, or different from 8.0.
+var mysql57Variables = map[string]mysqlVariable{
+	"default_authentication_plugin": enumVar(false, "mysql_native_password", "sha256_password"),
+	"expire_logs_days":              intVar(0, 99, true),
+	"innodb_file_format":            deprecated(enumVar(true, "Antelope", "Barracuda")),
+	"innodb_large_prefix":           deprecated(boolVar(true)),
+	"innodb_log_buffer_size":        sizeVar(1048576, 4294967295, false),
+	"master_info_repository":        enumVar(true, "FILE", "TABLE"),
+	"query_cache_size":              deprecated(sizeVar(0, maxUint64, true)),
+	"query_cache_type":              deprecated(enumVar(true, "0", "1", "2", "OFF", "ON", "DEMAND")),
+	"relay_log_info_repository":     enumVar(true, "FILE", "TABLE"),
+	"skip_host_cache":               boolVar(false),
+	"slave_rows_search_algorithms":  setVar(true, "TABLE_SCAN", "INDEX_SCAN", "HASH_SCAN"),
+	"tx_isolation":                  deprecated(enumVar(true, "READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE")),
+}
+
+// mysql80Variables is the catalogue of the variables only in MySQL 8.0, or different from 5.7.
+var mysql80Variables = map[string]mysqlVariable{
+	"binlog_expire_logs_seconds":    intVar(0, 4294967295, true),
+	"default_authentication_plugin": enumVar(false, "mysql_native_password", "sha256_password", "caching_sha2_password"),
+	"expire_logs_days":              deprecated(intVar(0, 99, true)),
+	"innodb_dedicated_server":       boolVar(false),
+	"innodb_log_buffer_size":        sizeVar(262144, 4294967295, true),
+	"master_info_repository":        deprecated(enumVar(true, "FILE", "TABLE")),
+	"relay_log_info_repository":     deprecated(enumVar(true, "FILE", "TABLE")),
+	"skip_host_cache":               deprecated(boolVar(false)),
+	"slave_rows_search_algorithms":  deprecated(setVar(true, "TABLE_SCAN", "INDEX_SCAN", "HASH_SCAN")),
+}
+
+// operatorOwnedVariables is the variables managed by the operator and xenon, they cannot
+// be set in the mysqlConf/pluginConf.
+var operatorOwnedVariables = []string{
+	"enforce_gtid_consistency",
+	"gtid_mode",
+	"log_bin",
+	"log_slave_updates",
+	"read_only",
+	"server_id",
+	"skip_slave_start",
+	"super_read_only",
+}
+
+// operatorOwnedPrefix is the prefix of the semi-sync variables managed by the operator.
+const operatorOwnedPrefix = "rpl_semi_sync_"
+
+var sizeValueRegexp = regexp.MustCompile(`^([0-9]+)([kKmMgG]?)$`)
+
+// normalizeVariableName converts the key in my.cnf to the name of the variable, and
+// returns whether the key has the loose prefix.
+func normalizeVariableName(key string) (string, bool) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+	if strings.HasPrefix(name, "loose_") {
+		return strings.TrimPrefix(name, "loose_"), true
+	}
+	return name, false
+}
+
+// lookupMysqlVariable returns the variable in the catalogue of the version.
+func lookupMysqlVariable(version, name string) (mysqlVariable, bool) {
+	versionVariables := mysql57Variables
+	if version == "8.0" {
+		versionVariables = mysql80Variables
+	}
+	if v, ok := versionVariables[name]; ok {
+		return v, true
+	}
+	v, ok := commonVariables[name]
+	return v, ok
+}
+
+// GetMysqlMajorVersion returns the major version of mysql, 5.7 or 8.0, it is got from
+// the image if the mysqlVersion is not set.
+func GetMysqlMajorVersion(mysqlVersion, image string) string {
+	switch {
+	case mysqlVersion == "8.0" || mysqlVersion == "5.7":
+		return mysqlVersion
+	case strings.Contains(image, "8.0"):
+		return "8.0"
+	}
+	return "5.7"
+}
+
+// onlineMysqlVariables is the variables applied online by SET GLOBAL. The other dynamic
+// variables, such as binlog_format and sql_mode, only take effect for the new sessions or
+// conflict with the operator and xenon, they are applied by restarting the nodes.
+var onlineMysqlVariables = []string{
+	"autocommit",
+	"binlog_cache_size",
+	"binlog_expire_logs_seconds",
+	"binlog_stmt_cache_size",
+	"character_set_server",
+	"collation_server",
+	"connect_timeout",
+	"expire_logs_days",
+	"group_concat_max_len",
+	"innodb_adaptive_hash_index",
+	"innodb_buffer_pool_size",
+	"innodb_flush_log_at_trx_commit",
+	"innodb_io_capacity",
+	"innodb_io_capacity_max",
+	"innodb_lock_wait_timeout",
+	"innodb_max_dirty_pages_pct",
+	"innodb_print_all_deadlocks",
+	"innodb_thread_concurrency",
+	"interactive_timeout",
+	"join_buffer_size",
+	"key_buffer_size",
+	"lock_wait_timeout",
+	"log_bin_trust_function_creators",
+	"log_queries_not_using_indexes",
+	"long_query_time",
+	"max_allowed_packet",
+	"max_connect_errors",
+	"max_connections",
+	"max_execution_time",
+	"max_heap_table_size",
+	"net_read_timeout",
+	"net_write_timeout",
+	"read_buffer_size",
+	"read_rnd_buffer_size",
+	"slave_net_timeout",
+	"slow_query_log",
+	"sort_buffer_size",
+	"sync_binlog",
+	"sync_master_info",
+	"sync_relay_log",
+	"sync_relay_log_info",
+	"table_definition_cache",
+	"table_open_cache",
+	"thread_cache_size",
+	"tmp_table_size",
+	"transaction_isolation",
+	"wait_timeout",
+	"audit_log_policy",
+	"audit_log_rotate_on_size",
+	"audit_log_rotations",
+	"connection_control_failed_connections_threshold",
+	"connection_control_max_connection_delay",
+	"connection_control_min_connection_delay",
+}
+
+// IsDynamicMysqlVariable returns true if the variable of the version is applied online by SET GLOBAL.
+func IsDynamicMysqlVariable(version, key string) bool {
+	name, _ := normalizeVariableName(key)
+	v, ok := lookupMysqlVariable(version, name)
+	if !ok || !v.dynamic {
+		return false
+	}
+	for _, online := range onlineMysqlVariables {
+		if name == online {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMysqlVariable validates the key and the value of my.cnf of the version, returns
+// the warning if the variable is deprecated or unknown. The unknown variables are allowed,
+// as the catalogue does not cover all the variables of the plugins and the minor versions.
+func validateMysqlVariable(version, key, value string) (string, error) {
+	name, loose := normalizeVariableName(key)
+	if strings.HasPrefix(name, operatorOwnedPrefix) {
+		return "", fmt.Errorf("%s is managed by the operator", key)
+	}
+	for _, owned := range operatorOwnedVariables {
+		if name == owned {
+			return "", fmt.Errorf("%s is managed by the operator", key)
+		}
+	}
+	v, ok := lookupMysqlVariable(version, name)
+	if !ok {
+		// mysqld ignores the unknown variables with the loose prefix.
+		if loose {
+			return "", nil
+		}
+		return fmt.Sprintf("%s is not a known variable of MySQL %s", key, version), nil
+	}
+	if err := v.validate(value); err != nil {
+		return "", fmt.Errorf("invalid value of %s: %s", key, err)
+	}
+	if v.deprecated {
+		return fmt.Sprintf("%s is deprecated in MySQL %s", key, version), nil
+	}
+	return "", nil
+}
+
+// validate checks whether the value matches the type and range of the variable.
+func (v mysqlVariable) validate(value string) error {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	switch v.typ {
+	case varBool:
+		switch strings.ToUpper(value) {
+		case "", "ON", "OFF", "1", "0", "TRUE", "FALSE":
+			return nil
+		}
+		return fmt.Errorf("%q is not a boolean", value)
+	case varInt, varSize, varFloat:
+		var n float64
+		var err error
+		if m := sizeValueRegexp.FindStringSubmatch(value); m != nil && (v.typ == varSize || len(m[2]) == 0) {
+			n, err = strconv.ParseFloat(m[1], 64)
+			switch strings.ToUpper(m[2]) {
+			case "K":
+				n *= 1 << 10
+			case "M":
+				n *= 1 << 20
+			case "G":
+				n *= 1 << 30
+			}
+		} else if v.typ == varFloat {
+			n, err = strconv.ParseFloat(value, 64)
+		} else {
+			return fmt.Errorf("%q is not a %s", value, v.typ)
+		}
+		if err != nil {
+			return fmt.Errorf("%q is not a %s", value, v.typ)
+		}
+		if n < v.min || n > v.max {
+			return fmt.Errorf("%s is out of range [%v, %v]", value, v.min, v.max)
+		}
+		return nil
+	case varEnum:
+		if !containsFold(v.values, value) {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(v.values, ","))
+		}
+		return nil
+	case varSet:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); len(item) != 0 && !containsFold(v.values, item) {
+				return fmt.Errorf("%q is not one of %s", item, strings.Join(v.values, ","))
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
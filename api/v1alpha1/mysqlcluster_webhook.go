@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -74,6 +75,12 @@ func (r *MysqlCluster) ValidateCreate() error {
 	if err := r.validateMysqlVersion(); err != nil {
 		return err
 	}
+	if err := r.validateMysqlConf(nil); err != nil {
+		return err
+	}
+	if err := r.validateSemiSyncOpts(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -112,6 +119,12 @@ func (r *MysqlCluster) ValidateUpdate(old runtime.Object) error {
 	if err := r.ValidataRo(); err != nil {
 		return err
 	}
+	if err := r.validateMysqlConf(oldCluster); err != nil {
+		return err
+	}
+	if err := r.validateSemiSyncOpts(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -225,6 +238,54 @@ func (r *MysqlCluster) validateMysqlVersion() error {
 	return nil
 }
 
+// Validate the keys and values of mysqlConf and pluginConf with the variables of the MySQL version,
+// the invalid values and the variables managed by the operator are forbidden, the unknown and
+// deprecated variables are warned. On update, the unchanged configs are skipped.
+func (r *MysqlCluster) validateMysqlConf(oldCluster *MysqlCluster) error {
+	version := GetMysqlMajorVersion(r.Spec.MysqlVersion, r.Spec.MysqlOpts.Image)
+	errs := []string{}
+	check := func(field string, conf, oldConf MysqlConf) {
+		keys := make([]string, 0, len(conf))
+		for key := range conf {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if old, ok := oldConf[key]; ok && old == conf[key] {
+				continue
+			}
+			warning, err := validateMysqlVariable(version, key, conf[key])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("spec.mysqlOpts.%s: %s", field, err))
+				continue
+			}
+			if len(warning) != 0 {
+				mysqlclusterlog.Info("warning: "+warning, "name", r.Name, "field", field)
+			}
+		}
+	}
+
+	var oldMysqlConf, oldPluginConf MysqlConf
+	if oldCluster != nil {
+		oldMysqlConf = oldCluster.Spec.MysqlOpts.MysqlConf
+		oldPluginConf = oldCluster.Spec.MysqlOpts.PluginConf
+	}
+	check("mysqlConf", r.Spec.MysqlOpts.MysqlConf, oldMysqlConf)
+	check("pluginConf", r.Spec.MysqlOpts.PluginConf, oldPluginConf)
+	if len(errs) != 0 {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("%s", strings.Join(errs, "; ")))
+	}
+	return nil
+}
+
+// validateSemiSyncOpts validates the degrade timeout is positive.
+func (r *MysqlCluster) validateSemiSyncOpts() error {
+	if r.Spec.SemiSyncOpts.DegradeTimeout != nil && r.Spec.SemiSyncOpts.DegradeTimeout.Duration <= 0 {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("semiSyncOpts.degradeTimeout must be positive"))
+	}
+	return nil
+}
+
 // Validate BothS3NFS
 func (r *MysqlCluster) validBothS3NFS() error {
 	if r.Spec.BothS3NFS != nil &&
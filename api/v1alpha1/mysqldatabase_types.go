@@ -0,0 +1,145 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseSpec defines the desired state of MysqlDatabase.
+type DatabaseSpec struct {
+	// Database is the name of the database to be created.
+	// This field should be immutable.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]{2,26}$"
+	Database string `json:"database,omitempty"`
+
+	// DatabaseOwner Contains parameters about the cluster bound by database.
+	// +kubebuilder:validation:Required
+	DatabaseOwner UserOwner `json:"databaseOwner,omitempty"`
+
+	// CharacterSet is the default character set of the database.
+	// +optional
+	// +kubebuilder:default:="utf8mb4"
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]+$"
+	CharacterSet string `json:"characterSet,omitempty"`
+
+	// Collation is the default collation of the database, the default collation of
+	// the character set is used if it is not set.
+	// +optional
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]*$"
+	Collation string `json:"collation,omitempty"`
+
+	// DeletionPolicy is the policy of the database when the MysqlDatabase is deleted,
+	// Retain keeps the database, Drop drops the database if it was created by the operator.
+	// +optional
+	// +kubebuilder:default:="Retain"
+	// +kubebuilder:validation:Enum=Retain;Drop
+	DeletionPolicy DatabaseDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DatabaseDeletionPolicy is the policy of the database when the MysqlDatabase is deleted.
+type DatabaseDeletionPolicy string
+
+const (
+	// DatabaseDeletionRetain keeps the database in MySQL.
+	DatabaseDeletionRetain DatabaseDeletionPolicy = "Retain"
+	// DatabaseDeletionDrop drops the database in MySQL if it was created by the operator.
+	DatabaseDeletionDrop DatabaseDeletionPolicy = "Drop"
+)
+
+// DatabaseStatus defines the observed state of MysqlDatabase.
+type DatabaseStatus struct {
+	// Conditions represents the MysqlDatabase resource conditions list.
+	// +optional
+	Conditions []MySQLDatabaseCondition `json:"conditions,omitempty"`
+
+	// Exists is true if the database exists in MySQL.
+	// +optional
+	Exists bool `json:"exists,omitempty"`
+	// Created is true if the database was created by the operator, a pre-existing
+	// database is adopted but never dropped.
+	// +optional
+	Created bool `json:"created,omitempty"`
+	// CharacterSet is the default character set of the database in MySQL.
+	// +optional
+	CharacterSet string `json:"characterSet,omitempty"`
+	// Collation is the default collation of the database in MySQL.
+	// +optional
+	Collation string `json:"collation,omitempty"`
+	// SizeBytes is the size of the data and the indexes of the database in information_schema.
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+	// LastCheckTime is the last time the database was checked.
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// MysqlDatabaseConditionType defines the condition types of a MysqlDatabase resource.
+type MysqlDatabaseConditionType string
+
+const (
+	// MySQLDatabaseReady means the MySQL database is created.
+	MySQLDatabaseReady MysqlDatabaseConditionType = "Ready"
+)
+
+// MySQLDatabaseCondition defines the condition struct for a MysqlDatabase resource.
+type MySQLDatabaseCondition struct {
+	// Type of MysqlDatabase condition.
+	Type MysqlDatabaseConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// The reason for the condition's last transition.
+	Reason string `json:"reason"`
+	// A human readable message indicating details about the transition.
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Database",type="string",JSONPath=".spec.database",description="The name of the MySQL database"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.databaseOwner.clusterName",description="The cluster of the database"
+// +kubebuilder:printcolumn:name="NameSpace",type="string",JSONPath=".spec.databaseOwner.nameSpace",description="The namespace of the database"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="The availability of the database"
+// +kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".status.sizeBytes",description="The size of the database in bytes"
+// +kubebuilder:printcolumn:name="CharacterSet",type="string",priority=1,JSONPath=".status.characterSet",description="The character set of the database"
+// +kubebuilder:printcolumn:name="Collation",type="string",priority=1,JSONPath=".status.collation",description="The collation of the database"
+// MysqlDatabase is the Schema for the databases API.
+type MysqlDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseSpec   `json:"spec,omitempty"`
+	Status DatabaseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MysqlDatabaseList contains a list of MysqlDatabase.
+type MysqlDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlDatabase `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlDatabase{}, &MysqlDatabaseList{})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleSpec defines the desired state of MysqlRole.
+type RoleSpec struct {
+	// Role is the name of the role to be operated.
+	// This field should be immutable.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]{2,26}$"
+	Role string `json:"role,omitempty"`
+
+	// RoleOwner Contains parameters about the cluster bound by role.
+	// +kubebuilder:validation:Required
+	RoleOwner UserOwner `json:"roleOwner,omitempty"`
+
+	// Permissions is the list of privileges that the role has in the specified database.
+	// +optional
+	Permissions []UserPermission `json:"permissions,omitempty"`
+}
+
+// RoleStatus defines the observed state of MysqlRole.
+type RoleStatus struct {
+	// Conditions represents the MysqlRole resource conditions list.
+	// +optional
+	Conditions []MySQLRoleCondition `json:"conditions,omitempty"`
+
+	// GrantDrifts contains the differences between the grants in MySQL and the spec found in the
+	// last reconciliation, the unexpected privileges are revoked and the missing ones are granted.
+	// +optional
+	GrantDrifts []GrantDrift `json:"grantDrifts,omitempty"`
+	// LastDriftTime is the last time the grants in MySQL drifted from the spec.
+	// +optional
+	LastDriftTime *metav1.Time `json:"lastDriftTime,omitempty"`
+}
+
+// MysqlRoleConditionType defines the condition types of a MysqlRole resource.
+type MysqlRoleConditionType string
+
+const (
+	// MySQLRoleReady means the MySQL role is created and granted.
+	MySQLRoleReady MysqlRoleConditionType = "Ready"
+)
+
+// MySQLRoleCondition defines the condition struct for a MysqlRole resource.
+type MySQLRoleCondition struct {
+	// Type of MysqlRole condition.
+	Type MysqlRoleConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// The reason for the condition's last transition.
+	Reason string `json:"reason"`
+	// A human readable message indicating details about the transition.
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="RoleName",type="string",JSONPath=".spec.role",description="The name of the MySQL role"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.roleOwner.clusterName",description="The cluster of the role"
+// +kubebuilder:printcolumn:name="NameSpace",type="string",JSONPath=".spec.roleOwner.nameSpace",description="The namespace of the role"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="The availability of the role"
+// MysqlRole is the Schema for the roles API.
+type MysqlRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoleSpec   `json:"spec,omitempty"`
+	Status RoleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MysqlRoleList contains a list of MysqlRole.
+type MysqlRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlRole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlRole{}, &MysqlRoleList{})
+}
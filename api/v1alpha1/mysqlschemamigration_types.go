@@ -0,0 +1,154 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchemaMigrationSpec defines the desired state of MysqlSchemaMigration.
+type SchemaMigrationSpec struct {
+	// Database is the name of the database that the migrations are applied to,
+	// the database should exist.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]{2,26}$"
+	Database string `json:"database,omitempty"`
+
+	// MigrationOwner Contains parameters about the cluster bound by migration.
+	// +kubebuilder:validation:Required
+	MigrationOwner UserOwner `json:"migrationOwner,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap which contains the SQL files. The keys ending
+	// with `.sql` are applied in the lexical order of the keys, and the version of a migration is
+	// the key without the `.sql` suffix, e.g. `V001__create_users.sql`.
+	// +kubebuilder:validation:Required
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// OnlineSchemaChange runs the migrations which contain only one ALTER TABLE statement
+	// with pt-online-schema-change in a job, the other migrations are applied directly.
+	// +optional
+	OnlineSchemaChange *OnlineSchemaChange `json:"onlineSchemaChange,omitempty"`
+}
+
+// OnlineSchemaChange defines the online tool which runs the long ALTER TABLE statements.
+type OnlineSchemaChange struct {
+	// Image is the image which contains pt-online-schema-change.
+	// +optional
+	// +kubebuilder:default:="percona/percona-toolkit:3.5.0"
+	Image string `json:"image,omitempty"`
+
+	// Args is the extra arguments of pt-online-schema-change, e.g. `--max-load=Threads_running=50`.
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+// SchemaMigrationStatus defines the observed state of MysqlSchemaMigration.
+type SchemaMigrationStatus struct {
+	// Conditions represents the MysqlSchemaMigration resource conditions list.
+	// +optional
+	Conditions []MySQLSchemaMigrationCondition `json:"conditions,omitempty"`
+
+	// AppliedMigrations is the list of the migrations recorded in the tracking table.
+	// +optional
+	AppliedMigrations []AppliedMigration `json:"appliedMigrations,omitempty"`
+	// PendingMigrations is the number of the migrations waiting to be applied.
+	// +optional
+	PendingMigrations int32 `json:"pendingMigrations,omitempty"`
+	// FailedMigration is the migration which failed, the following migrations are not applied
+	// until it is changed in the ConfigMap.
+	// +optional
+	FailedMigration *FailedMigration `json:"failedMigration,omitempty"`
+	// OnlineSchemaChangeJob is the name of the running pt-online-schema-change job.
+	// +optional
+	OnlineSchemaChangeJob string `json:"onlineSchemaChangeJob,omitempty"`
+}
+
+// AppliedMigration is a migration applied to the database.
+type AppliedMigration struct {
+	// Version is the version of the migration.
+	Version string `json:"version"`
+	// Checksum is the sha256 checksum of the SQL file.
+	Checksum string `json:"checksum"`
+	// AppliedTime is the time when the migration was applied.
+	AppliedTime metav1.Time `json:"appliedTime,omitempty"`
+}
+
+// FailedMigration is a migration which failed to be applied.
+type FailedMigration struct {
+	// Version is the version of the migration.
+	Version string `json:"version"`
+	// Checksum is the sha256 checksum of the SQL file.
+	Checksum string `json:"checksum"`
+	// Message is the error message of the migration.
+	Message string `json:"message,omitempty"`
+	// FailedTime is the time when the migration failed.
+	FailedTime metav1.Time `json:"failedTime,omitempty"`
+}
+
+// MysqlSchemaMigrationConditionType defines the condition types of a MysqlSchemaMigration resource.
+type MysqlSchemaMigrationConditionType string
+
+const (
+	// MySQLSchemaMigrationReady means all the migrations are applied.
+	MySQLSchemaMigrationReady MysqlSchemaMigrationConditionType = "Ready"
+)
+
+// MySQLSchemaMigrationCondition defines the condition struct for a MysqlSchemaMigration resource.
+type MySQLSchemaMigrationCondition struct {
+	// Type of MysqlSchemaMigration condition.
+	Type MysqlSchemaMigrationConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// The reason for the condition's last transition.
+	Reason string `json:"reason"`
+	// A human readable message indicating details about the transition.
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Database",type="string",JSONPath=".spec.database",description="The name of the MySQL database"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.migrationOwner.clusterName",description="The cluster of the migration"
+// +kubebuilder:printcolumn:name="ConfigMap",type="string",JSONPath=".spec.configMapName",description="The ConfigMap of the SQL files"
+// +kubebuilder:printcolumn:name="Pending",type="integer",JSONPath=".status.pendingMigrations",description="The number of the pending migrations"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether all the migrations are applied"
+// +kubebuilder:printcolumn:name="Failed",type="string",priority=1,JSONPath=".status.failedMigration.version",description="The failed migration"
+// MysqlSchemaMigration is the Schema for the schema migrations API.
+type MysqlSchemaMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaMigrationSpec   `json:"spec,omitempty"`
+	Status SchemaMigrationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MysqlSchemaMigrationList contains a list of MysqlSchemaMigration.
+type MysqlSchemaMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlSchemaMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlSchemaMigration{}, &MysqlSchemaMigrationList{})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SwitchoverSpec defines the desired state of MysqlSwitchover.
+type SwitchoverSpec struct {
+	// ClusterName is the name of the cluster in the namespace of the switchover.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// TargetPod is the name of the pod to be promoted to the leader.
+	// +kubebuilder:validation:Required
+	TargetPod string `json:"targetPod"`
+
+	// Timeout is the time to wait for the target to catch up and become the leader,
+	// the switchover is rolled back if the old leader is still the leader after the timeout.
+	// +optional
+	// +kubebuilder:default:="1m"
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SwitchoverPhase is the phase of the switchover.
+type SwitchoverPhase string
+
+const (
+	// SwitchoverDraining means the old leader is set read-only, and the target is catching up.
+	SwitchoverDraining SwitchoverPhase = "Draining"
+	// SwitchoverPromoting means the target is trying to be the leader.
+	SwitchoverPromoting SwitchoverPhase = "Promoting"
+	// SwitchoverSucceeded means the target has become the leader.
+	SwitchoverSucceeded SwitchoverPhase = "Succeeded"
+	// SwitchoverFailed means the switchover failed, and the old leader was not changed or
+	// cannot be restored.
+	SwitchoverFailed SwitchoverPhase = "Failed"
+	// SwitchoverRolledBack means the switchover failed, and the old leader was restored writable.
+	SwitchoverRolledBack SwitchoverPhase = "RolledBack"
+)
+
+// SwitchoverStatus defines the observed state of MysqlSwitchover.
+type SwitchoverStatus struct {
+	// Phase is the phase of the switchover.
+	// +optional
+	Phase SwitchoverPhase `json:"phase,omitempty"`
+	// Message is the detail of the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// FromLeader is the pod of the leader before the switchover.
+	// +optional
+	FromLeader string `json:"fromLeader,omitempty"`
+	// StartTime is the time when the old leader started draining.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// PromoteTime is the time when the target was asked to be the leader.
+	// +optional
+	PromoteTime *metav1.Time `json:"promoteTime,omitempty"`
+	// CompletionTime is the time when the switchover finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName",description="The cluster of the switchover"
+// +kubebuilder:printcolumn:name="From",type="string",JSONPath=".status.fromLeader",description="The leader before the switchover"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.targetPod",description="The pod to be promoted to the leader"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the switchover"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// MysqlSwitchover is the Schema for the switchovers API.
+type MysqlSwitchover struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SwitchoverSpec   `json:"spec,omitempty"`
+	Status SwitchoverStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MysqlSwitchoverList contains a list of MysqlSwitchover.
+type MysqlSwitchoverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlSwitchover `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlSwitchover{}, &MysqlSwitchoverList{})
+}
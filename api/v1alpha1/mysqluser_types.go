@@ -52,6 +52,16 @@ type UserSpec struct {
 	// +optional
 	Permissions []UserPermission `json:"permissions,omitempty"`
 
+	// Roles is the list of the roles granted to the user, the roles are defined by MysqlRole.
+	// MySQL 5.7 does not support roles, the privileges of the roles are granted to the user directly.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// DefaultRoles is the list of the roles activated when the user connects, it must be a subset
+	// of Roles. Only supported in MySQL 8.0.
+	// +optional
+	DefaultRoles []string `json:"defaultRoles,omitempty"`
+
 	// WithGrantOption is the flag to indicate whether the user has grant option.
 	// +optional
 	// +kubebuilder:default:=false
@@ -62,6 +72,72 @@ type UserSpec struct {
 	// https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 	// +kubebuilder:default:={type: "NONE"}
 	TLSOptions TLSOptions `json:"tlsOptions,omitempty"`
+
+	// RotationPolicy is the policy of rotating the password of the user.
+	// +optional
+	RotationPolicy *RotationPolicy `json:"rotationPolicy,omitempty"`
+
+	// ResourceLimits limits the resources that the user can use.
+	// https://dev.mysql.com/doc/refman/8.0/en/user-resources.html
+	// +optional
+	ResourceLimits *UserResourceLimits `json:"resourceLimits,omitempty"`
+
+	// AccountLocked is true if the account is locked, the user cannot connect to MySQL.
+	// +optional
+	// +kubebuilder:default:=false
+	AccountLocked bool `json:"accountLocked,omitempty"`
+
+	// PasswordOptions contains the password management options of the user.
+	// https://dev.mysql.com/doc/refman/8.0/en/password-management.html
+	// +optional
+	PasswordOptions *UserPasswordOptions `json:"passwordOptions,omitempty"`
+}
+
+// UserResourceLimits defines the resource limits of the user, 0 means no limit.
+type UserResourceLimits struct {
+	// MaxQueriesPerHour is the number of queries the user can issue per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxQueriesPerHour int32 `json:"maxQueriesPerHour,omitempty"`
+
+	// MaxUpdatesPerHour is the number of updates the user can issue per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUpdatesPerHour int32 `json:"maxUpdatesPerHour,omitempty"`
+
+	// MaxConnectionsPerHour is the number of times the user can connect per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxConnectionsPerHour int32 `json:"maxConnectionsPerHour,omitempty"`
+
+	// MaxUserConnections is the number of simultaneous connections of the user.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUserConnections int32 `json:"maxUserConnections,omitempty"`
+}
+
+// UserPasswordOptions defines the password management options of the user.
+type UserPasswordOptions struct {
+	// ExpireDays is the lifetime of the password in days, 0 means the password never expires.
+	// The global expiration policy default_password_lifetime is used if it is not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ExpireDays *int32 `json:"expireDays,omitempty"`
+
+	// FailedLoginAttempts is the number of consecutive failed logins that cause the account
+	// to be locked temporarily, 0 disables the tracking. Only supported in MySQL 8.0.19 or later.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=32767
+	FailedLoginAttempts int32 `json:"failedLoginAttempts,omitempty"`
+
+	// PasswordLockDays is the number of days the account is locked after too many consecutive
+	// failed logins, -1 means the account is locked until it is unlocked. Only supported in MySQL
+	// 8.0.19 or later.
+	// +optional
+	// +kubebuilder:validation:Minimum=-1
+	// +kubebuilder:validation:Maximum=32767
+	PasswordLockDays int32 `json:"passwordLockDays,omitempty"`
 }
 
 type UserOwner struct {
@@ -105,6 +181,24 @@ type TLSOptions struct {
 	// TODO: support Issuer, Subject and Cipher.
 }
 
+// RotationPolicy defines the policy of rotating the password of the user.
+type RotationPolicy struct {
+	// Interval is the interval between the password rotations, such as 720h.
+	// +kubebuilder:validation:Required
+	Interval metav1.Duration `json:"interval"`
+
+	// GeneratePassword is true if the operator generates a new password and writes it into the secret
+	// when the interval elapses. Otherwise the password is rotated when it is changed in the secret.
+	// +optional
+	GeneratePassword bool `json:"generatePassword,omitempty"`
+
+	// RetainPeriod is how long the old password is retained after the rotation on MySQL 8.0,
+	// the applications can roll over to the new password in the period.
+	// +optional
+	// +kubebuilder:default:="1h"
+	RetainPeriod metav1.Duration `json:"retainPeriod,omitempty"`
+}
+
 // UserStatus defines the observed state of MysqlUser.
 type UserStatus struct {
 	// Conditions represents the MysqlUser resource conditions list.
@@ -115,6 +209,80 @@ type UserStatus struct {
 	AllowedHosts []string `json:"allowedHosts,omitempty"`
 	// Identifies the users changed or not.
 	Revision string `json:"revision,omitempty"`
+
+	// GrantDrifts contains the differences between the grants in MySQL and the spec found in the
+	// last reconciliation, the unexpected privileges are revoked and the missing ones are granted.
+	// +optional
+	GrantDrifts []GrantDrift `json:"grantDrifts,omitempty"`
+	// LastDriftTime is the last time the grants in MySQL drifted from the spec.
+	// +optional
+	LastDriftTime *metav1.Time `json:"lastDriftTime,omitempty"`
+
+	// PasswordRotation is the status of the password rotation.
+	// +optional
+	PasswordRotation *PasswordRotationStatus `json:"passwordRotation,omitempty"`
+
+	// Nodes contains the sync state of the user on each node of the cluster other than the leader,
+	// including the read-only nodes.
+	// +optional
+	Nodes []UserNodeStatus `json:"nodes,omitempty"`
+}
+
+// UserNodeSyncState is the sync state of the user on a node.
+type UserNodeSyncState string
+
+const (
+	// UserNodeSynced means the user and its grants on the node are the same as the leader.
+	UserNodeSynced UserNodeSyncState = "Synced"
+	// UserNodeDiverged means the user or its grants on the node differ from the leader,
+	// and failed to be repaired.
+	UserNodeDiverged UserNodeSyncState = "Diverged"
+	// UserNodeUnknown means the node cannot be checked, e.g. the node is unreachable.
+	UserNodeUnknown UserNodeSyncState = "Unknown"
+)
+
+// UserNodeStatus defines the sync state of the user on a node.
+type UserNodeStatus struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+	// State is the sync state of the user on the node.
+	State UserNodeSyncState `json:"state"`
+	// Message is the reason why the node is not synced.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastRepairTime is the last time the user was repaired on the node.
+	// +optional
+	LastRepairTime *metav1.Time `json:"lastRepairTime,omitempty"`
+}
+
+// PasswordRotationStatus defines the status of the password rotation.
+type PasswordRotationStatus struct {
+	// LastRotationTime is the last time the password was rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// NextRotationTime is the time the password should be rotated.
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+	// OldPasswordDiscardTime is the time the old password retained on MySQL 8.0 is discarded.
+	// +optional
+	OldPasswordDiscardTime *metav1.Time `json:"oldPasswordDiscardTime,omitempty"`
+	// PasswordRevision identifies the password changed or not.
+	// +optional
+	PasswordRevision string `json:"passwordRevision,omitempty"`
+}
+
+// GrantDrift defines the difference between the privileges of user@host on an object and the spec.
+type GrantDrift struct {
+	// Host is the host of the user.
+	Host string `json:"host"`
+	// Object is the database and table of the privileges, such as `db`.*.
+	Object string `json:"object"`
+	// Missing is the privileges in the spec but not granted in MySQL.
+	// +optional
+	Missing []string `json:"missing,omitempty"`
+	// Unexpected is the privileges granted in MySQL but not in the spec.
+	// +optional
+	Unexpected []string `json:"unexpected,omitempty"`
 }
 
 // MysqlUserConditionType defines the condition types of a MysqlUser resource.
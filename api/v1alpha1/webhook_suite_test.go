@@ -155,3 +155,116 @@ func TestValidateUpdate(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+// test validateMysqlConf for webhook
+func TestValidateMysqlConf(t *testing.T) {
+	// unknown variable.
+	{
+		mysqlcluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					MysqlConf: MysqlConf{"max_connection": "1024"},
+				},
+			},
+		}
+		assert.NoError(t, mysqlcluster.validateMysqlConf(nil))
+	}
+	// unknown variable with the loose prefix.
+	{
+		mysqlcluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					MysqlConf: MysqlConf{"loose-unknown-plugin-option": "1"},
+				},
+			},
+		}
+		assert.NoError(t, mysqlcluster.validateMysqlConf(nil))
+	}
+	// out of range.
+	{
+		mysqlcluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					MysqlConf: MysqlConf{"max_connections": "0"},
+				},
+			},
+		}
+		assert.Error(t, mysqlcluster.validateMysqlConf(nil))
+	}
+	{
+		mysqlcluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					MysqlConf: MysqlConf{
+						"max_connections":       "1024",
+						"max-allowed-packet":    "16M",
+						"transaction_isolation": "READ-COMMITTED",
+					},
+					PluginConf: MysqlConf{"audit_log_rotations": "6"},
+				},
+			},
+		}
+		assert.NoError(t, mysqlcluster.validateMysqlConf(nil))
+	}
+	// managed by the operator.
+	{
+		for _, key := range []string{"gtid-mode", "log_bin", "read_only", "rpl_semi_sync_master_timeout"} {
+			mysqlcluster := &MysqlCluster{
+				Spec: MysqlClusterSpec{
+					MysqlVersion: "5.7",
+					MysqlOpts: MysqlOpts{
+						PluginConf: MysqlConf{key: "1"},
+					},
+				},
+			}
+			assert.Error(t, mysqlcluster.validateMysqlConf(nil), key)
+		}
+	}
+	// the variables of the version, the unknown and deprecated variables are warned.
+	{
+		warning, err := validateMysqlVariable("5.7", "binlog_expire_logs_seconds", "86400")
+		assert.NoError(t, err)
+		assert.Equal(t, "binlog_expire_logs_seconds is not a known variable of MySQL 5.7", warning)
+		warning, err = validateMysqlVariable("8.0", "binlog_expire_logs_seconds", "86400")
+		assert.NoError(t, err)
+		assert.Empty(t, warning)
+		_, err = validateMysqlVariable("8.0", "binlog_expire_logs_seconds", "-1")
+		assert.Error(t, err)
+		warning, err = validateMysqlVariable("8.0", "expire_logs_days", "7")
+		assert.NoError(t, err)
+		assert.Equal(t, "expire_logs_days is deprecated in MySQL 8.0", warning)
+	}
+	// the unchanged configs are skipped on update.
+	{
+		oldCluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					MysqlConf: MysqlConf{"max_connections": "0"},
+				},
+			},
+		}
+		mysqlcluster := oldCluster.DeepCopy()
+		mysqlcluster.Spec.MysqlOpts.MysqlConf["wait_timeout"] = "60"
+		assert.NoError(t, mysqlcluster.validateMysqlConf(oldCluster))
+		mysqlcluster.Spec.MysqlOpts.MysqlConf["wait_timeout"] = "0"
+		assert.Error(t, mysqlcluster.validateMysqlConf(oldCluster))
+	}
+}
+
+func TestIsDynamicMysqlVariable(t *testing.T) {
+	assert.True(t, IsDynamicMysqlVariable("5.7", "max-connections"))
+	assert.True(t, IsDynamicMysqlVariable("8.0", "binlog_expire_logs_seconds"))
+	assert.False(t, IsDynamicMysqlVariable("5.7", "binlog_expire_logs_seconds"))
+	// the dynamic variables not safe to apply online.
+	for _, key := range []string{"binlog_format", "sql_mode", "event_scheduler", "slave_parallel_workers"} {
+		assert.False(t, IsDynamicMysqlVariable("5.7", key), key)
+	}
+	// the static and unknown variables.
+	assert.False(t, IsDynamicMysqlVariable("5.7", "innodb_log_file_size"))
+	assert.False(t, IsDynamicMysqlVariable("5.7", "max_connection"))
+}
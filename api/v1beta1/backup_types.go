@@ -40,6 +40,16 @@ type BackupSpec struct {
 	BackupSchedule *BackupSchedule `json:"schedule,omitempty"`
 	// Backup Storage
 	BackupOpts BackupOps `json:"backupops,omitempty"`
+	// Verify the backup by restoring it in a scratch pod and running the sanity checks.
+	// +optional
+	Verification *BackupVerification `json:"verification,omitempty"`
+}
+
+type BackupVerification struct {
+	// The sanity queries run after CHECK TABLE of all the tables,
+	// the verification fails if any of them fails.
+	// +optional
+	Queries []string `json:"queries,omitempty"`
 }
 
 type BackupOps struct {
@@ -48,6 +58,24 @@ type BackupOps struct {
 	BackupHost string `json:"host,omitempty"`
 	S3         *S3    `json:"s3,omitempty"`
 	NFS        *NFS   `json:"nfs,omitempty"`
+	// +optional
+	PVC *PVC `json:"pvc,omitempty"`
+	// +optional
+	Local *Local `json:"local,omitempty"`
+	// +optional
+	Azure *Azure `json:"azure,omitempty"`
+	// +optional
+	GCS *GCS `json:"gcs,omitempty"`
+	// Compression algorithm of the backup, zstd or qpress, the backup is not compressed if empty.
+	// zstd requires xtrabackup 8.0.30 or later.
+	// +kubebuilder:validation:Enum=zstd;qpress
+	// +optional
+	Compression string `json:"compression,omitempty"`
+	// Encrypt the backup with the key in the encrypt-key item of the backupSecretName of the
+	// cluster, the restore decrypts it with the same key. The length of the key is 16, 24 or
+	// 32 bytes for AES128, AES192 or AES256.
+	// +optional
+	Encrypt bool `json:"encrypt,omitempty"`
 }
 
 type S3 struct {
@@ -62,22 +90,63 @@ type NFS struct {
 	Volume corev1.NFSVolumeSource `json:"volume,omitempty"`
 }
 
+type PVC struct {
+	// Defines a PersistentVolumeClaim for backup MySQL data, it should be ReadWriteMany
+	// if the cluster restores from it while the backup jobs are running.
+	Volume corev1.PersistentVolumeClaimVolumeSource `json:"volume,omitempty"`
+}
+
+type Local struct {
+	// Defines a directory on the host for backup MySQL data, the backup jobs and
+	// the pod restored from it must run on the same node, mainly for testing.
+	Volume corev1.HostPathVolumeSource `json:"volume,omitempty"`
+	// The node where the directory is, the backup jobs are pinned to it by the
+	// kubernetes.io/hostname label.
+	// +kubebuilder:validation:MinLength=1
+	NodeName string `json:"nodeName"`
+}
+
+type Azure struct {
+	// The secret contains azure-storage-account, azure-access-key, azure-container-name
+	// and the optional azure-endpoint.
+	// +optional
+	BackupSecretName string `json:"secretName,omitempty"`
+}
+
+type GCS struct {
+	// The secret contains the HMAC keys gcs-access-key, gcs-secret-key, gcs-bucket
+	// and the optional gcs-endpoint.
+	// +optional
+	BackupSecretName string `json:"secretName,omitempty"`
+}
+
 type ManualBackup struct {
+	// Backup type, full or incremental. An incremental backup is based on the
+	// last successful backup of the cluster, a full backup is taken if there is none.
+	// +optional
 	BackupType string `json:"type,omitempty"`
-	// Backup Retention
+	// Backup Retention, the days to keep the backups, the older ones are pruned.
 	// +optional
-	// +kubebuilder:default:=7
 	BackupRetention *int32 `json:"backupRetention,omitempty"`
+	// The number of the latest backups to keep, the older ones are pruned.
+	// +optional
+	BackupRetentionCount *int32 `json:"backupRetentionCount,omitempty"`
 }
 
 type BackupSchedule struct {
 	// Cron expression for backup schedule
 	// +optional
 	CronExpression string `json:"cronExpression,omitempty"`
-	// Backup Retention
+	// Backup Retention, the days to keep the backups, the older ones are pruned.
 	// +optional
 	BackupRetention *int32 `json:"backupRetention,omitempty"`
-	BackupType      string `json:"type,omitempty"`
+	// The number of the latest backups to keep, the older ones are pruned.
+	// +optional
+	BackupRetentionCount *int32 `json:"backupRetentionCount,omitempty"`
+	// Backup type, full or incremental. An incremental backup is based on the
+	// last successful backup of the cluster, a full backup is taken if there is none.
+	// +optional
+	BackupType string `json:"type,omitempty"`
 	// History Limit of job
 	// +optional
 	BackupJobHistoryLimit *int32 `json:"jobhistoryLimit,omitempty"`
@@ -93,8 +162,47 @@ type BackupStatus struct {
 	State            BackupConditionType     `json:"state,omitempty"`
 	ManualBackup     *ManualBackupStatus     `json:"manual,omitempty"`
 	ScheduledBackups []ScheduledBackupStatus `json:"scheduled,omitempty"`
+	// The backup which the incremental backup is based on.
+	ParentBackupName string `json:"parentBackupName,omitempty"`
+	// The backups needed to restore, from the full backup to this one.
+	BackupChain []string `json:"backupChain,omitempty"`
+	// The LSN which the backup ends at.
+	ToLSN string `json:"toLSN,omitempty"`
+	// The succeeded backups which are kept by the retention policy.
+	Backups []BackupRecord `json:"backups,omitempty"`
+	// The backups which have been pruned by the retention policy.
+	PrunedBackups []BackupRecord `json:"prunedBackups,omitempty"`
+	// The verification state of the latest backup, Verifying, Verified or VerificationFailed.
+	VerificationState BackupConditionType `json:"verificationState,omitempty"`
+	// The backup which the verification state belongs to.
+	VerifiedBackupName string `json:"verifiedBackupName,omitempty"`
+}
+
+// BackupRecord records a succeeded backup.
+type BackupRecord struct {
+	// The name of the backup in S3 or NFS.
+	Name string `json:"name"`
+	// The time the backup completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The backups needed to restore, from the full backup to this one.
+	BackupChain []string `json:"backupChain,omitempty"`
+	// The storage type of the backup, such as S3 or NFS.
+	BackupType string `json:"backupType,omitempty"`
+	// The host which the backup is taken on.
+	BackupHost string `json:"backupHost,omitempty"`
+	// The LSN which the backup ends at, the incremental backups are based on it.
+	ToLSN string `json:"toLSN,omitempty"`
+	// The time the backup is pruned.
+	PruneTime *metav1.Time `json:"pruneTime,omitempty"`
 }
 
+const (
+	// FullBackupType takes a full backup.
+	FullBackupType = "full"
+	// IncrementalBackupType takes a backup of the pages changed since the last backup.
+	IncrementalBackupType = "incremental"
+)
+
 type BackupConditionType string
 
 const (
@@ -104,6 +212,12 @@ const (
 	BackupFailed BackupConditionType = "Failed"
 	BackupStart  BackupConditionType = "Started"
 	BackupActive BackupConditionType = "Active"
+	// BackupVerifying means the backup is restoring in the verification pod
+	BackupVerifying BackupConditionType = "Verifying"
+	// BackupVerified means the backup has been restored and passed the checks
+	BackupVerified BackupConditionType = "Verified"
+	// BackupVerificationFailed means the backup failed to restore or the checks failed
+	BackupVerificationFailed BackupConditionType = "VerificationFailed"
 )
 
 type BackupInitiator string
@@ -138,6 +252,14 @@ type ManualBackupStatus struct {
 	BackupSize string `json:"backupSize,omitempty"`
 	// Get current backup status
 	State BackupConditionType `json:"state,omitempty"`
+	// The backup which the incremental backup is based on.
+	ParentBackupName string `json:"parentBackupName,omitempty"`
+	// The backups needed to restore, from the full backup to this one.
+	BackupChain []string `json:"backupChain,omitempty"`
+	// The LSN which the backup ends at.
+	ToLSN string `json:"toLSN,omitempty"`
+	// The host which the backup is taken on.
+	BackupHost string `json:"backupHost,omitempty"`
 }
 
 type ScheduledBackupStatus struct {
@@ -166,6 +288,14 @@ type ScheduledBackupStatus struct {
 	BackupSize string `json:"backupSize,omitempty"`
 	// Get current backup status
 	State BackupConditionType `json:"state,omitempty"`
+	// The backup which the incremental backup is based on.
+	ParentBackupName string `json:"parentBackupName,omitempty"`
+	// The backups needed to restore, from the full backup to this one.
+	BackupChain []string `json:"backupChain,omitempty"`
+	// The LSN which the backup ends at.
+	ToLSN string `json:"toLSN,omitempty"`
+	// The host which the backup is taken on.
+	BackupHost string `json:"backupHost,omitempty"`
 }
 
 // +kubebuilder:object:root=true
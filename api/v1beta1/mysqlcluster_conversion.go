@@ -92,9 +92,36 @@ func Convert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alpha1.
 		corev1.ResourceStorage: resource.MustParse(in.Persistence.Size),
 	}
 
-	if len(in.BackupSecretName) != 0 {
-		out.DataSource.S3Backup.Name = in.RestoreFrom
-		out.DataSource.S3Backup.SecretName = in.BackupSecretName
+	switch in.RestoreStorage {
+	case "azure":
+		out.DataSource.AzureBackup = &CloudBackupDataSource{
+			Name:       in.RestoreFrom,
+			SecretName: in.BackupSecretName,
+		}
+	case "gcs":
+		out.DataSource.GCSBackup = &CloudBackupDataSource{
+			Name:       in.RestoreFrom,
+			SecretName: in.BackupSecretName,
+		}
+	case "pvc":
+		out.DataSource.PVCBackup = &PVCBackupDataSource{
+			Name: in.RestoreFrom,
+			Volume: corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: in.RestoreClaimName,
+			},
+		}
+	case "local":
+		out.DataSource.LocalBackup = &LocalBackupDataSource{
+			Name: in.RestoreFrom,
+			Volume: corev1.HostPathVolumeSource{
+				Path: in.RestoreHostPath,
+			},
+		}
+	default:
+		if len(in.BackupSecretName) != 0 {
+			out.DataSource.S3Backup.Name = in.RestoreFrom
+			out.DataSource.S3Backup.SecretName = in.BackupSecretName
+		}
 	}
 	if len(in.NFSServerAddress) != 0 {
 		ipStr := strings.Split(in.NFSServerAddress, ":")
@@ -113,6 +140,14 @@ func Convert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alpha1.
 		}
 
 	}
+	out.DataSource.RestoreTarget = (*RestoreTarget)(unsafe.Pointer(in.RestoreTarget))
+	if len(in.RemoteSourceSecretName) != 0 {
+		out.DataSource.Remote.SourceConfig = &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: in.RemoteSourceSecretName,
+			},
+		}
+	}
 	if in.TlsSecretName != "" {
 		out.CustomTLSSecret = &corev1.SecretProjection{
 			LocalObjectReference: corev1.LocalObjectReference{
@@ -158,8 +193,11 @@ func Convert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *MysqlClus
 	out.PodPolicy.Tolerations = in.Tolerations
 	out.PodPolicy.Affinity = (*corev1.Affinity)(unsafe.Pointer(in.Affinity))
 	out.PodPolicy.PriorityClassName = in.PriorityClassName
-	// in.DataSource in.Standby
 	out.XenonOpts.EnableAutoRebuild = in.EnableAutoRebuild
+	out.RestoreTarget = (*v1alpha1.RestoreTarget)(unsafe.Pointer(in.DataSource.RestoreTarget))
+	if in.DataSource.Remote.SourceConfig != nil {
+		out.RemoteSourceSecretName = in.DataSource.Remote.SourceConfig.Name
+	}
 	if len(in.DataSource.S3Backup.Name) != 0 {
 		out.RestoreFrom = in.DataSource.S3Backup.Name
 		out.BackupSecretName = in.DataSource.S3Backup.SecretName
@@ -170,6 +208,24 @@ func Convert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *MysqlClus
 		out.NFSServerAddress = fmt.Sprintf("%s:%s",
 			in.DataSource.NFSBackup.Volume.Server, in.DataSource.NFSBackup.Volume.Path)
 	}
+	switch {
+	case in.DataSource.AzureBackup != nil:
+		out.RestoreStorage = "azure"
+		out.RestoreFrom = in.DataSource.AzureBackup.Name
+		out.BackupSecretName = in.DataSource.AzureBackup.SecretName
+	case in.DataSource.GCSBackup != nil:
+		out.RestoreStorage = "gcs"
+		out.RestoreFrom = in.DataSource.GCSBackup.Name
+		out.BackupSecretName = in.DataSource.GCSBackup.SecretName
+	case in.DataSource.PVCBackup != nil:
+		out.RestoreStorage = "pvc"
+		out.RestoreFrom = in.DataSource.PVCBackup.Name
+		out.RestoreClaimName = in.DataSource.PVCBackup.Volume.ClaimName
+	case in.DataSource.LocalBackup != nil:
+		out.RestoreStorage = "local"
+		out.RestoreFrom = in.DataSource.LocalBackup.Name
+		out.RestoreHostPath = in.DataSource.LocalBackup.Volume.Path
+	}
 
 	//TODO in.Log n.Service
 	return nil
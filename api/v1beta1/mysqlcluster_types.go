@@ -51,10 +51,18 @@ type MysqlClusterSpec struct {
 	//Compute resources of a MySQL container.
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 
-	// Containing CA (ca.crt) and server cert (tls.crt), server private key (tls.key) for SSL
+	// Containing CA (ca.crt) and server cert (tls.crt), server private key (tls.key) for SSL.
+	// The sidecar backup server serves over TLS with them, and the backup jobs present the
+	// cert as the client cert, so it should allow client auth.
 	// +optional
 	CustomTLSSecret *corev1.SecretProjection `json:"customTLSSecret,omitempty"`
 
+	// The operator generates a CA and the certificate of the cluster when customTLSSecret is
+	// empty, they are stored in the secret <cluster>-tls and renewed before expiry by rolling
+	// the pods. The replication between the nodes requires SSL when TLS is enabled.
+	// +optional
+	AutoTLS bool `json:"autoTLS,omitempty"`
+
 	// Defines a PersistentVolumeClaim for MySQL data.
 	// More info: https://kubernetes.io/docs/concepts/storage/persistent-volumes
 	// +kubebuilder:validation:Required
@@ -137,6 +145,10 @@ type MysqlClusterSpec struct {
 	// +optional
 	Standby *MySQLStandbySpec `json:"standby,omitempty"`
 
+	// Ship the closed binlogs of the leader to S3 or NFS for point-in-time recovery.
+	// +optional
+	BinlogArchive *BinlogArchiveOpts `json:"binlogArchive,omitempty"`
+
 	// If true, when the data is inconsistent, Xenon will automatically rebuild the invalid node.
 	// +optional
 	// +kubebuilder:default:=false
@@ -153,6 +165,42 @@ type MysqlClusterSpec struct {
 	// Specification of the service that exposes the MySQL leader instance.
 	// +optional
 	Service *ServiceSpec `json:"service,omitempty"`
+
+	// The replication between the leader and the followers, async or semi-sync.
+	// The cluster of a single node is always async.
+	// +optional
+	// +kubebuilder:validation:Enum=async;semi-sync
+	// +kubebuilder:default:="semi-sync"
+	ReplicationMode ReplicationMode `json:"replicationMode,omitempty"`
+
+	// The options of the semi-sync replication, ignored in async.
+	// +optional
+	SemiSyncOpts SemiSyncOpts `json:"semiSyncOpts,omitempty"`
+}
+
+// ReplicationMode is the mode of the replication between the leader and the followers.
+type ReplicationMode string
+
+const (
+	// ReplicationModeAsync means the leader does not wait for the followers.
+	ReplicationModeAsync ReplicationMode = "async"
+	// ReplicationModeSemiSync means the leader waits for the acks of the followers.
+	ReplicationModeSemiSync ReplicationMode = "semi-sync"
+)
+
+// SemiSyncOpts defines the options of the semi-sync replication.
+type SemiSyncOpts struct {
+	// The number of the followers the leader waits for the acks from, it is limited
+	// to the number of the followers (rpl_semi_sync_master_wait_for_slave_count).
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	WaitForSlaveCount int32 `json:"waitForSlaveCount,omitempty"`
+
+	// The time the leader waits for the acks before degrading to async, such as 10s
+	// (rpl_semi_sync_master_timeout). The leader never degrades if it is not set.
+	// +optional
+	DegradeTimeout *metav1.Duration `json:"degradeTimeout,omitempty"`
 }
 
 // ReadOnly define the ReadOnly pods
@@ -169,6 +217,11 @@ type ReadOnlyType struct {
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// The seconds the readonly pods delay the replication behind the master (MASTER_DELAY),
+	// the mistakes on the leader can be recovered from them in the meantime. 0 means no delay.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	DelaySeconds int32 `json:"delaySeconds,omitempty"`
 }
 
 type MySQLConfigs struct {
@@ -205,6 +258,21 @@ type MysqlClusterStatus struct {
 	Conditions []ClusterCondition `json:"conditions,omitempty"`
 	// Nodes contains the list of the node status fulfilled.
 	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// Replication is the replication mode in effect.
+	Replication *ReplicationStatus `json:"replication,omitempty"`
+	// StandbySource is the address (host:port) of the source that the standby leader
+	// replicates from. It is cleared once the cluster is promoted.
+	StandbySource string `json:"standbySource,omitempty"`
+}
+
+// ReplicationStatus defines the replication mode in effect.
+type ReplicationStatus struct {
+	// Mode is the replication mode in effect, it is async if there is no follower.
+	Mode ReplicationMode `json:"mode,omitempty"`
+	// WaitForSlaveCount is the number of the acks the leader waits for.
+	WaitForSlaveCount int32 `json:"waitForSlaveCount,omitempty"`
+	// Degraded is true if the leader has degraded to async after the timeout.
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -298,6 +366,31 @@ type NodeStatus struct {
 	RoStatus *RoStatus `json:"roStatus,omitempty"`
 	// Conditions contains the list of the node conditions fulfilled.
 	Conditions []NodeCondition `json:"conditions,omitempty"`
+	// Config is the state of the mysql configs on the node.
+	Config *NodeConfigStatus `json:"config,omitempty"`
+}
+
+// NodeConfigState is the state of the mysql configs on the node.
+type NodeConfigState string
+
+const (
+	// NodeConfigApplied means all the configs have been applied on the node.
+	NodeConfigApplied NodeConfigState = "Applied"
+	// NodeConfigPending means some configs are waiting to be applied on the node.
+	NodeConfigPending NodeConfigState = "Pending"
+)
+
+// NodeConfigStatus defines the state of the mysql configs on the node. The dynamic configs
+// are applied online by SET GLOBAL, the static configs are applied after the node restarts.
+type NodeConfigStatus struct {
+	// State is Applied if all the configs have been applied, otherwise Pending.
+	State NodeConfigState `json:"state,omitempty"`
+	// Applied is the dynamic configs which have been applied online.
+	Applied []string `json:"applied,omitempty"`
+	// Pending is the dynamic configs which failed to be applied online.
+	Pending []string `json:"pending,omitempty"`
+	// RestartRequired is true if the static configs changed and the node has not restarted.
+	RestartRequired bool `json:"restartRequired,omitempty"`
 }
 
 type RaftStatus struct {
@@ -426,10 +519,31 @@ type DataSource struct {
 	// restore from nfs
 	// +optional
 	NFSBackup *NFSBackupDataSource `json:"Nfsbackup,omitempty"`
+	// restore from a PersistentVolumeClaim
+	// +optional
+	PVCBackup *PVCBackupDataSource `json:"pvcBackup,omitempty"`
+	// restore from a directory on the host
+	// +optional
+	LocalBackup *LocalBackupDataSource `json:"localBackup,omitempty"`
+	// restore from azure blob storage
+	// +optional
+	AzureBackup *CloudBackupDataSource `json:"azureBackup,omitempty"`
+	// restore from google cloud storage
+	// +optional
+	GCSBackup *CloudBackupDataSource `json:"gcsBackup,omitempty"`
+	// Replay the archived binlogs after restoring from backup, until the time or gtid set.
+	// +optional
+	RestoreTarget *RestoreTarget `json:"restoreTarget,omitempty"`
 }
 
 type RemoteDataSource struct {
-	//
+	// The secret contains the host, port, user and password of the remote MySQL. To clone
+	// MySQL 5.7, it also contains the backup-user, backup-password, optional backup-port,
+	// ca.crt, tls.crt and tls.key to download the backup from the sidecar backup server
+	// of the remote MySQL over TLS.
+	// The user needs BACKUP_ADMIN and REPLICATION SLAVE privileges. The cluster keeps
+	// replicating from the remote MySQL as a standby until it is removed.
+	// +optional
 	SourceConfig *corev1.SecretProjection `json:"sourceConfig,omitempty"`
 }
 
@@ -442,6 +556,27 @@ type S3BackupDataSource struct {
 	SecretName string `json:"secretName"`
 }
 
+type PVCBackupDataSource struct {
+	// Backup name
+	Name string `json:"name"`
+	// The PersistentVolumeClaim where the backup is stored
+	Volume corev1.PersistentVolumeClaimVolumeSource `json:"volume,omitempty"`
+}
+
+type LocalBackupDataSource struct {
+	// Backup name
+	Name string `json:"name"`
+	// The directory on the host where the backup is stored
+	Volume corev1.HostPathVolumeSource `json:"volume,omitempty"`
+}
+
+type CloudBackupDataSource struct {
+	// Backup name
+	Name string `json:"name"`
+	// The secret contains the credentials, the same as the secret of the Backup
+	SecretName string `json:"secretName"`
+}
+
 type NFSBackupDataSource struct {
 	// Backup name
 	Name string `json:"name"`
@@ -497,6 +632,43 @@ type MySQLStandbySpec struct {
 	Port *int32 `json:"port,omitempty"`
 }
 
+// BinlogArchiveOpts defines where the closed binlogs of the leader are shipped to.
+type BinlogArchiveOpts struct {
+	// Represents the name of the secret that contains credentials to connect to
+	// the S3 storage (s3-endpoint, s3-access-key, s3-secret-key, s3-bucket).
+	// +optional
+	S3SecretName string `json:"s3SecretName,omitempty"`
+
+	// Represents NFS ip address and path where the binlogs archived to, format: ip:/path.
+	// +optional
+	NFSServerAddress string `json:"nfsServerAddress,omitempty"`
+
+	// Interval in seconds to check the closed binlogs.
+	// +optional
+	// +kubebuilder:default:=60
+	// +kubebuilder:validation:Minimum=1
+	Interval int32 `json:"interval,omitempty"`
+}
+
+// RestoreTarget defines the point where the archived binlogs are replayed to.
+type RestoreTarget struct {
+	// The name of the MySQL cluster whose binlogs were archived.
+	ClusterName string `json:"clusterName"`
+
+	// Replay the binlogs until the datetime, format: "2006-01-02 15:04:05".
+	// +optional
+	Time string `json:"time,omitempty"`
+
+	// Replay the binlogs until the gtid set is executed.
+	// +optional
+	GTID string `json:"gtid,omitempty"`
+
+	// The secret of the S3 storage where the binlogs were archived, defaults to
+	// spec.binlogArchive.s3SecretName. Not used when restoring from NFS.
+	// +optional
+	S3SecretName string `json:"s3SecretName,omitempty"`
+}
+
 type ServiceSpec struct {
 	// The port on which this service is exposed when type is NodePort or
 	// LoadBalancer. Value must be in-range and not in use or the operation will
@@ -25,6 +25,7 @@ import (
 
 	v1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -56,6 +57,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*BinlogArchiveOpts)(nil), (*v1alpha1.BinlogArchiveOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(a.(*BinlogArchiveOpts), b.(*v1alpha1.BinlogArchiveOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.BinlogArchiveOpts)(nil), (*BinlogArchiveOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(a.(*v1alpha1.BinlogArchiveOpts), b.(*BinlogArchiveOpts), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ClusterCondition)(nil), (*v1alpha1.ClusterCondition)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_ClusterCondition_To_v1alpha1_ClusterCondition(a.(*ClusterCondition), b.(*v1alpha1.ClusterCondition), scope)
 	}); err != nil {
@@ -66,6 +77,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*MySQLStandbySpec)(nil), (*v1alpha1.MySQLStandbySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_MySQLStandbySpec_To_v1alpha1_MySQLStandbySpec(a.(*MySQLStandbySpec), b.(*v1alpha1.MySQLStandbySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.MySQLStandbySpec)(nil), (*MySQLStandbySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_MySQLStandbySpec_To_v1beta1_MySQLStandbySpec(a.(*v1alpha1.MySQLStandbySpec), b.(*MySQLStandbySpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*MysqlCluster)(nil), (*v1alpha1.MysqlCluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_MysqlCluster_To_v1alpha1_MysqlCluster(a.(*MysqlCluster), b.(*v1alpha1.MysqlCluster), scope)
 	}); err != nil {
@@ -106,6 +127,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*NodeConfigStatus)(nil), (*v1alpha1.NodeConfigStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_NodeConfigStatus_To_v1alpha1_NodeConfigStatus(a.(*NodeConfigStatus), b.(*v1alpha1.NodeConfigStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.NodeConfigStatus)(nil), (*NodeConfigStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_NodeConfigStatus_To_v1beta1_NodeConfigStatus(a.(*v1alpha1.NodeConfigStatus), b.(*NodeConfigStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*NodeStatus)(nil), (*v1alpha1.NodeStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_NodeStatus_To_v1alpha1_NodeStatus(a.(*NodeStatus), b.(*v1alpha1.NodeStatus), scope)
 	}); err != nil {
@@ -136,6 +167,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ReplicationStatus)(nil), (*v1alpha1.ReplicationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(a.(*ReplicationStatus), b.(*v1alpha1.ReplicationStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.ReplicationStatus)(nil), (*ReplicationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(a.(*v1alpha1.ReplicationStatus), b.(*ReplicationStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RestoreTarget)(nil), (*v1alpha1.RestoreTarget)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(a.(*RestoreTarget), b.(*v1alpha1.RestoreTarget), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.RestoreTarget)(nil), (*RestoreTarget)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(a.(*v1alpha1.RestoreTarget), b.(*RestoreTarget), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RoStatus)(nil), (*v1alpha1.RoStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_RoStatus_To_v1alpha1_RoStatus(a.(*RoStatus), b.(*v1alpha1.RoStatus), scope)
 	}); err != nil {
@@ -146,6 +197,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*SemiSyncOpts)(nil), (*v1alpha1.SemiSyncOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(a.(*SemiSyncOpts), b.(*v1alpha1.SemiSyncOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.SemiSyncOpts)(nil), (*SemiSyncOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(a.(*v1alpha1.SemiSyncOpts), b.(*SemiSyncOpts), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*XenonOpts)(nil), (*v1alpha1.XenonOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_XenonOpts_To_v1alpha1_XenonOpts(a.(*XenonOpts), b.(*v1alpha1.XenonOpts), scope)
 	}); err != nil {
@@ -269,6 +330,7 @@ func autoConvert_v1beta1_BackupSpec_To_v1alpha1_BackupSpec(in *BackupSpec, out *
 	// WARNING: in.Manual requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupSchedule requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupOpts requires manual conversion: does not exist in peer-type
+	// WARNING: in.Verification requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -292,6 +354,13 @@ func autoConvert_v1beta1_BackupStatus_To_v1alpha1_BackupStatus(in *BackupStatus,
 	// WARNING: in.State requires manual conversion: does not exist in peer-type
 	// WARNING: in.ManualBackup requires manual conversion: does not exist in peer-type
 	// WARNING: in.ScheduledBackups requires manual conversion: does not exist in peer-type
+	// WARNING: in.ParentBackupName requires manual conversion: does not exist in peer-type
+	// WARNING: in.BackupChain requires manual conversion: does not exist in peer-type
+	// WARNING: in.ToLSN requires manual conversion: does not exist in peer-type
+	// WARNING: in.Backups requires manual conversion: does not exist in peer-type
+	// WARNING: in.PrunedBackups requires manual conversion: does not exist in peer-type
+	// WARNING: in.VerificationState requires manual conversion: does not exist in peer-type
+	// WARNING: in.VerifiedBackupName requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -304,6 +373,30 @@ func autoConvert_v1alpha1_BackupStatus_To_v1beta1_BackupStatus(in *v1alpha1.Back
 	return nil
 }
 
+func autoConvert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in *BinlogArchiveOpts, out *v1alpha1.BinlogArchiveOpts, s conversion.Scope) error {
+	out.S3SecretName = in.S3SecretName
+	out.NFSServerAddress = in.NFSServerAddress
+	out.Interval = in.Interval
+	return nil
+}
+
+// Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts is an autogenerated conversion function.
+func Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in *BinlogArchiveOpts, out *v1alpha1.BinlogArchiveOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in *v1alpha1.BinlogArchiveOpts, out *BinlogArchiveOpts, s conversion.Scope) error {
+	out.S3SecretName = in.S3SecretName
+	out.NFSServerAddress = in.NFSServerAddress
+	out.Interval = in.Interval
+	return nil
+}
+
+// Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts is an autogenerated conversion function.
+func Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in *v1alpha1.BinlogArchiveOpts, out *BinlogArchiveOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in, out, s)
+}
+
 func autoConvert_v1beta1_ClusterCondition_To_v1alpha1_ClusterCondition(in *ClusterCondition, out *v1alpha1.ClusterCondition, s conversion.Scope) error {
 	out.Type = v1alpha1.ClusterConditionType(in.Type)
 	out.Status = v1.ConditionStatus(in.Status)
@@ -332,6 +425,32 @@ func Convert_v1alpha1_ClusterCondition_To_v1beta1_ClusterCondition(in *v1alpha1.
 	return autoConvert_v1alpha1_ClusterCondition_To_v1beta1_ClusterCondition(in, out, s)
 }
 
+func autoConvert_v1beta1_MySQLStandbySpec_To_v1alpha1_MySQLStandbySpec(in *MySQLStandbySpec, out *v1alpha1.MySQLStandbySpec, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.ClusterName = in.ClusterName
+	out.Host = in.Host
+	out.Port = (*int32)(unsafe.Pointer(in.Port))
+	return nil
+}
+
+// Convert_v1beta1_MySQLStandbySpec_To_v1alpha1_MySQLStandbySpec is an autogenerated conversion function.
+func Convert_v1beta1_MySQLStandbySpec_To_v1alpha1_MySQLStandbySpec(in *MySQLStandbySpec, out *v1alpha1.MySQLStandbySpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_MySQLStandbySpec_To_v1alpha1_MySQLStandbySpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_MySQLStandbySpec_To_v1beta1_MySQLStandbySpec(in *v1alpha1.MySQLStandbySpec, out *MySQLStandbySpec, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.ClusterName = in.ClusterName
+	out.Host = in.Host
+	out.Port = (*int32)(unsafe.Pointer(in.Port))
+	return nil
+}
+
+// Convert_v1alpha1_MySQLStandbySpec_To_v1beta1_MySQLStandbySpec is an autogenerated conversion function.
+func Convert_v1alpha1_MySQLStandbySpec_To_v1beta1_MySQLStandbySpec(in *v1alpha1.MySQLStandbySpec, out *MySQLStandbySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_MySQLStandbySpec_To_v1beta1_MySQLStandbySpec(in, out, s)
+}
+
 func autoConvert_v1beta1_MysqlCluster_To_v1alpha1_MysqlCluster(in *MysqlCluster, out *v1alpha1.MysqlCluster, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -413,6 +532,7 @@ func autoConvert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *Mysql
 	// WARNING: in.MySQLConfig requires manual conversion: does not exist in peer-type
 	// WARNING: in.Resources requires manual conversion: does not exist in peer-type
 	// WARNING: in.CustomTLSSecret requires manual conversion: does not exist in peer-type
+	out.AutoTLS = in.AutoTLS
 	// WARNING: in.Storage requires manual conversion: does not exist in peer-type
 	out.MysqlVersion = in.MysqlVersion
 	// WARNING: in.Xenon requires manual conversion: does not exist in peer-type
@@ -427,10 +547,15 @@ func autoConvert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *Mysql
 	// WARNING: in.PriorityClassName requires manual conversion: does not exist in peer-type
 	out.MinAvailable = in.MinAvailable
 	// WARNING: in.DataSource requires manual conversion: does not exist in peer-type
-	// WARNING: in.Standby requires manual conversion: does not exist in peer-type
+	out.Standby = (*v1alpha1.MySQLStandbySpec)(unsafe.Pointer(in.Standby))
+	out.BinlogArchive = (*v1alpha1.BinlogArchiveOpts)(unsafe.Pointer(in.BinlogArchive))
 	// WARNING: in.EnableAutoRebuild requires manual conversion: does not exist in peer-type
 	// WARNING: in.Log requires manual conversion: does not exist in peer-type
 	// WARNING: in.Service requires manual conversion: does not exist in peer-type
+	out.ReplicationMode = v1alpha1.ReplicationMode(in.ReplicationMode)
+	if err := Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(&in.SemiSyncOpts, &out.SemiSyncOpts, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -447,10 +572,22 @@ func autoConvert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alp
 	// WARNING: in.BackupSecretName requires manual conversion: does not exist in peer-type
 	// WARNING: in.RestoreFrom requires manual conversion: does not exist in peer-type
 	// WARNING: in.NFSServerAddress requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreStorage requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreClaimName requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreHostPath requires manual conversion: does not exist in peer-type
+	// WARNING: in.RemoteSourceSecretName requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupSchedule requires manual conversion: does not exist in peer-type
 	// WARNING: in.BothS3NFS requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupScheduleJobsHistoryLimit requires manual conversion: does not exist in peer-type
 	// WARNING: in.TlsSecretName requires manual conversion: does not exist in peer-type
+	out.AutoTLS = in.AutoTLS
+	out.Standby = (*MySQLStandbySpec)(unsafe.Pointer(in.Standby))
+	out.BinlogArchive = (*BinlogArchiveOpts)(unsafe.Pointer(in.BinlogArchive))
+	// WARNING: in.RestoreTarget requires manual conversion: does not exist in peer-type
+	out.ReplicationMode = ReplicationMode(in.ReplicationMode)
+	if err := Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(&in.SemiSyncOpts, &out.SemiSyncOpts, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -459,6 +596,8 @@ func autoConvert_v1beta1_MysqlClusterStatus_To_v1alpha1_MysqlClusterStatus(in *M
 	out.State = v1alpha1.ClusterState(in.State)
 	out.Conditions = *(*[]v1alpha1.ClusterCondition)(unsafe.Pointer(&in.Conditions))
 	out.Nodes = *(*[]v1alpha1.NodeStatus)(unsafe.Pointer(&in.Nodes))
+	out.Replication = (*v1alpha1.ReplicationStatus)(unsafe.Pointer(in.Replication))
+	out.StandbySource = in.StandbySource
 	return nil
 }
 
@@ -472,6 +611,8 @@ func autoConvert_v1alpha1_MysqlClusterStatus_To_v1beta1_MysqlClusterStatus(in *v
 	out.State = ClusterState(in.State)
 	out.Conditions = *(*[]ClusterCondition)(unsafe.Pointer(&in.Conditions))
 	out.Nodes = *(*[]NodeStatus)(unsafe.Pointer(&in.Nodes))
+	out.Replication = (*ReplicationStatus)(unsafe.Pointer(in.Replication))
+	out.StandbySource = in.StandbySource
 	return nil
 }
 
@@ -504,6 +645,32 @@ func Convert_v1alpha1_NodeCondition_To_v1beta1_NodeCondition(in *v1alpha1.NodeCo
 	return autoConvert_v1alpha1_NodeCondition_To_v1beta1_NodeCondition(in, out, s)
 }
 
+func autoConvert_v1beta1_NodeConfigStatus_To_v1alpha1_NodeConfigStatus(in *NodeConfigStatus, out *v1alpha1.NodeConfigStatus, s conversion.Scope) error {
+	out.State = v1alpha1.NodeConfigState(in.State)
+	out.Applied = *(*[]string)(unsafe.Pointer(&in.Applied))
+	out.Pending = *(*[]string)(unsafe.Pointer(&in.Pending))
+	out.RestartRequired = in.RestartRequired
+	return nil
+}
+
+// Convert_v1beta1_NodeConfigStatus_To_v1alpha1_NodeConfigStatus is an autogenerated conversion function.
+func Convert_v1beta1_NodeConfigStatus_To_v1alpha1_NodeConfigStatus(in *NodeConfigStatus, out *v1alpha1.NodeConfigStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_NodeConfigStatus_To_v1alpha1_NodeConfigStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_NodeConfigStatus_To_v1beta1_NodeConfigStatus(in *v1alpha1.NodeConfigStatus, out *NodeConfigStatus, s conversion.Scope) error {
+	out.State = NodeConfigState(in.State)
+	out.Applied = *(*[]string)(unsafe.Pointer(&in.Applied))
+	out.Pending = *(*[]string)(unsafe.Pointer(&in.Pending))
+	out.RestartRequired = in.RestartRequired
+	return nil
+}
+
+// Convert_v1alpha1_NodeConfigStatus_To_v1beta1_NodeConfigStatus is an autogenerated conversion function.
+func Convert_v1alpha1_NodeConfigStatus_To_v1beta1_NodeConfigStatus(in *v1alpha1.NodeConfigStatus, out *NodeConfigStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_NodeConfigStatus_To_v1beta1_NodeConfigStatus(in, out, s)
+}
+
 func autoConvert_v1beta1_NodeStatus_To_v1alpha1_NodeStatus(in *NodeStatus, out *v1alpha1.NodeStatus, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Message = in.Message
@@ -512,6 +679,7 @@ func autoConvert_v1beta1_NodeStatus_To_v1alpha1_NodeStatus(in *NodeStatus, out *
 	}
 	out.RoStatus = (*v1alpha1.RoStatus)(unsafe.Pointer(in.RoStatus))
 	out.Conditions = *(*[]v1alpha1.NodeCondition)(unsafe.Pointer(&in.Conditions))
+	out.Config = (*v1alpha1.NodeConfigStatus)(unsafe.Pointer(in.Config))
 	return nil
 }
 
@@ -528,6 +696,7 @@ func autoConvert_v1alpha1_NodeStatus_To_v1beta1_NodeStatus(in *v1alpha1.NodeStat
 	}
 	out.RoStatus = (*RoStatus)(unsafe.Pointer(in.RoStatus))
 	out.Conditions = *(*[]NodeCondition)(unsafe.Pointer(&in.Conditions))
+	out.Config = (*NodeConfigStatus)(unsafe.Pointer(in.Config))
 	return nil
 }
 
@@ -566,6 +735,7 @@ func autoConvert_v1beta1_ReadOnlyType_To_v1alpha1_ReadOnlyType(in *ReadOnlyType,
 	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
 	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
 	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.DelaySeconds = in.DelaySeconds
 	return nil
 }
 
@@ -580,6 +750,7 @@ func autoConvert_v1alpha1_ReadOnlyType_To_v1beta1_ReadOnlyType(in *v1alpha1.Read
 	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
 	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
 	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.DelaySeconds = in.DelaySeconds
 	return nil
 }
 
@@ -588,6 +759,54 @@ func Convert_v1alpha1_ReadOnlyType_To_v1beta1_ReadOnlyType(in *v1alpha1.ReadOnly
 	return autoConvert_v1alpha1_ReadOnlyType_To_v1beta1_ReadOnlyType(in, out, s)
 }
 
+func autoConvert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in *ReplicationStatus, out *v1alpha1.ReplicationStatus, s conversion.Scope) error {
+	out.Mode = v1alpha1.ReplicationMode(in.Mode)
+	out.WaitForSlaveCount = in.WaitForSlaveCount
+	out.Degraded = in.Degraded
+	return nil
+}
+
+// Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus is an autogenerated conversion function.
+func Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in *ReplicationStatus, out *v1alpha1.ReplicationStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in *v1alpha1.ReplicationStatus, out *ReplicationStatus, s conversion.Scope) error {
+	out.Mode = ReplicationMode(in.Mode)
+	out.WaitForSlaveCount = in.WaitForSlaveCount
+	out.Degraded = in.Degraded
+	return nil
+}
+
+// Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in *v1alpha1.ReplicationStatus, out *ReplicationStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in *RestoreTarget, out *v1alpha1.RestoreTarget, s conversion.Scope) error {
+	out.ClusterName = in.ClusterName
+	out.Time = in.Time
+	out.GTID = in.GTID
+	return nil
+}
+
+// Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget is an autogenerated conversion function.
+func Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in *RestoreTarget, out *v1alpha1.RestoreTarget, s conversion.Scope) error {
+	return autoConvert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in, out, s)
+}
+
+func autoConvert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in *v1alpha1.RestoreTarget, out *RestoreTarget, s conversion.Scope) error {
+	out.ClusterName = in.ClusterName
+	out.Time = in.Time
+	out.GTID = in.GTID
+	return nil
+}
+
+// Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget is an autogenerated conversion function.
+func Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in *v1alpha1.RestoreTarget, out *RestoreTarget, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in, out, s)
+}
+
 func autoConvert_v1beta1_RoStatus_To_v1alpha1_RoStatus(in *RoStatus, out *v1alpha1.RoStatus, s conversion.Scope) error {
 	out.ReadOnly = in.ReadOnly
 	out.Replication = in.Replication
@@ -612,6 +831,28 @@ func Convert_v1alpha1_RoStatus_To_v1beta1_RoStatus(in *v1alpha1.RoStatus, out *R
 	return autoConvert_v1alpha1_RoStatus_To_v1beta1_RoStatus(in, out, s)
 }
 
+func autoConvert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in *SemiSyncOpts, out *v1alpha1.SemiSyncOpts, s conversion.Scope) error {
+	out.WaitForSlaveCount = in.WaitForSlaveCount
+	out.DegradeTimeout = (*metav1.Duration)(unsafe.Pointer(in.DegradeTimeout))
+	return nil
+}
+
+// Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts is an autogenerated conversion function.
+func Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in *SemiSyncOpts, out *v1alpha1.SemiSyncOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in *v1alpha1.SemiSyncOpts, out *SemiSyncOpts, s conversion.Scope) error {
+	out.WaitForSlaveCount = in.WaitForSlaveCount
+	out.DegradeTimeout = (*metav1.Duration)(unsafe.Pointer(in.DegradeTimeout))
+	return nil
+}
+
+// Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts is an autogenerated conversion function.
+func Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in *v1alpha1.SemiSyncOpts, out *SemiSyncOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in, out, s)
+}
+
 func autoConvert_v1beta1_XenonOpts_To_v1alpha1_XenonOpts(in *XenonOpts, out *v1alpha1.XenonOpts, s conversion.Scope) error {
 	out.Image = in.Image
 	out.AdmitDefeatHearbeatCount = (*int32)(unsafe.Pointer(in.AdmitDefeatHearbeatCount))
@@ -23,9 +23,25 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Azure) DeepCopyInto(out *Azure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Azure.
+func (in *Azure) DeepCopy() *Azure {
+	if in == nil {
+		return nil
+	}
+	out := new(Azure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backup) DeepCopyInto(out *Backup) {
 	*out = *in
@@ -98,6 +114,26 @@ func (in *BackupOps) DeepCopyInto(out *BackupOps) {
 		*out = new(NFS)
 		**out = **in
 	}
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(PVC)
+		**out = **in
+	}
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = new(Local)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(Azure)
+		**out = **in
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(GCS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupOps.
@@ -126,6 +162,34 @@ func (in *BackupOpts) DeepCopy() *BackupOpts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRecord) DeepCopyInto(out *BackupRecord) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BackupChain != nil {
+		in, out := &in.BackupChain, &out.BackupChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PruneTime != nil {
+		in, out := &in.PruneTime, &out.PruneTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRecord.
+func (in *BackupRecord) DeepCopy() *BackupRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
 	*out = *in
@@ -134,6 +198,11 @@ func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.BackupRetentionCount != nil {
+		in, out := &in.BackupRetentionCount, &out.BackupRetentionCount
+		*out = new(int32)
+		**out = **in
+	}
 	if in.BackupJobHistoryLimit != nil {
 		in, out := &in.BackupJobHistoryLimit, &out.BackupJobHistoryLimit
 		*out = new(int32)
@@ -165,6 +234,11 @@ func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.BackupOpts.DeepCopyInto(&out.BackupOpts)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(BackupVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSpec.
@@ -200,6 +274,25 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.BackupChain != nil {
+		in, out := &in.BackupChain, &out.BackupChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Backups != nil {
+		in, out := &in.Backups, &out.Backups
+		*out = make([]BackupRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PrunedBackups != nil {
+		in, out := &in.PrunedBackups, &out.PrunedBackups
+		*out = make([]BackupRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
@@ -212,6 +305,56 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerification) DeepCopyInto(out *BackupVerification) {
+	*out = *in
+	if in.Queries != nil {
+		in, out := &in.Queries, &out.Queries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerification.
+func (in *BackupVerification) DeepCopy() *BackupVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BinlogArchiveOpts) DeepCopyInto(out *BinlogArchiveOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinlogArchiveOpts.
+func (in *BinlogArchiveOpts) DeepCopy() *BinlogArchiveOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(BinlogArchiveOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudBackupDataSource) DeepCopyInto(out *CloudBackupDataSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudBackupDataSource.
+func (in *CloudBackupDataSource) DeepCopy() *CloudBackupDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudBackupDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -238,6 +381,31 @@ func (in *DataSource) DeepCopyInto(out *DataSource) {
 		*out = new(NFSBackupDataSource)
 		**out = **in
 	}
+	if in.PVCBackup != nil {
+		in, out := &in.PVCBackup, &out.PVCBackup
+		*out = new(PVCBackupDataSource)
+		**out = **in
+	}
+	if in.LocalBackup != nil {
+		in, out := &in.LocalBackup, &out.LocalBackup
+		*out = new(LocalBackupDataSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AzureBackup != nil {
+		in, out := &in.AzureBackup, &out.AzureBackup
+		*out = new(CloudBackupDataSource)
+		**out = **in
+	}
+	if in.GCSBackup != nil {
+		in, out := &in.GCSBackup, &out.GCSBackup
+		*out = new(CloudBackupDataSource)
+		**out = **in
+	}
+	if in.RestoreTarget != nil {
+		in, out := &in.RestoreTarget, &out.RestoreTarget
+		*out = new(RestoreTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSource.
@@ -271,6 +439,53 @@ func (in *ExporterSpec) DeepCopy() *ExporterSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCS) DeepCopyInto(out *GCS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCS.
+func (in *GCS) DeepCopy() *GCS {
+	if in == nil {
+		return nil
+	}
+	out := new(GCS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Local) DeepCopyInto(out *Local) {
+	*out = *in
+	in.Volume.DeepCopyInto(&out.Volume)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Local.
+func (in *Local) DeepCopy() *Local {
+	if in == nil {
+		return nil
+	}
+	out := new(Local)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalBackupDataSource) DeepCopyInto(out *LocalBackupDataSource) {
+	*out = *in
+	in.Volume.DeepCopyInto(&out.Volume)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalBackupDataSource.
+func (in *LocalBackupDataSource) DeepCopy() *LocalBackupDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalBackupDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogOpts) DeepCopyInto(out *LogOpts) {
 	*out = *in
@@ -295,6 +510,11 @@ func (in *ManualBackup) DeepCopyInto(out *ManualBackup) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.BackupRetentionCount != nil {
+		in, out := &in.BackupRetentionCount, &out.BackupRetentionCount
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManualBackup.
@@ -318,6 +538,11 @@ func (in *ManualBackupStatus) DeepCopyInto(out *ManualBackupStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.BackupChain != nil {
+		in, out := &in.BackupChain, &out.BackupChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManualBackupStatus.
@@ -501,12 +726,18 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 		*out = new(MySQLStandbySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BinlogArchive != nil {
+		in, out := &in.BinlogArchive, &out.BinlogArchive
+		*out = new(BinlogArchiveOpts)
+		**out = **in
+	}
 	in.Log.DeepCopyInto(&out.Log)
 	if in.Service != nil {
 		in, out := &in.Service, &out.Service
 		*out = new(ServiceSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	in.SemiSyncOpts.DeepCopyInto(&out.SemiSyncOpts)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterSpec.
@@ -536,6 +767,11 @@ func (in *MysqlClusterStatus) DeepCopyInto(out *MysqlClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(ReplicationStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterStatus.
@@ -596,6 +832,31 @@ func (in *NodeCondition) DeepCopy() *NodeCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfigStatus) DeepCopyInto(out *NodeConfigStatus) {
+	*out = *in
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfigStatus.
+func (in *NodeConfigStatus) DeepCopy() *NodeConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 	*out = *in
@@ -612,6 +873,11 @@ func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(NodeConfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
@@ -624,6 +890,36 @@ func (in *NodeStatus) DeepCopy() *NodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVC) DeepCopyInto(out *PVC) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVC.
+func (in *PVC) DeepCopy() *PVC {
+	if in == nil {
+		return nil
+	}
+	out := new(PVC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCBackupDataSource) DeepCopyInto(out *PVCBackupDataSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCBackupDataSource.
+func (in *PVCBackupDataSource) DeepCopy() *PVCBackupDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCBackupDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RaftStatus) DeepCopyInto(out *RaftStatus) {
 	*out = *in
@@ -696,6 +992,36 @@ func (in *RemoteDataSource) DeepCopy() *RemoteDataSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationStatus) DeepCopyInto(out *ReplicationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationStatus.
+func (in *ReplicationStatus) DeepCopy() *ReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreTarget) DeepCopyInto(out *RestoreTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreTarget.
+func (in *RestoreTarget) DeepCopy() *RestoreTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoStatus) DeepCopyInto(out *RoStatus) {
 	*out = *in
@@ -752,6 +1078,11 @@ func (in *ScheduledBackupStatus) DeepCopyInto(out *ScheduledBackupStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.BackupChain != nil {
+		in, out := &in.BackupChain, &out.BackupChain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledBackupStatus.
@@ -764,6 +1095,26 @@ func (in *ScheduledBackupStatus) DeepCopy() *ScheduledBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemiSyncOpts) DeepCopyInto(out *SemiSyncOpts) {
+	*out = *in
+	if in.DegradeTimeout != nil {
+		in, out := &in.DegradeTimeout, &out.DegradeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemiSyncOpts.
+func (in *SemiSyncOpts) DeepCopy() *SemiSyncOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(SemiSyncOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
 	*out = *in
@@ -147,6 +147,49 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "MysqlUser")
 		os.Exit(1)
 	}
+	if err = (&controllers.MysqlRoleReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlrole"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlRole")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlDatabaseReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqldatabase"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlDatabase")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlSchemaMigrationReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlschemamigration"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlSchemaMigration")
+		os.Exit(1)
+	}
+	podExecutor, err := internal.NewPodExecutor()
+	if err != nil {
+		setupLog.Error(err, "unable to create pod executor")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlSwitchoverReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlswitchover"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+		XenonExecutor:    internal.NewXenonExecutor(),
+		XenonChecker:     podExecutor,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlSwitchover")
+		os.Exit(1)
+	}
 	if err = (&controllers.BackupCronReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
@@ -40,6 +40,10 @@ var (
 	connectionMaxIdleTime = 30 * time.Second
 	connectionTimeout     = 30 * time.Second
 	raftStatusCmd         = "xenoncli raft status"
+	// drainedFile is written by xenon on the leader drained by a switchover.
+	drainedFile = utils.XenonDrainedFile
+	// fencedFile is written by xenon on the leader fenced for losing the quorum.
+	fencedFile = utils.XenonFencedFile
 )
 
 type RaftStatus struct {
@@ -115,7 +119,7 @@ type Agent struct {
 	conf      MySQLConfig
 	db        *sqlx.DB
 	maxDelay  time.Duration
-	ksClient  *kubernetes.Clientset
+	ksClient  kubernetes.Interface
 	podName   string
 	nameSpace string
 }
@@ -204,14 +208,14 @@ func (c *Agent) liveness() error {
 
 func (c *Agent) readiness() error {
 	// Check the instance works primary or not
-	rows, err := c.db.Query("select @@read_only")
+	rows, err := c.db.Query("select @@read_only, @@super_read_only")
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
-	var readOnly bool
+	var readOnly, superReadOnly bool
 	for rows.Next() {
-		if err := rows.Scan(&readOnly); err != nil {
+		if err := rows.Scan(&readOnly, &superReadOnly); err != nil {
 			return err
 		}
 	}
@@ -244,12 +248,34 @@ func (c *Agent) readiness() error {
 			if status.LastError != "" {
 				return fmt.Errorf("slave has error: %s", status.LastError)
 			}
-			if status.SecondsBehindMaster.Int64 > int64(c.maxDelay.Seconds()) {
+			if replicationLag(status) > int64(c.maxDelay.Seconds()) {
 				return fmt.Errorf("slave is too far behind master")
 			}
 		}
 	case "LEADER":
 		{
+			// The standby leader replicates from the source cluster, it must keep read only.
+			status := &SlaveStatus{}
+			if err := c.db.GetContext(context.Background(), status, `show slave status`); err == nil && status.MasterHost != "" {
+				log.Infof("am standby leader, replicating from %s", status.MasterHost)
+				return nil
+			}
+			// The leader drained by a switchover keeps the writes stopped until it is undrained
+			// or demoted.
+			if _, err := os.Stat(drainedFile); err == nil {
+				log.Info("am leader drained by the switchover")
+				return nil
+			}
+			// The leader fenced for losing the quorum, or made super_read_only on purpose, is
+			// left to xenon.
+			if _, err := os.Stat(fencedFile); err == nil {
+				log.Info("am leader fenced for losing the quorum")
+				return nil
+			}
+			if superReadOnly {
+				log.Info("am leader but super_read_only is on")
+				return nil
+			}
 			if !utils.ExistUpdateFile() && readOnly {
 				log.Errorf("am leader but read_only is on")
 				if err := c.setGlobalReadOnlyOff(); err != nil {
@@ -272,7 +298,7 @@ func (c *Agent) readiness() error {
 			if status.LastError != "" {
 				return fmt.Errorf("slave has error: %s", status.LastError)
 			}
-			if status.SecondsBehindMaster.Int64 > int64(c.maxDelay.Seconds()) {
+			if replicationLag(status) > int64(c.maxDelay.Seconds()) {
 				return fmt.Errorf("slave is too far behind master")
 			}
 		}
@@ -280,6 +306,16 @@ func (c *Agent) readiness() error {
 	return nil
 }
 
+// replicationLag returns the seconds the slave is behind the master, the intended delay
+// of the delayed replica (MASTER_DELAY) is not counted as the lag.
+func replicationLag(status *SlaveStatus) int64 {
+	lag := status.SecondsBehindMaster.Int64 - int64(status.SQLDelay)
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
 func (c *Agent) postStart() error {
 	return nil
 }
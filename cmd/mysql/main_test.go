@@ -0,0 +1,117 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+)
+
+// newTestAgent returns the agent of the pod with the role label on the fake server.
+func newTestAgent(t *testing.T, server *sqltest.Server, role string) *Agent {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "sample-mysql-0",
+		Namespace: "default",
+		Labels:    map[string]string{"role": role},
+	}}
+	return &Agent{
+		db:        sqlx.NewDb(sqltest.NewDB(t, server), "mysql"),
+		ksClient:  fake.NewSimpleClientset(pod),
+		podName:   pod.Name,
+		nameSpace: pod.Namespace,
+	}
+}
+
+// setDrainedFile uses a temporary drained file, which is written if drained.
+func setDrainedFile(t *testing.T, drained bool) {
+	old := drainedFile
+	drainedFile = filepath.Join(t.TempDir(), "drained")
+	t.Cleanup(func() { drainedFile = old })
+	if drained {
+		assert.NoError(t, os.WriteFile(drainedFile, []byte{}, 0644))
+	}
+}
+
+func setFencedFile(t *testing.T, fenced bool) {
+	old := fencedFile
+	fencedFile = filepath.Join(t.TempDir(), "fenced")
+	t.Cleanup(func() { fencedFile = old })
+	if fenced {
+		assert.NoError(t, os.WriteFile(fencedFile, []byte{}, 0644))
+	}
+}
+
+func TestLeaderReadiness(t *testing.T) {
+	columns := []string{"@@read_only", "@@super_read_only"}
+	readOnly := &sqltest.Result{Columns: columns, Rows: [][]driver.Value{{int64(1), int64(0)}}}
+	superReadOnly := &sqltest.Result{Columns: columns, Rows: [][]driver.Value{{int64(1), int64(1)}}}
+
+	// The leader with read_only is made writable.
+	setDrainedFile(t, false)
+	setFencedFile(t, false)
+	server := &sqltest.Server{}
+	server.SetResult("select @@read_only, @@super_read_only", readOnly)
+	assert.NoError(t, newTestAgent(t, server, "LEADER").readiness())
+	assert.Contains(t, server.Stmts(), "set global read_only=0")
+
+	// The leader drained by a switchover stays read only.
+	setDrainedFile(t, true)
+	server = &sqltest.Server{}
+	server.SetResult("select @@read_only, @@super_read_only", readOnly)
+	assert.NoError(t, newTestAgent(t, server, "LEADER").readiness())
+	assert.NotContains(t, server.Stmts(), "set global read_only=0")
+
+	// The leader fenced for losing the quorum stays super_read_only.
+	setDrainedFile(t, false)
+	setFencedFile(t, true)
+	server = &sqltest.Server{}
+	server.SetResult("select @@read_only, @@super_read_only", superReadOnly)
+	assert.NoError(t, newTestAgent(t, server, "LEADER").readiness())
+	assert.NotContains(t, server.Stmts(), "set global read_only=0")
+
+	// The leader with super_read_only set by others stays super_read_only.
+	setFencedFile(t, false)
+	server = &sqltest.Server{}
+	server.SetResult("select @@read_only, @@super_read_only", superReadOnly)
+	assert.NoError(t, newTestAgent(t, server, "LEADER").readiness())
+	assert.NotContains(t, server.Stmts(), "set global read_only=0")
+}
+
+func TestReplicationLag(t *testing.T) {
+	status := &SlaveStatus{SecondsBehindMaster: sql.NullInt64{Int64: 40, Valid: true}}
+	assert.Equal(t, int64(40), replicationLag(status))
+
+	// The intended delay is not the lag.
+	status.SQLDelay = 3600
+	status.SecondsBehindMaster.Int64 = 3610
+	assert.Equal(t, int64(10), replicationLag(status))
+
+	// The delayed replica catching up with the delay.
+	status.SecondsBehindMaster.Int64 = 120
+	assert.Equal(t, int64(0), replicationLag(status))
+}
@@ -97,6 +97,44 @@ func main() {
 			},
 		}
 		cmd.AddCommand(reqBackupCmd)
+		pruneCmd := &cobra.Command{
+			Use:   "prune_backups",
+			Short: "delete the backups from S3 or NFS",
+			Args:  cobra.MinimumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := sidecar.RunPruneBackups(reqBackupCfg, args); err != nil {
+					log.Error(err, "run command failed")
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.AddCommand(pruneCmd)
+		verifyCmd := &cobra.Command{
+			Use:   "verify_restore",
+			Short: "restore a backup for verification",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := sidecar.RunVerifyRestore(reqBackupCfg, args[0]); err != nil {
+					log.Error(err, "run command failed")
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.AddCommand(verifyCmd)
+
+	case utils.ContainerBinlogName:
+		binlogCfg := sidecar.NewBinlogConfig()
+		archiveCmd := &cobra.Command{
+			Use:   "archive",
+			Short: "start archive binlogs",
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := sidecar.RunBinlogArchiver(binlogCfg, stop); err != nil {
+					log.Error(err, "run command failed")
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.AddCommand(archiveCmd)
 
 	default:
 		initCfg := sidecar.NewInitConfig()
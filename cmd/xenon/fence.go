@@ -0,0 +1,182 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/radondb/radondb-mysql-kubernetes/utils"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// XenonRaftStatus is the output of xenoncli raft status.
+type XenonRaftStatus struct {
+	Leader string   `json:"leader"`
+	State  string   `json:"state"`
+	Nodes  []string `json:"nodes"`
+}
+
+const (
+	// fenceInterval is the interval of checking the quorum.
+	fenceInterval = 5 * time.Second
+	// minFencingMembers is the least members to fence. With two members, the leader cannot tell
+	// whether it is isolated or the follower is down, fencing would stop the writes in both cases.
+	minFencingMembers = 3
+
+	// The actions of the fencing, which are the reasons of the events.
+	fenced   = "Fenced"
+	unfenced = "Unfenced"
+)
+
+// fencedFile marks that super_read_only was set by the fencing, so that only the fencing clears it.
+var fencedFile = XenonFencedFile
+
+// runFencing checks the quorum in the background of xenon, it never returns.
+func runFencing() {
+	for {
+		if err := fence(); err != nil {
+			log.Errorf("failed to fence: %s", err.Error())
+		}
+		time.Sleep(fenceInterval)
+	}
+}
+
+// fence sets the local MySQL super_read_only if the node is the leader but lost the quorum,
+// so that an isolated leader cannot accept writes even if the API server is unreachable.
+// It is cleared once the node is the leader with the quorum again.
+func fence() error {
+	status, err := getRaftStatus()
+	if err != nil {
+		return err
+	}
+	if len(status.Nodes) < minFencingMembers {
+		return nil
+	}
+	isLeader := status.State == string(Leader)
+	alive := len(status.Nodes)
+	if isLeader {
+		gtidList, err := getGTIDList()
+		if err != nil {
+			return err
+		}
+		alive = aliveNodes(gtidList)
+	}
+	quorum := hasQuorum(len(status.Nodes), alive)
+	if _, err := os.Stat(fencedFile); os.IsNotExist(err) && (!isLeader || quorum) {
+		return nil
+	}
+
+	conn, err := getLocalMySQLConn()
+	if err != nil {
+		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
+	}
+	defer conn.Close()
+	action, err := fenceMySQL(conn, isLeader, quorum)
+	if err != nil || action == "" {
+		return err
+	}
+	msg := fmt.Sprintf("leader lost the quorum with %d of %d nodes alive, set super_read_only", alive, len(status.Nodes))
+	eventType := corev1.EventTypeWarning
+	if action == unfenced {
+		msg = fmt.Sprintf("leader regained the quorum with %d of %d nodes alive, cleared super_read_only", alive, len(status.Nodes))
+		eventType = corev1.EventTypeNormal
+	}
+	log.Warn(msg)
+	// The API server may be unreachable as well, the operator records the fencing on its side.
+	if err := RecordEventTo(myself(""), eventType, action, msg); err != nil {
+		log.Errorf("failed to record the fencing event: %s", err.Error())
+	}
+	return nil
+}
+
+// fenceMySQL sets super_read_only on the leader without the quorum, and clears it once the
+// leader has the quorum again. It returns the action that was done, empty if nothing was done.
+// A node that is not the leader any more keeps super_read_only, leaderStart clears it if the
+// node is elected again.
+func fenceMySQL(db *sql.DB, isLeader, quorum bool) (string, error) {
+	if isLeader && !quorum {
+		var superReadOnly int
+		if err := db.QueryRow("SELECT @@super_read_only").Scan(&superReadOnly); err != nil {
+			return "", fmt.Errorf("failed to get super_read_only: %s", err.Error())
+		}
+		// Set by others, such as a switchover.
+		if superReadOnly == 1 {
+			return "", nil
+		}
+		if err := os.WriteFile(fencedFile, []byte{}, 0644); err != nil {
+			return "", fmt.Errorf("failed to mark the fencing: %s", err.Error())
+		}
+		if _, err := SetSuperReadOnly(db, true); err != nil {
+			return "", fmt.Errorf("failed to set super_read_only: %s", err.Error())
+		}
+		return fenced, nil
+	}
+
+	if _, err := os.Stat(fencedFile); os.IsNotExist(err) {
+		return "", nil
+	}
+	if !isLeader {
+		return "", os.Remove(fencedFile)
+	}
+	// The leader drained by a switchover and the leader of the standby cluster keep super_read_only.
+	if _, err := os.Stat(drainedFile); err == nil || isStandby() {
+		return "", os.Remove(fencedFile)
+	}
+	if _, err := SetSuperReadOnly(db, false); err != nil {
+		return "", fmt.Errorf("failed to clear super_read_only: %s", err.Error())
+	}
+	return unfenced, os.Remove(fencedFile)
+}
+
+// aliveNodes returns the number of the nodes that xenon can reach, including itself.
+func aliveNodes(gtidList []*GTID) int {
+	alive := 0
+	for _, gtid := range gtidList {
+		if gtid.Raft == string(Unknown) || gtid.Raft == "" {
+			continue
+		}
+		alive++
+	}
+	return alive
+}
+
+// hasQuorum returns true if the alive nodes are the majority of the members.
+// The quorum is assumed if the members are unknown.
+func hasQuorum(members, alive int) bool {
+	if members == 0 {
+		return true
+	}
+	return alive > members/2
+}
+
+func getRaftStatus() (*XenonRaftStatus, error) {
+	cmd := []string{"bash", "-c", raftStatusCommand}
+	res, stderr, err := runCommandLocal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exec xenoncli raft status: %s", stderr)
+	}
+	status := &XenonRaftStatus{}
+	if err := json.Unmarshal(res.Bytes(), status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal raft status: %s", err.Error())
+	}
+	return status, nil
+}
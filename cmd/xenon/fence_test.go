@@ -0,0 +1,190 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/radondb/radondb-mysql-kubernetes/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasQuorum(t *testing.T) {
+	assert.True(t, hasQuorum(3, 3))
+	assert.True(t, hasQuorum(3, 2))
+	assert.False(t, hasQuorum(3, 1))
+	assert.False(t, hasQuorum(4, 2))
+	assert.True(t, hasQuorum(5, 3))
+	assert.True(t, hasQuorum(1, 1))
+	// Unknown members.
+	assert.True(t, hasQuorum(0, 1))
+}
+
+func TestAliveNodes(t *testing.T) {
+	gtidList := []*GTID{
+		{ID: "sample-mysql-0", Raft: "LEADER"},
+		{ID: "sample-mysql-1", Raft: string(Unknown)},
+		{ID: "sample-mysql-2", Raft: ""},
+		{ID: "sample-mysql-3", Raft: "FOLLOWER"},
+	}
+	assert.Equal(t, 2, aliveNodes(gtidList))
+}
+
+func setFencedFile(t *testing.T) string {
+	old := fencedFile
+	fencedFile = filepath.Join(t.TempDir(), "fenced")
+	t.Cleanup(func() { fencedFile = old })
+	return fencedFile
+}
+
+func TestFenceMySQL(t *testing.T) {
+	file := setFencedFile(t)
+
+	// The leader with the quorum is never fenced.
+	server := &fakeServer{}
+	action, err := fenceMySQL(newFakeDB(t, server), true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Empty(t, server.stmts)
+
+	// Set by others, such as a switchover.
+	server = &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@super_read_only": {{int64(1)}},
+		},
+	}
+	action, err = fenceMySQL(newFakeDB(t, server), true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Equal(t, []string{"SELECT @@super_read_only"}, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFenceThenUnfence(t *testing.T) {
+	file := setFencedFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@super_read_only": {{int64(0)}},
+		},
+	}
+	db := newFakeDB(t, server)
+
+	// The leader lost the quorum.
+	action, err := fenceMySQL(db, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fenced, action)
+	assert.Equal(t, []string{"SELECT @@super_read_only", "SET GLOBAL super_read_only=1"}, server.stmts)
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+
+	// Still without the quorum, nothing more to do.
+	server.rows["SELECT @@super_read_only"] = [][]driver.Value{{int64(1)}}
+	server.stmts = nil
+	action, err = fenceMySQL(db, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Equal(t, []string{"SELECT @@super_read_only"}, server.stmts)
+
+	// The quorum is back and the node is still the leader.
+	server.stmts = nil
+	action, err = fenceMySQL(db, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, unfenced, action)
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=0"}, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	// Nothing to clear any more.
+	server.stmts = nil
+	action, err = fenceMySQL(db, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Empty(t, server.stmts)
+}
+
+func TestFenceThenDrained(t *testing.T) {
+	file := setFencedFile(t)
+	drained := setDrainedFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@super_read_only": {{int64(0)}},
+		},
+	}
+	db := newFakeDB(t, server)
+	action, err := fenceMySQL(db, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fenced, action)
+
+	// The leader was drained by a switchover meanwhile, it stays super_read_only.
+	assert.NoError(t, os.WriteFile(drained, []byte{}, 0644))
+	server.stmts = nil
+	action, err = fenceMySQL(db, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Empty(t, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFenceThenUnfenceStandby(t *testing.T) {
+	file := setFencedFile(t)
+	setStandby(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@super_read_only": {{int64(0)}},
+		},
+	}
+	db := newFakeDB(t, server)
+	action, err := fenceMySQL(db, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fenced, action)
+
+	// The leader of the standby cluster stays super_read_only when the quorum is back.
+	server.stmts = nil
+	action, err = fenceMySQL(db, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Empty(t, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFenceThenDemoted(t *testing.T) {
+	file := setFencedFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@super_read_only": {{int64(0)}},
+		},
+	}
+	db := newFakeDB(t, server)
+	action, err := fenceMySQL(db, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fenced, action)
+
+	// Another node was elected, the old leader stays super_read_only.
+	server.stmts = nil
+	action, err = fenceMySQL(db, false, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", action)
+	assert.Empty(t, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	. "github.com/radondb/radondb-mysql-kubernetes/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// longRunningWritesThreshold is the threshold in seconds of the long running writes.
+	longRunningWritesThreshold = 4
+	// leaderStopTimeout is the time leaderStop has before mysqld is killed.
+	leaderStopTimeout = 5 * time.Second
+)
+
+// maxConnectionsFile saves the max_connections lowered by leaderStop, leaderStart restores it.
+var maxConnectionsFile = XenonMetaVolumeMountPath + "/max-connections"
+
+// drainedFile marks the leader drained by a switchover, the readiness of mysql does not clear
+// its read_only. leaderStart removes it.
+var drainedFile = XenonDrainedFile
+
+// flushTablesWithReadLock is the name of the step which holds the global read lock.
+const flushTablesWithReadLock = "flush tables with read lock"
+
+// step is a step of the leader hooks, it returns the statement that was executed.
+type step struct {
+	name string
+	run  func() (string, error)
+}
+
+// StepResult is the result of a step.
+type StepResult struct {
+	Name      string `json:"name"`
+	Statement string `json:"statement,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Duration  string `json:"duration"`
+}
+
+// HookReport is the report of leaderStart or leaderStop.
+type HookReport struct {
+	Hook  string       `json:"hook"`
+	Steps []StepResult `json:"steps"`
+	// Failed is the name of the step that failed, empty if all the steps succeeded.
+	Failed string `json:"failed,omitempty"`
+}
+
+func (r *HookReport) String() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", r.Hook, r.Steps)
+	}
+	return string(data)
+}
+
+// runSteps runs the steps in order and stops at the first failed one.
+func runSteps(hook string, steps []step) (*HookReport, error) {
+	report := &HookReport{Hook: hook, Steps: []StepResult{}}
+	for _, s := range steps {
+		log.Infof("%s: %s", hook, s.name)
+		start := time.Now()
+		stmt, err := s.run()
+		result := StepResult{
+			Name:      s.name,
+			Statement: stmt,
+			Duration:  time.Since(start).String(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			report.Steps = append(report.Steps, result)
+			report.Failed = s.name
+			return report, fmt.Errorf("step %s failed: %s", s.name, err.Error())
+		}
+		report.Steps = append(report.Steps, result)
+	}
+	return report, nil
+}
+
+// leaderStartSteps returns the sql steps of leaderStart.
+func leaderStartSteps(db *sql.DB) []step {
+	return []step{
+		restoreMaxConnectionsStep(db),
+		{"disable super read only", func() (string, error) {
+			// The leader of the standby cluster replicates from the source, it stays super_read_only.
+			if isStandby() {
+				return "", nil
+			}
+			return SetSuperReadOnly(db, false)
+		}},
+		{"enable event scheduler", func() (string, error) {
+			return SetEventScheduler(db, true)
+		}},
+		{"enable semi-sync master", func() (string, error) {
+			return enableSemiSyncMaster(db)
+		}},
+		{"unmark drained", func() (string, error) {
+			if err := os.Remove(drainedFile); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			return "", nil
+		}},
+	}
+}
+
+// enableSemiSyncMaster enables the semi-sync master if the replication is semi-sync. The
+// operator enables the semi-sync slave on all the nodes only in semi-sync.
+func enableSemiSyncMaster(db *sql.DB) (string, error) {
+	query := "SELECT @@rpl_semi_sync_slave_enabled"
+	var slaveEnabled int
+	if err := db.QueryRow(query).Scan(&slaveEnabled); err != nil {
+		return query, err
+	}
+	if slaveEnabled != 1 {
+		return "", nil
+	}
+	return SetSemiSyncMaster(db, true)
+}
+
+// leaderStopSteps returns the sql steps of leaderStop.
+func leaderStopSteps(db *sql.DB) []step {
+	return []step{
+		{"disable event scheduler", func() (string, error) {
+			return SetEventScheduler(db, false)
+		}},
+		{"set super read only", func() (string, error) {
+			return SetSuperReadOnly(db, true)
+		}},
+		{"check long running writes", func() (string, error) {
+			num, stmt, err := CheckLongRunningWrites(db, longRunningWritesThreshold)
+			if err != nil {
+				return stmt, err
+			}
+			return stmt + " = " + strconv.Itoa(num), nil
+		}},
+		{"limit max connections", func() (string, error) {
+			return LimitMaxConnections(db, maxConnectionsFile)
+		}},
+		{"kill threads", func() (string, error) {
+			return "", KillThreads(db)
+		}},
+		{"require ssl for replication", func() (string, error) {
+			return RequireMasterSSL(db)
+		}},
+		{flushTablesWithReadLock, func() (string, error) {
+			return FlushTablesWithReadLock(db)
+		}},
+		{"flush binary logs", func() (string, error) {
+			return FlushBinaryLogs(db)
+		}},
+	}
+}
+
+// drainSteps returns the sql steps of drain, which are the steps of leaderStop without the global
+// read lock, since the lock is released as soon as the drain command exits. The leader is marked
+// drained first, so that the readiness of mysql does not enable the writes meanwhile.
+func drainSteps(db *sql.DB) []step {
+	steps := []step{
+		{"mark drained", func() (string, error) {
+			return "", os.WriteFile(drainedFile, []byte{}, 0644)
+		}},
+	}
+	for _, s := range leaderStopSteps(db) {
+		if s.name != flushTablesWithReadLock {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// demoteSteps returns the sql steps of leaderStop after the raft is disabled. The writes are
+// stopped by the steps of drain, then the max connections are restored, so that the node can
+// serve as a follower.
+func demoteSteps(db *sql.DB) []step {
+	return append(leaderStopSteps(db), restoreMaxConnectionsStep(db))
+}
+
+func restoreMaxConnectionsStep(db *sql.DB) step {
+	return step{"restore max connections", func() (string, error) {
+		return RestoreMaxConnections(db, maxConnectionsFile)
+	}}
+}
+
+// leaderStart is called by xenon after the node became the leader.
+func leaderStart() error {
+	log.Infof("leader start started")
+	conn, err := getLocalMySQLConn()
+	if err != nil {
+		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
+	}
+	defer conn.Close()
+
+	steps := append(leaderStartSteps(conn),
+		step{"patch role label", func() (string, error) {
+			return "", PatchRoleLabelTo(myself(string(Leader)))
+		}},
+		step{"patch leader endpoints", func() (string, error) {
+			return "", PatchLeaderEndpointsTo(myself(string(Leader)))
+		}},
+	)
+	report, err := runSteps("leaderStart", steps)
+	log.Infof("leader start report: %s", report)
+	return err
+}
+
+// leaderStop is called by xenon before the node gives up the leader. mysqld keeps running with
+// super_read_only once the steps succeeded, it is killed only if they cannot be done in time.
+// A readonly node, e.g. the old leader drained by a switchover, only restores the max connections.
+func leaderStop() error {
+	log.Infof("leader stop started")
+	conn, err := getLocalMySQLConn()
+	if err != nil {
+		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
+	}
+	defer conn.Close()
+
+	if isReadonly(conn) {
+		log.Info("I am readonly, skip the leader stop")
+		report, err := runSteps("leaderStop", []step{restoreMaxConnectionsStep(conn)})
+		log.Infof("leader stop report: %s", report)
+		return err
+	}
+	// Buffered so that the goroutine can exit after the timeout.
+	ch := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := enableMyRaft(); err != nil {
+				log.Error(err)
+			}
+		}()
+
+		steps := append([]step{
+			{"disable raft", func() (string, error) {
+				return raftDisableCommand, disableMyRaft()
+			}},
+		}, demoteSteps(conn)...)
+		report, err := runSteps("leaderStop", steps)
+		log.Infof("leader stop report: %s", report)
+		if err != nil {
+			// The node is demoted anyway, it must not be left with the limited max connections.
+			if _, err := RestoreMaxConnections(conn, maxConnectionsFile); err != nil {
+				log.Error(err)
+			}
+		}
+		ch <- err
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(leaderStopTimeout):
+		log.Info("timeout")
+		if err := killMysqld(); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// drain stops the writes on the leader by the steps of drain without giving up the leader,
+// the operator runs it on the old leader before a switchover.
+func drain() error {
+	conn, err := getLocalMySQLConn()
+	if err != nil {
+		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
+	}
+	defer conn.Close()
+
+	report, err := runSteps("drain", drainSteps(conn))
+	log.Infof("drain report: %s", report)
+	return err
+}
+
+// undrain restores the writes on the leader by the steps of leaderStart, the operator runs it
+// on the old leader when a switchover is rolled back.
+func undrain() error {
+	conn, err := getLocalMySQLConn()
+	if err != nil {
+		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
+	}
+	defer conn.Close()
+
+	report, err := runSteps("undrain", leaderStartSteps(conn))
+	log.Infof("undrain report: %s", report)
+	return err
+}
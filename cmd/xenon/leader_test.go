@@ -0,0 +1,387 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer records the statements and answers them with the preset rows or errors.
+type fakeServer struct {
+	mu    sync.Mutex
+	stmts []string
+	rows  map[string][][]driver.Value
+	errs  map[string]error
+}
+
+func (s *fakeServer) do(query string) ([][]driver.Value, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stmts = append(s.stmts, query)
+	return s.rows[query], s.errs[query]
+}
+
+var (
+	fakeServersMu sync.Mutex
+	fakeServers   = map[string]*fakeServer{}
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeServersMu.Lock()
+	defer fakeServersMu.Unlock()
+	return &fakeConn{server: fakeServers[name]}, nil
+}
+
+type fakeConn struct {
+	server *fakeServer
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{server: c.server, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	server *fakeServer
+	query  string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if _, err := s.server.do(s.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.server.do(s.query)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"c0"}
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+func newFakeDB(t *testing.T, server *fakeServer) *sql.DB {
+	fakeServersMu.Lock()
+	name := fmt.Sprintf("%s-%d", t.Name(), len(fakeServers))
+	fakeServers[name] = server
+	fakeServersMu.Unlock()
+	db, err := sql.Open("fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func setMaxConnectionsFile(t *testing.T) string {
+	old := maxConnectionsFile
+	maxConnectionsFile = filepath.Join(t.TempDir(), "max-connections")
+	t.Cleanup(func() { maxConnectionsFile = old })
+	return maxConnectionsFile
+}
+
+func setDrainedFile(t *testing.T) string {
+	old := drainedFile
+	drainedFile = filepath.Join(t.TempDir(), "drained")
+	t.Cleanup(func() { drainedFile = old })
+	return drainedFile
+}
+
+func setStandby(t *testing.T) {
+	old := standby
+	standby = "true"
+	t.Cleanup(func() { standby = old })
+}
+
+func stepNames(report *HookReport) []string {
+	names := []string{}
+	for _, s := range report.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestLeaderStart(t *testing.T) {
+	file := setMaxConnectionsFile(t)
+	assert.NoError(t, os.WriteFile(file, []byte("1024"), 0644))
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@rpl_semi_sync_slave_enabled": {{int64(1)}},
+		},
+	}
+	db := newFakeDB(t, server)
+
+	report, err := runSteps("leaderStart", leaderStartSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, "", report.Failed)
+	assert.Equal(t, []string{
+		"SET GLOBAL max_connections=1024",
+		"SET GLOBAL super_read_only=0",
+		"SET GLOBAL event_scheduler=1",
+		"SELECT @@rpl_semi_sync_slave_enabled",
+		"SET GLOBAL rpl_semi_sync_master_enabled=ON",
+	}, server.stmts)
+	// The saved max connections is consumed.
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	// Nothing to restore, and the semi-sync master is not enabled in async.
+	server = &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@rpl_semi_sync_slave_enabled": {{int64(0)}},
+		},
+	}
+	db = newFakeDB(t, server)
+	_, err = runSteps("leaderStart", leaderStartSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"SET GLOBAL super_read_only=0",
+		"SET GLOBAL event_scheduler=1",
+		"SELECT @@rpl_semi_sync_slave_enabled",
+	}, server.stmts)
+}
+
+func TestLeaderStartStandby(t *testing.T) {
+	setMaxConnectionsFile(t)
+	setStandby(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@rpl_semi_sync_slave_enabled": {{int64(0)}},
+		},
+	}
+	db := newFakeDB(t, server)
+
+	// The leader of the standby cluster stays super_read_only.
+	report, err := runSteps("leaderStart", leaderStartSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, "", report.Failed)
+	assert.Equal(t, "", report.Steps[1].Statement)
+	assert.Equal(t, []string{
+		"SET GLOBAL event_scheduler=1",
+		"SELECT @@rpl_semi_sync_slave_enabled",
+	}, server.stmts)
+}
+
+func TestLeaderStartFailFast(t *testing.T) {
+	setMaxConnectionsFile(t)
+	server := &fakeServer{
+		errs: map[string]error{
+			"SET GLOBAL super_read_only=0": errors.New("access denied"),
+		},
+	}
+	db := newFakeDB(t, server)
+
+	report, err := runSteps("leaderStart", leaderStartSteps(db))
+	assert.Error(t, err)
+	assert.Equal(t, "disable super read only", report.Failed)
+	assert.Equal(t, []string{"restore max connections", "disable super read only"}, stepNames(report))
+	assert.Equal(t, "access denied", report.Steps[1].Error)
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=0"}, server.stmts)
+}
+
+func TestLeaderStop(t *testing.T) {
+	file := setMaxConnectionsFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@max_connections":                    {{int64(1024)}},
+			"SHOW GLOBAL STATUS LIKE 'Threads_connected'": {{"Threads_connected", int64(3)}},
+			"SELECT @@ssl_ca":                             {{"/etc/mysql-ssl/ca.crt"}},
+			"SELECT Id FROM information_schema.PROCESSLIST WHERE Command NOT IN ('Binlog Dump', 'Binlog Dump GTID') " +
+				"AND User NOT IN ('system user', 'event_scheduler') AND Id != CONNECTION_ID()": {{int64(11)}, {int64(12)}},
+		},
+	}
+	server.rows[longRunningWritesQuery(t)] = [][]driver.Value{{int64(0)}}
+	db := newFakeDB(t, server)
+
+	report, err := runSteps("leaderStop", leaderStopSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, "", report.Failed)
+	assert.Equal(t, []string{
+		"disable event scheduler",
+		"set super read only",
+		"check long running writes",
+		"limit max connections",
+		"kill threads",
+		"require ssl for replication",
+		"flush tables with read lock",
+		"flush binary logs",
+	}, stepNames(report))
+	assert.Contains(t, server.stmts, "SET GLOBAL max_connections=3")
+	// The connections of the SUPER users are killed too.
+	kills := 0
+	for _, stmt := range server.stmts {
+		if stmt == "KILL ?" {
+			kills++
+		}
+	}
+	assert.Equal(t, 2, kills)
+	assert.Contains(t, server.stmts, "CHANGE MASTER TO MASTER_SSL=1")
+	assert.Equal(t, "FLUSH  BINARY LOGS", server.stmts[len(server.stmts)-1])
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "1024", string(data))
+}
+
+func TestLeaderStopFailFast(t *testing.T) {
+	setMaxConnectionsFile(t)
+	server := &fakeServer{
+		errs: map[string]error{
+			"SET GLOBAL super_read_only=1": errors.New("lock wait timeout"),
+		},
+	}
+	db := newFakeDB(t, server)
+
+	report, err := runSteps("leaderStop", leaderStopSteps(db))
+	assert.Error(t, err)
+	assert.Equal(t, "set super read only", report.Failed)
+	assert.Equal(t, []string{"disable event scheduler", "set super read only"}, stepNames(report))
+	assert.Equal(t, []string{"SET GLOBAL event_scheduler=0", "SET GLOBAL super_read_only=1"}, server.stmts)
+}
+
+func TestDemoteSteps(t *testing.T) {
+	file := setMaxConnectionsFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@max_connections":                    {{int64(1024)}},
+			"SHOW GLOBAL STATUS LIKE 'Threads_connected'": {{"Threads_connected", int64(3)}},
+			"SELECT @@ssl_ca":                             {{""}},
+		},
+	}
+	server.rows[longRunningWritesQuery(t)] = [][]driver.Value{{int64(0)}}
+	db := newFakeDB(t, server)
+
+	// The demoted leader serves as a follower with the original max connections.
+	report, err := runSteps("leaderStop", demoteSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, "restore max connections", report.Steps[len(report.Steps)-1].Name)
+	assert.Contains(t, server.stmts, "SET GLOBAL max_connections=3")
+	assert.Equal(t, "SET GLOBAL max_connections=1024", server.stmts[len(server.stmts)-1])
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	// The old leader drained by a switchover is readonly, its leaderStop only restores.
+	setDrainedFile(t)
+	server.stmts = nil
+	_, err = runSteps("drain", drainSteps(db))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "SET GLOBAL max_connections=1024", server.stmts[len(server.stmts)-1])
+	server.stmts = nil
+	_, err = runSteps("leaderStop", []step{restoreMaxConnectionsStep(db)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SET GLOBAL max_connections=1024"}, server.stmts)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDrainSteps(t *testing.T) {
+	setMaxConnectionsFile(t)
+	file := setDrainedFile(t)
+	server := &fakeServer{
+		rows: map[string][][]driver.Value{
+			"SELECT @@max_connections":                    {{int64(1024)}},
+			"SHOW GLOBAL STATUS LIKE 'Threads_connected'": {{"Threads_connected", int64(3)}},
+			"SELECT @@ssl_ca":                             {{""}},
+			"SELECT @@rpl_semi_sync_slave_enabled":        {{int64(0)}},
+		},
+	}
+	server.rows[longRunningWritesQuery(t)] = [][]driver.Value{{int64(0)}}
+	db := newFakeDB(t, server)
+
+	// The leader is marked drained, the global read lock is not taken by the short-lived command.
+	report, err := runSteps("drain", drainSteps(db))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"mark drained",
+		"disable event scheduler",
+		"set super read only",
+		"check long running writes",
+		"limit max connections",
+		"kill threads",
+		"require ssl for replication",
+		"flush binary logs",
+	}, stepNames(report))
+	assert.NotContains(t, server.stmts, "FLUSH NO_WRITE_TO_BINLOG TABLES WITH READ LOCK")
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+
+	// The mark is removed once the writes are restored.
+	_, err = runSteps("undrain", leaderStartSteps(db))
+	assert.NoError(t, err)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// longRunningWritesQuery returns the query of CheckLongRunningWrites.
+func longRunningWritesQuery(t *testing.T) string {
+	server := &fakeServer{}
+	db := newFakeDB(t, server)
+	CheckLongRunningWrites(db, longRunningWritesThreshold)
+	return server.stmts[0]
+}
@@ -51,6 +51,7 @@ var (
 	ns          string
 	podName     string
 	autoRebuild string
+	standby     string
 )
 
 type GTID struct {
@@ -73,6 +74,7 @@ func init() {
 	ns = os.Getenv("NAMESPACE")
 	podName = os.Getenv("POD_NAME")
 	autoRebuild = os.Getenv("AUTO_REBUILD")
+	standby = os.Getenv("STANDBY")
 	debugFlag, _ := strconv.ParseBool(os.Getenv("RADONDB_DEBUG"))
 	if debugFlag {
 		log.SetLevel(log.DebugLevel)
@@ -82,7 +84,7 @@ func init() {
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
+		log.Fatalf("Usage: %s leaderStart|leaderStop|drain|undrain|fence|liveness|readiness|postStart|preStop", os.Args[0])
 	}
 	switch os.Args[1] {
 	case "leaderStart":
@@ -93,6 +95,16 @@ func main() {
 		if err := leaderStop(); err != nil {
 			log.Fatalf("leaderStop failed: %s", err.Error())
 		}
+	case "drain":
+		if err := drain(); err != nil {
+			log.Fatalf("drain failed: %s", err.Error())
+		}
+	case "undrain":
+		if err := undrain(); err != nil {
+			log.Fatalf("undrain failed: %s", err.Error())
+		}
+	case "fence":
+		runFencing()
 	case "liveness":
 		if err := liveness(); err != nil {
 			log.Fatalf("liveness failed: %s", err.Error())
@@ -110,101 +122,8 @@ func main() {
 			log.Fatalf("postStop failed: %s", err.Error())
 		}
 	default:
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
-	}
-}
-
-// TODO
-func leaderStart() error {
-	return nil
-}
-
-func leaderStop() error {
-	log.Infof("leader stop started")
-	conn, err := getLocalMySQLConn()
-	if err != nil {
-		return fmt.Errorf("failed to get the connection of local MySQL: %s", err.Error())
-	}
-	defer conn.Close()
-
-	if isReadonly(conn) {
-		log.Info("I am readonly, skip the leader stop")
-		os.Exit(0)
-	}
-	ch := make(chan error)
-	go func() {
-		defer func() {
-			if err := enableMyRaft(); err != nil {
-				log.Error(err)
-			}
-		}()
-
-		log.Info("Raft disable")
-		if err := disableMyRaft(); err != nil {
-			log.Errorf("failed to failover: %v", err)
-			ch <- err
-		}
-
-		// Step 1: disable event scheduler
-		log.Info("Disabling event scheduler")
-		stmt, err := SetEventScheduler(conn, false)
-		if err != nil {
-			ch <- err
-		}
-		log.Infof("set event scheduler to false: %s", stmt)
-
-		// Step 2: set readonly
-		log.Info("Setting readonly")
-		stmt, err = SetReadOnly(conn, true)
-		if err != nil {
-			ch <- err
-		}
-		log.Infof("set readonly to true: %s", stmt)
-
-		// Step 3: check long running writes
-		log.Info("Checking long running writes")
-		num, stmt, err := CheckLongRunningWrites(conn, 4)
-		if err != nil {
-			ch <- err
-		}
-		log.Infof("%d,long running writes: %s", num, stmt)
-
-		// TODO: Step 4: set max connections
-
-		// Step 5: kill threads
-		log.Info("Killing threads")
-		err = KillThreads(conn)
-		if err != nil {
-			ch <- err
-		}
-
-		// Step 6: FlushTablesWithReadLock
-		log.Info("Flushing tables with read lock")
-		stmt, err = FlushTablesWithReadLock(conn)
-		if err != nil {
-			ch <- err
-		}
-		log.Info("flushed tables with read lock: ", stmt)
-
-		// Step 7: FlushBinaryLogs
-		log.Info("Flushing binary logs")
-		stmt, err = FlushBinaryLogs(conn)
-		if err != nil {
-			ch <- err
-		}
-		log.Info("flushed binary logs:", stmt)
-	}()
-	select {
-	case err := <-ch:
-		return err
-	case <-time.After(5 * time.Second):
-		log.Info("timeout")
-		if err := killMysqld(); err != nil {
-			return err
-		}
-		return nil
+		log.Fatalf("Usage: %s leaderStart|leaderStop|drain|undrain|fence|liveness|readiness|postStart|preStop", os.Args[0])
 	}
-
 }
 
 func liveness() error {
@@ -310,6 +229,12 @@ func enableAutoRebuild() bool {
 	return autoRebuild == "true"
 }
 
+// isStandby returns true if the cluster replicates from another source, the leader of the
+// standby cluster is kept super_read_only.
+func isStandby() bool {
+	return standby == "true"
+}
+
 func runCommandLocal(cmd []string) (bytes.Buffer, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -353,18 +278,6 @@ func SetEventScheduler(db *sql.DB, state bool) (string, error) {
 	return stmt, err
 }
 
-func SetReadOnly(db *sql.DB, state bool) (string, error) {
-	var err error
-	stmt := ""
-	if state {
-		stmt = "SET GLOBAL read_only=1"
-	} else {
-		stmt = "SET GLOBAL read_only=0"
-	}
-	_, err = db.Exec(stmt)
-	return stmt, err
-}
-
 func CheckLongRunningWrites(db *sql.DB, thresh int) (int, string, error) {
 	var count int
 	query := "select SUM(ct) from ( select count(*) as ct from information_schema.processlist  where command = 'Query' and time >= ? and info not like 'select%' union all select count(*) as ct  FROM  INFORMATION_SCHEMA.INNODB_TRX trx WHERE trx.trx_started < CURRENT_TIMESTAMP - INTERVAL ? SECOND) A"
@@ -372,27 +285,35 @@ func CheckLongRunningWrites(db *sql.DB, thresh int) (int, string, error) {
 	return count, query + "(" + strconv.Itoa(thresh) + ")", err
 }
 
+// KillThreads kills the connections of all the users including the SUPER ones, except the
+// binlog dump threads and the system threads, so that no transaction is running on the node.
 func KillThreads(db *sql.DB) error {
-	query := "SELECT Id FROM information_schema.PROCESSLIST WHERE Command != 'Binlog Dump GTID' AND User not in ('root','radondb_repl') AND Id != CONNECTION_ID()"
-	logs := query
+	query := "SELECT Id FROM information_schema.PROCESSLIST WHERE Command NOT IN ('Binlog Dump', 'Binlog Dump GTID') " +
+		"AND User NOT IN ('system user', 'event_scheduler') AND Id != CONNECTION_ID()"
 	rows, err := db.Query(query)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	// The threads are killed after the rows are closed, so that the connection is reused.
+	var ids []string
 	for rows.Next() {
 		var id int
-		err := rows.Scan(&id)
-		if err != nil {
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
 			return fmt.Errorf("failed to scan rows: %s", err.Error())
 		}
-		log, err := KillThread(db, strconv.Itoa(id))
-		logs += log
-		if err != nil {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := KillThread(db, id); err != nil {
 			return err
 		}
 	}
-	return err
+	return nil
 }
 
 // TODO: This is a hack to kill threads. We need to find a better way to do this
@@ -401,11 +322,94 @@ func KillThread(db *sql.DB, id string) (string, error) {
 	return "KILL ? (" + id + ")", err
 }
 
-// func SetMaxConnections(db *sql.DB, connections string) (string, error) {
-// 	query := "SET GLOBAL max_connections=" + connections
-// 	_, err := db.Exec(query)
-// 	return query, err
-// }
+func SetSuperReadOnly(db *sql.DB, state bool) (string, error) {
+	var err error
+	stmt := ""
+	if state {
+		stmt = "SET GLOBAL super_read_only=1"
+	} else {
+		stmt = "SET GLOBAL super_read_only=0"
+	}
+	_, err = db.Exec(stmt)
+	return stmt, err
+}
+
+func SetSemiSyncMaster(db *sql.DB, state bool) (string, error) {
+	var err error
+	stmt := ""
+	if state {
+		stmt = "SET GLOBAL rpl_semi_sync_master_enabled=ON"
+	} else {
+		stmt = "SET GLOBAL rpl_semi_sync_master_enabled=OFF"
+	}
+	_, err = db.Exec(stmt)
+	return stmt, err
+}
+
+func SetMaxConnections(db *sql.DB, connections int) (string, error) {
+	query := "SET GLOBAL max_connections=" + strconv.Itoa(connections)
+	_, err := db.Exec(query)
+	return query, err
+}
+
+// RequireMasterSSL requires SSL for the replication when the cluster certificates are used,
+// so that the CHANGE MASTER of xenon after the demotion connects to the new leader with SSL.
+func RequireMasterSSL(db *sql.DB) (string, error) {
+	var ca sql.NullString
+	query := "SELECT @@ssl_ca"
+	if err := db.QueryRow(query).Scan(&ca); err != nil {
+		return query, err
+	}
+	if !strings.HasPrefix(ca.String, TlsMountPath) {
+		return query, nil
+	}
+	query = "CHANGE MASTER TO MASTER_SSL=1"
+	_, err := db.Exec(query)
+	return query, err
+}
+
+// LimitMaxConnections lowers max_connections to the connected threads, so that no more
+// connections can come in. The original value is saved to file unless it was saved before.
+func LimitMaxConnections(db *sql.DB, file string) (string, error) {
+	var connections int
+	query := "SELECT @@max_connections"
+	if err := db.QueryRow(query).Scan(&connections); err != nil {
+		return query, err
+	}
+	var name string
+	var threads int
+	query = "SHOW GLOBAL STATUS LIKE 'Threads_connected'"
+	if err := db.QueryRow(query).Scan(&name, &threads); err != nil {
+		return query, err
+	}
+	// A saved value is the one before the last leaderStop, which was not restored.
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if err := os.WriteFile(file, []byte(strconv.Itoa(connections)), 0644); err != nil {
+			return "", fmt.Errorf("failed to save max connections: %s", err.Error())
+		}
+	}
+	return SetMaxConnections(db, threads)
+}
+
+// RestoreMaxConnections restores max_connections saved by LimitMaxConnections.
+func RestoreMaxConnections(db *sql.DB, file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read max connections: %s", err.Error())
+	}
+	connections, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse max connections: %s", err.Error())
+	}
+	stmt, err := SetMaxConnections(db, connections)
+	if err != nil {
+		return stmt, err
+	}
+	return stmt, os.Remove(file)
+}
 
 func FlushTablesWithReadLock(db *sql.DB) (string, error) {
 	query := "FLUSH NO_WRITE_TO_BINLOG TABLES WITH READ LOCK"
@@ -519,16 +523,24 @@ func WaitMySQLAvailable(timeout time.Duration) error {
 }
 
 func GetGTIDList() []*GTID {
+	gtidList, err := getGTIDList()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return gtidList
+}
+
+func getGTIDList() ([]*GTID, error) {
 	cmd := []string{"bash", "-c", mysqlGtidCommand}
 	res, stderr, err := runCommandLocal(cmd)
 	if err != nil {
-		log.Fatalf("failed to exec xenoncli cluster gtid json: %s", stderr)
+		return nil, fmt.Errorf("failed to exec xenoncli cluster gtid json: %s", stderr)
 	}
 	gtidList := GTIDList{GTID: []*GTID{}}
 	if err := json.Unmarshal(res.Bytes(), &gtidList); err != nil {
-		log.Fatalf("failed to unmarshal gtid: %s", err.Error())
+		return nil, fmt.Errorf("failed to unmarshal gtid: %s", err.Error())
 	}
-	return gtidList.GTID
+	return gtidList.GTID, nil
 }
 
 func formatGTIDSet(set string) string {
@@ -138,6 +138,12 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err := r.reconcileCronBackup(ctx, backup, backupResources.cronjobs, backupResources.jobs, cluster); err != nil {
 		log.Error(err, "unable to reconcile cron backup")
 	}
+	if result.RequeueAfter, err = r.reconcileRetention(ctx, backup, backupResources.jobs, cluster); err != nil {
+		log.Error(err, "unable to reconcile backup retention")
+	}
+	if err := r.reconcileVerification(ctx, backup, backupResources.jobs, cluster); err != nil {
+		log.Error(err, "unable to reconcile backup verification")
+	}
 	return patchClusterStatus()
 }
 
@@ -219,19 +225,22 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 		}
 	}
 	if backup.Spec.BackupSchedule != nil {
+		// remove last if field more 5
+		schedules := strings.Fields(backup.Spec.BackupSchedule.CronExpression)
+		if len(schedules) > 5 {
+			backup.Spec.BackupSchedule.CronExpression = strings.Join(schedules[:5], " ")
+			log.Info("rewrite CronExpression", "orign", strings.Join(schedules, " "), "new", backup.Spec.BackupSchedule.CronExpression)
+		}
 		// if the backup is a scheduled backup, ignore manual backups
 		return nil
 	}
 
-	// remove last if field more 5
-	schedules := strings.Fields(backup.Spec.BackupSchedule.CronExpression)
-	if len(schedules) > 5 {
-		backup.Spec.BackupSchedule.CronExpression = strings.Join(schedules[:5], " ")
-		log.Info("rewrite CronExpression", "orign", strings.Join(schedules, " "), "new", backup.Spec.BackupSchedule.CronExpression)
-	}
-
 	if len(manualBackupJobs) > 0 {
 		for _, job := range manualBackupJobs {
+			// the prune and verify jobs are owned by the backup too
+			if job.GetLabels()[LableManualJob] != "true" {
+				continue
+			}
 			if job.GetOwnerReferences()[0].Name == backup.GetName() {
 				currentBackupJob = job
 				break
@@ -250,7 +259,9 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 				manualStatus.BackupName = currentBackupJob.GetAnnotations()["backupName"]
 				manualStatus.BackupSize = currentBackupJob.GetAnnotations()["backupSize"]
 				manualStatus.BackupType = currentBackupJob.GetAnnotations()["backupType"]
-
+				manualStatus.ToLSN = currentBackupJob.GetAnnotations()[utils.JobAnonationToLSN]
+				manualStatus.BackupHost = currentBackupJob.GetAnnotations()[utils.JobAnonationHost]
+				manualStatus.BackupChain, manualStatus.ParentBackupName = getBackupChain(currentBackupJob)
 			}
 			if completed || failed {
 				manualStatus.Finished = true
@@ -274,6 +285,9 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 			backup.Status.CompletionTime = manualStatus.CompletionTime
 			backup.Status.StartTime = manualStatus.StartTime
 			backup.Status.Type = v1beta1.ManualBackupInitiator
+			backup.Status.ToLSN = manualStatus.ToLSN
+			backup.Status.BackupChain = manualStatus.BackupChain
+			backup.Status.ParentBackupName = manualStatus.ParentBackupName
 
 		}
 
@@ -385,6 +399,9 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 			sbs.BackupName = job.GetAnnotations()["backupName"]
 			sbs.BackupSize = job.GetAnnotations()["backupSize"]
 			sbs.BackupType = job.GetAnnotations()["backupType"]
+			sbs.ToLSN = job.GetAnnotations()[utils.JobAnonationToLSN]
+			sbs.BackupHost = job.GetAnnotations()[utils.JobAnonationHost]
+			sbs.BackupChain, sbs.ParentBackupName = getBackupChain(job)
 			sbs.CompletionTime = job.Status.CompletionTime
 			sbs.Failed = job.Status.Failed
 			sbs.Succeeded = job.Status.Succeeded
@@ -415,6 +432,9 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 		backup.Status.Type = v1beta1.CronJobBackupInitiator
 		backup.Status.State = latestScheduledStatus.State
 		backup.Status.BackupType = latestScheduledStatus.BackupType
+		backup.Status.ToLSN = latestScheduledStatus.ToLSN
+		backup.Status.BackupChain = latestScheduledStatus.BackupChain
+		backup.Status.ParentBackupName = latestScheduledStatus.ParentBackupName
 	}
 	// file the scheduled backup status
 	backup.Status.ScheduledBackups = scheduledStatus
@@ -441,7 +461,7 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	suspend := (cluster.Status.State != v1beta1.ClusterReadyState) || (cluster.Spec.Standby != nil)
+	suspend := (cluster.Status.State != v1beta1.ClusterReadyState) || (cluster.Spec.Standby != nil && cluster.Spec.Standby.Enabled)
 	cronJob := &batchv1beta1.CronJob{
 		ObjectMeta: objectMeta,
 		Spec: batchv1beta1.CronJobSpec{
@@ -476,6 +496,7 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 
 	// If backup.Spec.BackupOpts.S3 is not nil then use ENV BACKUP_TYPE=s3 and set the s3SecretName
 	// If backup.Spec.BackupOpts.NFS is not nil then use ENV BACKUP_TYPE=nfs and mount the nfs volume
+	// Azure and GCS are set like S3, PVC and Local are mounted like NFS.
 
 	backupHost := GetBackupHost(cluster)
 	backupImage := cluster.Spec.Backup.Image
@@ -487,8 +508,21 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 	var NFSVolume *corev1.Volume
 	var NFSVolumeMount *corev1.VolumeMount
 
-	if backup.Spec.BackupOpts.S3 != nil && backup.Spec.BackupOpts.NFS != nil {
-		return nil, errors.New("backup can only be configured with one of S3 or NFS")
+	storages := 0
+	for _, configured := range []bool{
+		backup.Spec.BackupOpts.S3 != nil,
+		backup.Spec.BackupOpts.NFS != nil,
+		backup.Spec.BackupOpts.PVC != nil,
+		backup.Spec.BackupOpts.Local != nil,
+		backup.Spec.BackupOpts.Azure != nil,
+		backup.Spec.BackupOpts.GCS != nil,
+	} {
+		if configured {
+			storages++
+		}
+	}
+	if storages > 1 {
+		return nil, errors.New("backup can only be configured with one of S3, NFS, PVC, Local, Azure or GCS")
 	}
 
 	if backup.Spec.BackupOpts.S3 != nil {
@@ -503,6 +537,28 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 
 	}
 
+	if backup.Spec.BackupOpts.Azure != nil {
+		azureSecretName := backup.Spec.BackupOpts.Azure.BackupSecretName
+		S3BackuptEnv = append(S3BackuptEnv,
+			getEnvVarFromSecret(azureSecretName, "AZURE_STORAGE_ACCOUNT", "azure-storage-account", false),
+			getEnvVarFromSecret(azureSecretName, "AZURE_ACCESS_KEY", "azure-access-key", false),
+			getEnvVarFromSecret(azureSecretName, "AZURE_CONTAINER_NAME", "azure-container-name", false),
+			getEnvVarFromSecret(azureSecretName, "AZURE_ENDPOINT", "azure-endpoint", true),
+		)
+		backupTypeEnv = corev1.EnvVar{Name: "BACKUP_TYPE", Value: "azure"}
+	}
+
+	if backup.Spec.BackupOpts.GCS != nil {
+		gcsSecretName := backup.Spec.BackupOpts.GCS.BackupSecretName
+		S3BackuptEnv = append(S3BackuptEnv,
+			getEnvVarFromSecret(gcsSecretName, "GCS_ENDPOINT", "gcs-endpoint", true),
+			getEnvVarFromSecret(gcsSecretName, "GCS_ACCESSKEY", "gcs-access-key", false),
+			getEnvVarFromSecret(gcsSecretName, "GCS_SECRETKEY", "gcs-secret-key", false),
+			getEnvVarFromSecret(gcsSecretName, "GCS_BUCKET", "gcs-bucket", false),
+		)
+		backupTypeEnv = corev1.EnvVar{Name: "BACKUP_TYPE", Value: "gcs"}
+	}
+
 	if backup.Spec.BackupOpts.NFS != nil {
 		NFSVolume = &corev1.Volume{
 			Name:         "nfs-backup",
@@ -516,6 +572,30 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 
 	}
 
+	if backup.Spec.BackupOpts.PVC != nil {
+		NFSVolume = &corev1.Volume{
+			Name:         "nfs-backup",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &backup.Spec.BackupOpts.PVC.Volume},
+		}
+		NFSVolumeMount = &corev1.VolumeMount{
+			Name:      "nfs-backup",
+			MountPath: "/backup",
+		}
+		backupTypeEnv = corev1.EnvVar{Name: "BACKUP_TYPE", Value: "pvc"}
+	}
+
+	if backup.Spec.BackupOpts.Local != nil {
+		NFSVolume = &corev1.Volume{
+			Name:         "nfs-backup",
+			VolumeSource: corev1.VolumeSource{HostPath: &backup.Spec.BackupOpts.Local.Volume},
+		}
+		NFSVolumeMount = &corev1.VolumeMount{
+			Name:      "nfs-backup",
+			MountPath: "/backup",
+		}
+		backupTypeEnv = corev1.EnvVar{Name: "BACKUP_TYPE", Value: "local"}
+	}
+
 	container := corev1.Container{
 		Env: []corev1.EnvVar{
 			{Name: "CONTAINER_TYPE", Value: utils.ContainerBackupJobName},
@@ -560,6 +640,16 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 	}
 
 	container.Env = append(container.Env, backupTypeEnv)
+	if len(backup.Spec.BackupOpts.Compression) != 0 {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_COMPRESSION", Value: backup.Spec.BackupOpts.Compression})
+	}
+	if backup.Spec.BackupOpts.Encrypt {
+		// the backup server encrypts with the key of the cluster, it is not sent by the job.
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_ENCRYPT", Value: "true"})
+	}
+	if getBackupType(backup) == v1beta1.IncrementalBackupType {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_INCREMENTAL", Value: "true"})
+	}
 
 	jobSpec := &batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
@@ -575,10 +665,43 @@ func generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster
 	if NFSVolume != nil {
 		jobSpec.Template.Spec.Volumes = []corev1.Volume{*NFSVolume}
 	}
+	// The backup job presents the cert of the cluster to the sidecar backup server.
+	tlsSecret := cluster.Spec.CustomTLSSecret
+	if tlsSecret == nil && cluster.Spec.AutoTLS {
+		// The secret generated by the operator, the private key of the CA is not projected.
+		tlsSecret = &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: fmt.Sprintf("%s-tls", cluster.Name)},
+			Items: []corev1.KeyToPath{
+				{Key: utils.TLSCAKey, Path: utils.TLSCAKey},
+				{Key: utils.TLSCertKey, Path: utils.TLSCertKey},
+				{Key: utils.TLSPKey, Path: utils.TLSPKey},
+			},
+		}
+	}
+	if tlsSecret != nil {
+		jobSpec.Template.Spec.Volumes = append(jobSpec.Template.Spec.Volumes, corev1.Volume{
+			Name: utils.TlsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{{Secret: tlsSecret}},
+				},
+			},
+		})
+		jobSpec.Template.Spec.Containers[0].VolumeMounts = append(jobSpec.Template.Spec.Containers[0].VolumeMounts,
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName,
+				MountPath: utils.XBackupTlsMountPath,
+				ReadOnly:  true,
+			})
+	}
 	var backoffLimit int32 = 1
 
 	jobSpec.Template.Spec.Tolerations = cluster.Spec.Tolerations
 	jobSpec.Template.Spec.Affinity = cluster.Spec.Affinity
+	// The directory on the host only exists on the node, pin the backup jobs to it.
+	if local := backup.Spec.BackupOpts.Local; local != nil {
+		jobSpec.Template.Spec.NodeSelector = map[string]string{corev1.LabelHostname: local.NodeName}
+	}
 	jobSpec.BackoffLimit = &backoffLimit
 	return jobSpec, nil
 }
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+func TestReconcileManualBackupSkipsSchedule(t *testing.T) {
+	r := &BackupReconciler{}
+	backup := &v1beta1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "sample-backup",
+			Labels: map[string]string{"cluster": "sample"},
+		},
+		Spec: v1beta1.BackupSpec{
+			ClusterName:    "sample",
+			BackupSchedule: &v1beta1.BackupSchedule{CronExpression: "0 0 * * * *"},
+		},
+	}
+	assert.NoError(t, r.reconcileManualBackup(context.TODO(), backup, nil, &v1beta1.MysqlCluster{}))
+	// The seconds field is dropped, and no manual backup is started.
+	assert.Equal(t, "0 0 * * *", backup.Spec.BackupSchedule.CronExpression)
+	assert.Nil(t, backup.Status.ManualBackup)
+}
+
+func TestGenerateBackupJobSpecLocal(t *testing.T) {
+	backup := &v1beta1.Backup{
+		Spec: v1beta1.BackupSpec{
+			ClusterName: "sample",
+			BackupOpts: v1beta1.BackupOps{Local: &v1beta1.Local{
+				Volume:   corev1.HostPathVolumeSource{Path: "/data/backups"},
+				NodeName: "node-1",
+			}},
+		},
+	}
+	cluster := &v1beta1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample"}}
+	spec, err := generateBackupJobSpec(backup, cluster, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{corev1.LabelHostname: "node-1"}, spec.Template.Spec.NodeSelector)
+	assert.Equal(t, "/data/backups", spec.Template.Spec.Volumes[0].HostPath.Path)
+
+	backup.Spec.BackupOpts = v1beta1.BackupOps{S3: &v1beta1.S3{BackupSecretName: "s3-secret"}}
+	spec, err = generateBackupJobSpec(backup, cluster, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, spec.Template.Spec.NodeSelector)
+}
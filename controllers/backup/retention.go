@@ -0,0 +1,238 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+const (
+	// retentionCheckInterval is the interval to check the backups older than the retention days.
+	retentionCheckInterval = time.Hour
+	// maxBackupRecords is the number of the latest backups kept in the status without retention
+	// policy. With the policy, the records are kept until the backups are pruned.
+	maxBackupRecords = 100
+	// maxPrunedBackupRecords is the number of the latest pruned backups kept in the status.
+	maxPrunedBackupRecords = 20
+)
+
+// reconcileRetention prunes the backups out of the retention policy. The objects in S3 or the
+// directories in NFS are deleted by a prune job, then the backups are recorded in the status.
+// It returns the interval to check the retention again.
+func (r *BackupReconciler) reconcileRetention(ctx context.Context, backup *v1beta1.Backup,
+	jobs []*batchv1.Job, cluster *v1beta1.MysqlCluster) (time.Duration, error) {
+	log := log.FromContext(ctx).WithValues("backup", "Retention")
+
+	recordBackups(backup)
+	days, count := getRetentionPolicy(backup)
+	if days == nil && count == nil {
+		trimBackupRecords(backup)
+		return 0, nil
+	}
+
+	for _, job := range jobs {
+		if job.GetLabels()[LablePruneJob] != "true" || !metav1.IsControlledBy(job, backup) {
+			continue
+		}
+		switch {
+		case jobCompleted(job):
+			pruned := strings.Split(job.GetAnnotations()[AnnotationPruneBackups], ",")
+			log.Info("backups pruned", "backups", pruned)
+			markBackupsPruned(backup, pruned)
+		case jobFailed(job):
+			log.Info("failed to prune backups, retry later", "job", job.Name)
+		default:
+			// wait for the prune job finished.
+			return retentionCheckInterval, nil
+		}
+		if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			return retentionCheckInterval, client.IgnoreNotFound(err)
+		}
+	}
+
+	expired := getExpiredBackups(backup.Status.Backups, days, count, time.Now())
+	if len(expired) == 0 {
+		return retentionCheckInterval, nil
+	}
+
+	log.Info("prune the expired backups", "backups", expired)
+	pruneJob := &batchv1.Job{}
+	pruneJob.ObjectMeta = PruneJobMeta(cluster)
+	labels := PruneJobLabels(cluster.Name)
+	pruneJob.ObjectMeta.Labels = labels
+	pruneJob.ObjectMeta.Annotations = map[string]string{
+		AnnotationPruneBackups: strings.Join(expired, ","),
+	}
+	spec, err := generateBackupJobSpec(backup, cluster, labels)
+	if err != nil {
+		return retentionCheckInterval, errors.WithStack(err)
+	}
+	spec.Template.Spec.Containers[0].Args = append([]string{"prune_backups"}, expired...)
+	pruneJob.Spec = *spec
+
+	pruneJob.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("Job"))
+	if err := controllerutil.SetControllerReference(backup, pruneJob,
+		r.Client.Scheme()); err != nil {
+		return retentionCheckInterval, errors.WithStack(err)
+	}
+	return retentionCheckInterval, errors.WithStack(r.Client.Create(ctx, pruneJob))
+}
+
+// getRetentionPolicy returns the retention days and count of the backup.
+func getRetentionPolicy(backup *v1beta1.Backup) (*int32, *int32) {
+	if backup.Spec.BackupSchedule != nil {
+		return backup.Spec.BackupSchedule.BackupRetention, backup.Spec.BackupSchedule.BackupRetentionCount
+	}
+	if backup.Spec.Manual != nil {
+		return backup.Spec.Manual.BackupRetention, backup.Spec.Manual.BackupRetentionCount
+	}
+	return nil, nil
+}
+
+// recordBackups records the succeeded backups in the status, the backup jobs may be deleted
+// by the history limit, but the backups are kept until pruned. The incremental backups find
+// their parent in the records.
+func recordBackups(backup *v1beta1.Backup) {
+	known := map[string]bool{}
+	for _, b := range backup.Status.Backups {
+		known[b.Name] = true
+	}
+	for _, b := range backup.Status.PrunedBackups {
+		known[b.Name] = true
+	}
+	record := func(state v1beta1.BackupConditionType, r v1beta1.BackupRecord) {
+		if len(r.Name) == 0 || state != v1beta1.BackupSucceeded || known[r.Name] {
+			return
+		}
+		known[r.Name] = true
+		backup.Status.Backups = append(backup.Status.Backups, r)
+	}
+	if s := backup.Status.ManualBackup; s != nil {
+		record(s.State, v1beta1.BackupRecord{
+			Name:           s.BackupName,
+			CompletionTime: s.CompletionTime,
+			BackupChain:    s.BackupChain,
+			BackupType:     s.BackupType,
+			BackupHost:     s.BackupHost,
+			ToLSN:          s.ToLSN,
+		})
+	}
+	for _, s := range backup.Status.ScheduledBackups {
+		record(s.State, v1beta1.BackupRecord{
+			Name:           s.BackupName,
+			CompletionTime: s.CompletionTime,
+			BackupChain:    s.BackupChain,
+			BackupType:     s.BackupType,
+			BackupHost:     s.BackupHost,
+			ToLSN:          s.ToLSN,
+		})
+	}
+}
+
+// trimBackupRecords keeps the latest records of the backups without retention policy, which are
+// never pruned by the operator. The backups in the chain of a kept record are kept too, so that
+// the incremental backups still find their parents.
+func trimBackupRecords(backup *v1beta1.Backup) {
+	n := len(backup.Status.Backups)
+	if n <= maxBackupRecords {
+		return
+	}
+	needed := map[string]bool{}
+	for _, b := range backup.Status.Backups[n-maxBackupRecords:] {
+		for _, name := range b.BackupChain {
+			needed[name] = true
+		}
+	}
+	backups := []v1beta1.BackupRecord{}
+	for i, b := range backup.Status.Backups {
+		if i >= n-maxBackupRecords || needed[b.Name] {
+			backups = append(backups, b)
+		}
+	}
+	backup.Status.Backups = backups
+}
+
+// markBackupsPruned moves the pruned backups from the backups to the pruned backups.
+func markBackupsPruned(backup *v1beta1.Backup, pruned []string) {
+	isPruned := map[string]bool{}
+	for _, name := range pruned {
+		isPruned[name] = true
+	}
+	now := metav1.Now()
+	backups := []v1beta1.BackupRecord{}
+	for _, b := range backup.Status.Backups {
+		if isPruned[b.Name] {
+			b.PruneTime = &now
+			backup.Status.PrunedBackups = append(backup.Status.PrunedBackups, b)
+			continue
+		}
+		backups = append(backups, b)
+	}
+	backup.Status.Backups = backups
+	if n := len(backup.Status.PrunedBackups); n > maxPrunedBackupRecords {
+		backup.Status.PrunedBackups = backup.Status.PrunedBackups[n-maxPrunedBackupRecords:]
+	}
+}
+
+// getExpiredBackups returns the backups older than the retention days or beyond the retention
+// count. The backups in the chain of a retained incremental backup are not expired.
+func getExpiredBackups(backups []v1beta1.BackupRecord, days, count *int32, now time.Time) []string {
+	sorted := make([]v1beta1.BackupRecord, len(backups))
+	copy(sorted, backups)
+	// newest first, the backup without completion time is regarded as the newest.
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CompletionTime == nil || sorted[j].CompletionTime == nil {
+			return sorted[j].CompletionTime != nil
+		}
+		return sorted[j].CompletionTime.Before(sorted[i].CompletionTime)
+	})
+
+	var expired []v1beta1.BackupRecord
+	needed := map[string]bool{}
+	for i, b := range sorted {
+		tooMany := count != nil && i >= int(*count)
+		tooOld := days != nil && b.CompletionTime != nil &&
+			b.CompletionTime.Time.Before(now.AddDate(0, 0, -int(*days)))
+		if tooMany || tooOld {
+			expired = append(expired, b)
+			continue
+		}
+		for _, name := range b.BackupChain {
+			needed[name] = true
+		}
+	}
+
+	var names []string
+	for _, b := range expired {
+		if !needed[b.Name] {
+			names = append(names, b.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+func TestRecordBackups(t *testing.T) {
+	completionTime := metav1.NewTime(time.Now())
+	backup := &v1beta1.Backup{
+		Status: v1beta1.BackupStatus{
+			ScheduledBackups: []v1beta1.ScheduledBackupStatus{
+				{
+					BackupName:     "full",
+					State:          v1beta1.BackupSucceeded,
+					CompletionTime: &completionTime,
+					BackupType:     "S3",
+					BackupHost:     "sample-mysql-1",
+					ToLSN:          "100",
+					BackupChain:    []string{"full"},
+				},
+				{BackupName: "failed", State: v1beta1.BackupFailed},
+			},
+			PrunedBackups: []v1beta1.BackupRecord{{Name: "pruned"}},
+		},
+	}
+	backup.Status.ScheduledBackups = append(backup.Status.ScheduledBackups,
+		v1beta1.ScheduledBackupStatus{BackupName: "pruned", State: v1beta1.BackupSucceeded})
+
+	recordBackups(backup)
+	expect := []v1beta1.BackupRecord{
+		{
+			Name:           "full",
+			CompletionTime: &completionTime,
+			BackupChain:    []string{"full"},
+			BackupType:     "S3",
+			BackupHost:     "sample-mysql-1",
+			ToLSN:          "100",
+		},
+	}
+	assert.Equal(t, expect, backup.Status.Backups)
+
+	// The records are kept after the jobs are deleted by the history limit.
+	backup.Status.ScheduledBackups = nil
+	recordBackups(backup)
+	assert.Equal(t, expect, backup.Status.Backups)
+}
+
+func TestTrimBackupRecords(t *testing.T) {
+	backup := &v1beta1.Backup{}
+	for i := 0; i < maxBackupRecords+5; i++ {
+		backup.Status.Backups = append(backup.Status.Backups, v1beta1.BackupRecord{Name: fmt.Sprintf("backup-%d", i)})
+	}
+	// The records beyond the limit are kept until the backups are pruned.
+	recordBackups(backup)
+	assert.Len(t, backup.Status.Backups, maxBackupRecords+5)
+
+	// The parent of a kept incremental backup is not trimmed.
+	last := &backup.Status.Backups[maxBackupRecords+4]
+	last.BackupChain = []string{"backup-1", "backup-3", last.Name}
+	trimBackupRecords(backup)
+	assert.Len(t, backup.Status.Backups, maxBackupRecords+2)
+	assert.Equal(t, "backup-1", backup.Status.Backups[0].Name)
+	assert.Equal(t, "backup-3", backup.Status.Backups[1].Name)
+	assert.Equal(t, "backup-5", backup.Status.Backups[2].Name)
+}
+
+func TestMarkBackupsPruned(t *testing.T) {
+	backup := &v1beta1.Backup{
+		Status: v1beta1.BackupStatus{
+			Backups: []v1beta1.BackupRecord{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		},
+	}
+	for i := 0; i < maxPrunedBackupRecords; i++ {
+		backup.Status.PrunedBackups = append(backup.Status.PrunedBackups, v1beta1.BackupRecord{Name: fmt.Sprintf("old-%d", i)})
+	}
+	markBackupsPruned(backup, []string{"a", "c"})
+	assert.Equal(t, []v1beta1.BackupRecord{{Name: "b"}}, backup.Status.Backups)
+	assert.Len(t, backup.Status.PrunedBackups, maxPrunedBackupRecords)
+	assert.Equal(t, "old-2", backup.Status.PrunedBackups[0].Name)
+	last := backup.Status.PrunedBackups[maxPrunedBackupRecords-1]
+	assert.Equal(t, "c", last.Name)
+	assert.NotNil(t, last.PruneTime)
+}
+
+func TestGetRetentionPolicy(t *testing.T) {
+	seven, three := int32(7), int32(3)
+	// The manual backups are kept unless the retention is set.
+	days, count := getRetentionPolicy(&v1beta1.Backup{Spec: v1beta1.BackupSpec{Manual: &v1beta1.ManualBackup{}}})
+	assert.Nil(t, days)
+	assert.Nil(t, count)
+
+	days, count = getRetentionPolicy(&v1beta1.Backup{Spec: v1beta1.BackupSpec{
+		BackupSchedule: &v1beta1.BackupSchedule{BackupRetention: &seven, BackupRetentionCount: &three},
+	}})
+	assert.Equal(t, &seven, days)
+	assert.Equal(t, &three, count)
+}
+
+func TestGetExpiredBackups(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) *metav1.Time {
+		t := metav1.NewTime(now.AddDate(0, 0, -days))
+		return &t
+	}
+	backups := []v1beta1.BackupRecord{
+		{Name: "full-1", CompletionTime: daysAgo(10), BackupChain: []string{"full-1"}},
+		{Name: "full-2", CompletionTime: daysAgo(6), BackupChain: []string{"full-2"}},
+		{Name: "incr-1", CompletionTime: daysAgo(5), BackupChain: []string{"full-2", "incr-1"}},
+		{Name: "incr-2", CompletionTime: daysAgo(1), BackupChain: []string{"full-2", "incr-1", "incr-2"}},
+		// The backup without completion time is regarded as the newest.
+		{Name: "unknown"},
+	}
+	seven, two, one := int32(7), int32(2), int32(1)
+	testCases := []struct {
+		days, count *int32
+		expired     []string
+	}{
+		{nil, nil, nil},
+		{&seven, nil, []string{"full-1"}},
+		// The chain of the retained incremental backup is kept.
+		{nil, &two, []string{"full-1"}},
+		{&seven, &one, []string{"incr-2", "incr-1", "full-2", "full-1"}},
+		{&two, nil, []string{"full-1"}},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expired, getExpiredBackups(backups, tc.days, tc.count, now))
+	}
+
+	// The chain is pruned once no retained backup needs it.
+	backups = append(backups[:4:4], v1beta1.BackupRecord{Name: "full-3", CompletionTime: daysAgo(0), BackupChain: []string{"full-3"}})
+	assert.Equal(t, []string{"incr-2", "incr-1", "full-2", "full-1"}, getExpiredBackups(backups, nil, &one, now))
+}
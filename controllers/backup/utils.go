@@ -18,6 +18,7 @@ package backup
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
@@ -34,11 +35,17 @@ const (
 	LabelCluster   = labelPrefix + "cluster"
 	LableCronJob   = labelPrefix + "cronjob"
 	LableManualJob = labelPrefix + "manualjob"
+	LablePruneJob  = labelPrefix + "prunejob"
+	LableVerifyJob = labelPrefix + "verifyjob"
 )
 
 // Define the annotation of backup.
 const (
 	AnnotationPrefix = "backups.mysql.radondb.com/"
+	// AnnotationPruneBackups is the backups deleted by the prune job.
+	AnnotationPruneBackups = AnnotationPrefix + "prune-backups"
+	// AnnotationVerifyBackup is the backup restored by the verify job.
+	AnnotationVerifyBackup = AnnotationPrefix + "verify-backup"
 )
 
 func BackupSelector(clusterName string) labels.Selector {
@@ -91,6 +98,34 @@ func ManualBackupLabels(clusterName string) labels.Set {
 	}
 }
 
+func PruneJobMeta(cluster *v1beta1.MysqlCluster) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      cluster.GetName() + "-prune-" + rand.String(4),
+		Namespace: cluster.GetNamespace(),
+	}
+}
+
+func PruneJobLabels(clusterName string) labels.Set {
+	return map[string]string{
+		LabelCluster:  clusterName,
+		LablePruneJob: "true",
+	}
+}
+
+func VerifyJobMeta(cluster *v1beta1.MysqlCluster) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      cluster.GetName() + "-verify-" + rand.String(4),
+		Namespace: cluster.GetNamespace(),
+	}
+}
+
+func VerifyJobLabels(clusterName string) labels.Set {
+	return map[string]string{
+		LabelCluster:   clusterName,
+		LableVerifyJob: "true",
+	}
+}
+
 func CronBackupLabels(clusterName string) labels.Set {
 	return map[string]string{
 		LabelCluster: clusterName,
@@ -110,6 +145,30 @@ func GetBackupHost(cluster *v1beta1.MysqlCluster) string {
 	return host
 }
 
+// getBackupType returns the backup type of the backup, full or incremental.
+func getBackupType(backup *v1beta1.Backup) string {
+	if backup.Spec.BackupSchedule != nil {
+		return backup.Spec.BackupSchedule.BackupType
+	}
+	if backup.Spec.Manual != nil {
+		return backup.Spec.Manual.BackupType
+	}
+	return v1beta1.FullBackupType
+}
+
+// getBackupChain returns the backup chain and the parent backup from the job annotations.
+func getBackupChain(job *batchv1.Job) ([]string, string) {
+	chain := job.GetAnnotations()[utils.JobAnonationChain]
+	if len(chain) == 0 {
+		return nil, ""
+	}
+	backups := strings.Split(chain, ",")
+	if len(backups) < 2 {
+		return backups, ""
+	}
+	return backups, backups[len(backups)-2]
+}
+
 func GetXtrabackupURL(backupHost string) string {
 	xtrabackupPort := utils.XBackupPort
 	url := fmt.Sprintf("%s:%d", backupHost, xtrabackupPort)
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// verifyScript starts mysqld on the restored data directory without networking and grant
+// tables, then runs CHECK TABLE of all the tables and the sanity queries passed as arguments.
+// Any failure, including mysqlcheck piped to tee, fails the job.
+const verifyScript = `set -e
+set -o pipefail
+mysqld --defaults-file=/etc/mysql/my.cnf --user=mysql --skip-networking --skip-grant-tables \
+	--skip-slave-start --socket=/tmp/verify.sock --log-error=/var/log/mysql/mysql-error.log &
+pid=$!
+mysql=( mysql --socket=/tmp/verify.sock -uroot )
+for i in {300..0}; do
+	if "${mysql[@]}" -e 'SELECT 1' >/dev/null 2>&1; then
+		break
+	fi
+	if ! kill -0 $pid 2>/dev/null; then
+		i=0
+		break
+	fi
+	sleep 1
+done
+if [ "$i" = 0 ]; then
+	echo >&2 'mysqld failed to start on the restored backup.'
+	cat >&2 /var/log/mysql/mysql-error.log
+	exit 1
+fi
+mysqlcheck --socket=/tmp/verify.sock -uroot --all-databases --check | tee /tmp/check.log
+if grep -qE '^(error|Error) *:' /tmp/check.log; then
+	echo >&2 'CHECK TABLE failed.'
+	exit 1
+fi
+for query in "$@"; do
+	echo "run the sanity query: $query"
+	"${mysql[@]}" -e "$query"
+done
+"${mysql[@]}" -e 'SHUTDOWN'
+wait $pid
+echo 'the backup is verified.'
+`
+
+// reconcileVerification verifies the latest backup by restoring it in a scratch pod, the
+// verify job is kept until a newer backup is verified, so that its logs can be checked.
+func (r *BackupReconciler) reconcileVerification(ctx context.Context, backup *v1beta1.Backup,
+	jobs []*batchv1.Job, cluster *v1beta1.MysqlCluster) error {
+	if backup.Spec.Verification == nil {
+		return nil
+	}
+	log := log.FromContext(ctx).WithValues("backup", "Verification")
+
+	latest := getLatestBackup(backup.Status.Backups)
+	if latest == nil {
+		return nil
+	}
+	if backup.Status.VerifiedBackupName != latest.Name {
+		backup.Status.VerifiedBackupName = latest.Name
+		backup.Status.VerificationState = ""
+	}
+
+	var current *batchv1.Job
+	for _, job := range jobs {
+		if job.GetLabels()[LableVerifyJob] != "true" || !metav1.IsControlledBy(job, backup) {
+			continue
+		}
+		if job.GetAnnotations()[AnnotationVerifyBackup] == latest.Name {
+			current = job
+			continue
+		}
+		// the verification of the older backup is out of date.
+		if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+	if current != nil {
+		switch {
+		case jobCompleted(current):
+			backup.Status.VerificationState = v1beta1.BackupVerified
+		case jobFailed(current):
+			backup.Status.VerificationState = v1beta1.BackupVerificationFailed
+		default:
+			backup.Status.VerificationState = v1beta1.BackupVerifying
+		}
+		return nil
+	}
+	// the verify job has finished and been deleted by others.
+	if backup.Status.VerificationState != "" && backup.Status.VerificationState != v1beta1.BackupVerifying {
+		return nil
+	}
+
+	log.Info("verify the backup", "backup", latest.Name)
+	verifyJob := &batchv1.Job{}
+	verifyJob.ObjectMeta = VerifyJobMeta(cluster)
+	labels := VerifyJobLabels(cluster.Name)
+	verifyJob.ObjectMeta.Labels = labels
+	verifyJob.ObjectMeta.Annotations = map[string]string{
+		AnnotationVerifyBackup: latest.Name,
+	}
+	// the backup is decrypted with the key in the backup secret of the cluster, which is
+	// only kept in v1alpha1.
+	var backupSecretName string
+	if backup.Spec.BackupOpts.Encrypt {
+		alphaCluster := &v1alpha1.MysqlCluster{}
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(cluster), alphaCluster); err != nil {
+			return errors.WithStack(err)
+		}
+		backupSecretName = alphaCluster.Spec.BackupSecretName
+	}
+	spec, err := generateVerifyJobSpec(backup, cluster, labels, latest.Name, backupSecretName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	verifyJob.Spec = *spec
+
+	verifyJob.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("Job"))
+	if err := controllerutil.SetControllerReference(backup, verifyJob,
+		r.Client.Scheme()); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := r.Client.Create(ctx, verifyJob); err != nil {
+		return errors.WithStack(err)
+	}
+	backup.Status.VerificationState = v1beta1.BackupVerifying
+	return nil
+}
+
+// getLatestBackup returns the latest completed backup.
+func getLatestBackup(backups []v1beta1.BackupRecord) *v1beta1.BackupRecord {
+	var latest *v1beta1.BackupRecord
+	for i := range backups {
+		b := &backups[i]
+		if b.CompletionTime == nil {
+			continue
+		}
+		if latest == nil || latest.CompletionTime.Before(b.CompletionTime) {
+			latest = b
+		}
+	}
+	return latest
+}
+
+// generateVerifyJobSpec generates the verify job, the restore init container runs the sidecar
+// image to restore the backup as the init-sidecar does, then the verify container runs mysqld
+// of the cluster image on the restored data. The encrypted backup is decrypted with the
+// encrypt-key in backupSecretName.
+func generateVerifyJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster,
+	labels map[string]string, backupName, backupSecretName string) (*batchv1.JobSpec, error) {
+	jobSpec, err := generateBackupJobSpec(backup, cluster, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	configMapName := cluster.Spec.MySQLConfig.ConfigMapName
+	if len(configMapName) == 0 {
+		configMapName = fmt.Sprintf("%s-mysql", cluster.GetName())
+	}
+	dataMount := corev1.VolumeMount{
+		Name:      utils.DataVolumeName,
+		MountPath: utils.DataVolumeMountPath,
+	}
+	confMount := corev1.VolumeMount{
+		Name:      utils.MysqlConfVolumeName,
+		MountPath: utils.MysqlConfVolumeMountPath,
+	}
+
+	restore := jobSpec.Template.Spec.Containers[0]
+	restore.Name = "restore"
+	restore.Args = []string{"verify_restore", backupName}
+	restore.VolumeMounts = append(restore.VolumeMounts, dataMount, confMount, corev1.VolumeMount{
+		Name:      utils.MysqlCMVolumeName,
+		MountPath: utils.MysqlCMVolumeMountPath,
+	})
+	if len(backupSecretName) != 0 {
+		restore.Env = append(restore.Env,
+			getEnvVarFromSecret(backupSecretName, "BACKUP_ENCRYPT_KEY", "encrypt-key", false))
+	}
+
+	verify := corev1.Container{
+		Name:            "verify",
+		Image:           cluster.Spec.Image,
+		ImagePullPolicy: cluster.Spec.ImagePullPolicy,
+		Command:         []string{"bash", "-c", verifyScript, "verify"},
+		Args:            backup.Spec.Verification.Queries,
+		VolumeMounts: []corev1.VolumeMount{dataMount, confMount, {
+			Name:      utils.LogsVolumeName,
+			MountPath: utils.LogsVolumeMountPath,
+		}},
+	}
+
+	jobSpec.Template.Spec.InitContainers = []corev1.Container{restore}
+	jobSpec.Template.Spec.Containers = []corev1.Container{verify}
+	emptyDir := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	jobSpec.Template.Spec.Volumes = append(jobSpec.Template.Spec.Volumes,
+		corev1.Volume{Name: utils.DataVolumeName, VolumeSource: emptyDir},
+		corev1.Volume{Name: utils.MysqlConfVolumeName, VolumeSource: emptyDir},
+		corev1.Volume{Name: utils.LogsVolumeName, VolumeSource: emptyDir},
+		corev1.Volume{
+			Name: utils.MysqlCMVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: configMapName,
+					},
+				},
+			},
+		},
+	)
+	// the failed verification is not retried, the result is recorded in the backup status.
+	var backoffLimit int32 = 0
+	jobSpec.BackoffLimit = &backoffLimit
+	return jobSpec, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+// runVerifyScript runs the verify script with the fake mysql commands, mysqlcheck exits
+// with checkExit, and mysql fails the queries containing "fail".
+func runVerifyScript(t *testing.T, checkExit string, queries ...string) (string, error) {
+	bin := t.TempDir()
+	fakes := map[string]string{
+		"mysqld":     "exit 0",
+		"mysql":      `case "$*" in *fail*) exit 1;; esac`,
+		"mysqlcheck": "echo 'db.t1 OK'; exit " + checkExit,
+	}
+	for name, script := range fakes {
+		assert.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/bash\n"+script+"\n"), 0755))
+	}
+	cmd := exec.Command("bash", append([]string{"-c", verifyScript, "verify"}, queries...)...)
+	cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestVerifyScript(t *testing.T) {
+	out, err := runVerifyScript(t, "0", "SELECT 1")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "the backup is verified.")
+
+	// mysqlcheck is piped to tee, its failure is not hidden.
+	out, err = runVerifyScript(t, "2")
+	assert.Error(t, err)
+	assert.NotContains(t, out, "the backup is verified.")
+
+	out, err = runVerifyScript(t, "0", "SELECT 1", "SELECT fail")
+	assert.Error(t, err)
+	assert.NotContains(t, out, "the backup is verified.")
+}
+
+func TestReconcileVerificationFailed(t *testing.T) {
+	completionTime := metav1.NewTime(time.Now())
+	backup := &v1beta1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-backup", UID: "backup-uid"},
+		Spec:       v1beta1.BackupSpec{Verification: &v1beta1.BackupVerification{}},
+		Status: v1beta1.BackupStatus{
+			Backups: []v1beta1.BackupRecord{{Name: "backup-1", CompletionTime: &completionTime}},
+		},
+	}
+	isController := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "sample-verify-abcd",
+			Labels:      VerifyJobLabels("sample"),
+			Annotations: map[string]string{AnnotationVerifyBackup: "backup-1"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Name: "sample-backup", UID: "backup-uid", Controller: &isController},
+			},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+		},
+	}
+	r := &BackupReconciler{}
+	assert.NoError(t, r.reconcileVerification(context.TODO(), backup, []*batchv1.Job{job}, &v1beta1.MysqlCluster{}))
+	assert.Equal(t, v1beta1.BackupVerificationFailed, backup.Status.VerificationState)
+	assert.Equal(t, "backup-1", backup.Status.VerifiedBackupName)
+
+	job.Status.Conditions[0].Type = batchv1.JobComplete
+	assert.NoError(t, r.reconcileVerification(context.TODO(), backup, []*batchv1.Job{job}, &v1beta1.MysqlCluster{}))
+	assert.Equal(t, v1beta1.BackupVerified, backup.Status.VerificationState)
+}
+
+func TestGenerateVerifyJobSpecEncrypted(t *testing.T) {
+	backup := &v1beta1.Backup{
+		Spec: v1beta1.BackupSpec{
+			ClusterName:  "sample",
+			BackupOpts:   v1beta1.BackupOps{S3: &v1beta1.S3{BackupSecretName: "s3-secret"}, Encrypt: true},
+			Verification: &v1beta1.BackupVerification{},
+		},
+	}
+	cluster := &v1beta1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample"}}
+	spec, err := generateVerifyJobSpec(backup, cluster, VerifyJobLabels("sample"), "backup-1", "backup-secret")
+	assert.NoError(t, err)
+	restore := spec.Template.Spec.InitContainers[0]
+	assert.Contains(t, restore.Env, getEnvVarFromSecret("backup-secret", "BACKUP_ENCRYPT_KEY", "encrypt-key", false))
+	// The backup job only asks the server to encrypt, the key is not passed to it.
+	backupSpec, err := generateBackupJobSpec(backup, cluster, nil)
+	assert.NoError(t, err)
+	for _, env := range backupSpec.Template.Spec.Containers[0].Env {
+		assert.NotEqual(t, "BACKUP_ENCRYPT_KEY", env.Name)
+	}
+	assert.Contains(t, backupSpec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "BACKUP_ENCRYPT", Value: "true"})
+}
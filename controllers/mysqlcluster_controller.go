@@ -61,6 +61,7 @@ type MysqlClusterReconciler struct {
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps;secrets;services;pods;pods/exec;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;create;patch
+// +kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
@@ -125,10 +126,20 @@ func (r *MysqlClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	// Todo: modify mysql cm will trigger rolling update but it will not be applied.
-	cmRev := mysqlCMSyncer.Object().(*corev1.ConfigMap).ResourceVersion
+	// Only the static configs trigger rolling update, the dynamic configs are applied online
+	// by the status syncer.
+	cmRev := mysqlCMSyncer.GetConfigRev()
 	sctRev := secretSyncer.Object().(*corev1.Secret).ResourceVersion
 
+	tlsRev := ""
+	if instance.Spec.AutoTLS && len(instance.Spec.TlsSecretName) == 0 {
+		tlsSecretSyncer := clustersyncer.NewTLSSecretSyncer(r.Client, instance)
+		if err = syncer.Sync(ctx, tlsSecretSyncer, r.Recorder); err != nil {
+			return ctrl.Result{}, err
+		}
+		tlsRev = tlsSecretSyncer.Object().(*corev1.Secret).ResourceVersion
+	}
+
 	r.XenonExecutor.SetRootPassword(instance.Spec.MysqlOpts.RootPassword)
 
 	// run the syncers for services, pdb and statefulset
@@ -152,14 +163,14 @@ func (r *MysqlClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		// Delete follower service
 		r.deleteFollowerService(ctx, req, instance.Unwrap())
 		syncers = append(syncers,
-			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, r.SQLRunnerFactory, r.XenonExecutor),
+			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, tlsRev, r.SQLRunnerFactory, r.XenonExecutor),
 			clustersyncer.NewPDBSyncer(r.Client, instance),
 			clustersyncer.NewXenonCMSyncer(r.Client, instance),
 		)
 	} else {
 		syncers = append(syncers,
 			clustersyncer.NewFollowerSVCSyncer(r.Client, instance),
-			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, r.SQLRunnerFactory, r.XenonExecutor),
+			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, tlsRev, r.SQLRunnerFactory, r.XenonExecutor),
 			clustersyncer.NewPDBSyncer(r.Client, instance),
 			clustersyncer.NewXenonCMSyncer(r.Client, instance),
 		)
@@ -0,0 +1,252 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/presslabs/controller-util/pkg/meta"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqldatabase"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlDatabaseReconciler reconciles a MysqlDatabase object.
+type MysqlDatabaseReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+}
+
+var (
+	databaseLog       = log.Log.WithName("controller").WithName("mysqldatabase")
+	databaseFinalizer = "mysqldatabase-finalizer"
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases/finalizers,verbs=update
+
+// Reconcile creates the database in the mysql cluster and reports its state.
+func (r *MysqlDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	database := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+
+	err := r.Get(ctx, req.NamespacedName, database.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			databaseLog.Info("mysql database not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	oldStatus := database.Status.DeepCopy()
+
+	// If mysql database has been deleted then drop it from mysql cluster if required.
+	if !database.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.removeDatabase(ctx, database)
+	}
+
+	rdErr := r.reconcileDatabaseInCluster(ctx, database)
+	if !reflect.DeepEqual(oldStatus, &database.Status) {
+		databaseLog.Info("update mysql database status", "key", database.GetKey(), "diff", deep.Equal(oldStatus, &database.Status))
+		if err := r.Status().Update(ctx, database.Unwrap()); err != nil {
+			if rdErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rdErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rdErr != nil {
+		return ctrl.Result{}, rdErr
+	}
+
+	// Enqueue the resource again after to keep the status up to date, and to recreate
+	// the database in case it is dropped directly into mysql.
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: 2 * time.Minute,
+	}, nil
+}
+
+// removeDatabase drops the corresponding database in mysql before mysql database cr is deleted
+// if the deletion policy is Drop and the database was created by the operator, the database is
+// retained otherwise.
+func (r *MysqlDatabaseReconciler) removeDatabase(ctx context.Context, mysqlDatabase *mysqldatabase.MysqlDatabase) error {
+	if meta.HasFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer) {
+		switch {
+		case mysqlDatabase.Spec.DeletionPolicy != apiv1alpha1.DatabaseDeletionDrop:
+			databaseLog.Info("retaining database in mysql cluster", "key", mysqlDatabase.GetKey(), "database", mysqlDatabase.Spec.Database)
+		case !mysqlDatabase.Status.Created:
+			databaseLog.Info("retaining database not created by the operator", "key", mysqlDatabase.GetKey(), "database", mysqlDatabase.Spec.Database)
+		default:
+			if err := r.dropDatabaseFromDB(mysqlDatabase); err != nil {
+				return err
+			}
+		}
+
+		meta.RemoveFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer)
+
+		// Update resource so it will remove the finalizer.
+		if err := r.Update(ctx, mysqlDatabase.Unwrap()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MysqlDatabaseReconciler) reconcileDatabaseInCluster(ctx context.Context, mysqlDatabase *mysqldatabase.MysqlDatabase) (err error) {
+	// Catch the error and set the failed status.
+	defer func() {
+		if err != nil {
+			mysqlDatabase.UpdateStatusCondition(
+				apiv1alpha1.MySQLDatabaseReady, corev1.ConditionFalse,
+				mysqldatabase.ProvisionFailedReason, fmt.Sprintf("The database provisioning has failed: %s", err),
+			)
+		}
+	}()
+
+	// Add finalizer if is not added on the resource, before the database is created,
+	// so that the created database is always dropped by the finalizer.
+	if !meta.HasFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer) {
+		meta.AddFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer)
+		if err = r.Update(ctx, mysqlDatabase.Unwrap()); err != nil {
+			return
+		}
+	}
+
+	if err = r.reconcileDatabaseInDB(ctx, mysqlDatabase); err != nil {
+		return
+	}
+
+	mysqlDatabase.UpdateStatusCondition(
+		apiv1alpha1.MySQLDatabaseReady, corev1.ConditionTrue,
+		mysqldatabase.ProvisionSucceededReason, "The database provisioning has succeeded.",
+	)
+	return
+}
+
+// reconcileDatabaseInDB creates the database or alters its character set and collation,
+// then reports the state of the database read from information_schema.
+func (r *MysqlDatabaseReconciler) reconcileDatabaseInDB(ctx context.Context, mysqlDatabase *mysqldatabase.MysqlDatabase) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, mysqlDatabase.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	name, charset, collation := mysqlDatabase.Spec.Database, mysqlDatabase.Spec.CharacterSet, mysqlDatabase.Spec.Collation
+	info, err := internal.GetDatabaseInfo(sqlRunner, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !info.Exists:
+		// Record the ownership before the creation, only the database created by the operator
+		// is dropped. The ownership must not be lost if the status update fails after it.
+		if !mysqlDatabase.Status.Created {
+			mysqlDatabase.Status.Created = true
+			if err := r.Status().Update(ctx, mysqlDatabase.Unwrap()); err != nil {
+				mysqlDatabase.Status.Created = false
+				return fmt.Errorf("failed to record the ownership of the database: %s", err)
+			}
+		}
+		databaseLog.Info("creating database in mysql cluster", "key", mysqlDatabase.GetKey(), "database", name)
+		if err := internal.CreateDatabase(sqlRunner, name, charset, collation); err != nil {
+			return err
+		}
+	// The collation is compared only if it is set, otherwise the default collation
+	// of the character set is used.
+	case !strings.EqualFold(info.CharacterSet, charset) ||
+		(collation != "" && !strings.EqualFold(info.Collation, collation)):
+		databaseLog.Info("altering database in mysql cluster", "key", mysqlDatabase.GetKey(), "database", name,
+			"characterSet", charset, "collation", collation)
+		if err := internal.AlterDatabase(sqlRunner, name, charset, collation); err != nil {
+			return err
+		}
+	default:
+		return r.updateDatabaseStatus(mysqlDatabase, info)
+	}
+
+	if info, err = internal.GetDatabaseInfo(sqlRunner, name); err != nil {
+		return err
+	}
+	return r.updateDatabaseStatus(mysqlDatabase, info)
+}
+
+func (r *MysqlDatabaseReconciler) updateDatabaseStatus(mysqlDatabase *mysqldatabase.MysqlDatabase, info *internal.DatabaseInfo) error {
+	now := metav1.Now()
+	mysqlDatabase.Status.Exists = info.Exists
+	mysqlDatabase.Status.CharacterSet = info.CharacterSet
+	mysqlDatabase.Status.Collation = info.Collation
+	mysqlDatabase.Status.SizeBytes = info.SizeBytes
+	mysqlDatabase.Status.LastCheckTime = &now
+
+	if !info.Exists {
+		return fmt.Errorf("database %s does not exist after creation", mysqlDatabase.Spec.Database)
+	}
+	return nil
+}
+
+func (r *MysqlDatabaseReconciler) dropDatabaseFromDB(mysqlDatabase *mysqldatabase.MysqlDatabase) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, mysqlDatabase.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if errors.IsNotFound(err) {
+		// If the mysql cluster does not exists then we can safely assume that
+		// the database is deleted so exist successfully.
+		statusErr, ok := err.(*errors.StatusError)
+		if ok && mysqlcluster.IsClusterKind(statusErr.Status().Details.Kind) {
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	databaseLog.Info("dropping database from mysql cluster", "key", mysqlDatabase.GetKey(), "database", mysqlDatabase.Spec.Database, "cluster", mysqlDatabase.GetClusterKey())
+	return internal.DropDatabase(sqlRunner, mysqlDatabase.Spec.Database)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlDatabase{}).
+		Complete(r)
+}
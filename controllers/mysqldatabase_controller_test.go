@@ -0,0 +1,213 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/presslabs/controller-util/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqldatabase"
+)
+
+const (
+	schemataQuery = "SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME " +
+		"FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = app;"
+	sizeQuery = "SELECT COALESCE(SUM(DATA_LENGTH + INDEX_LENGTH), 0) " +
+		"FROM information_schema.TABLES WHERE TABLE_SCHEMA = app;"
+)
+
+// dropStmts returns the DROP DATABASE statements run on the server.
+func dropStmts(server *sqltest.Server) []string {
+	stmts := []string{}
+	for _, stmt := range server.Stmts() {
+		if strings.HasPrefix(stmt, "DROP DATABASE") {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func TestRemoveDatabase(t *testing.T) {
+	ctx := context.TODO()
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	database := &apiv1alpha1.MysqlDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.DatabaseSpec{
+			Database:       "app",
+			DatabaseOwner:  apiv1alpha1.UserOwner{ClusterName: "sample"},
+			CharacterSet:   "utf8mb4",
+			DeletionPolicy: apiv1alpha1.DatabaseDeletionDrop,
+		},
+	}
+	exists := &sqltest.Result{
+		Columns: []string{"DEFAULT_CHARACTER_SET_NAME", "DEFAULT_COLLATION_NAME"},
+		Rows:    [][]driver.Value{{"utf8mb4", "utf8mb4_general_ci"}},
+	}
+
+	tests := []struct {
+		name    string
+		existed bool
+		dropped bool
+	}{
+		{name: "created by the operator", existed: false, dropped: true},
+		{name: "adopted", existed: true, dropped: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &sqltest.Server{
+				Results: map[string]*sqltest.Result{
+					sizeQuery: {Columns: []string{"size"}, Rows: [][]driver.Value{{int64(16384)}}},
+				},
+				OnExec: func(s *sqltest.Server, stmt string) {
+					if strings.HasPrefix(stmt, "CREATE DATABASE") {
+						s.SetResult(schemataQuery, exists)
+					}
+				},
+			}
+			if tt.existed {
+				server.SetResult(schemataQuery, exists)
+			}
+			r := &MysqlDatabaseReconciler{
+				Client:           fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret, database.DeepCopy()).Build(),
+				SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+			}
+			mysqlDatabase := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+			assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(database), mysqlDatabase.Unwrap()))
+
+			assert.NoError(t, r.reconcileDatabaseInCluster(ctx, mysqlDatabase))
+			assert.True(t, mysqlDatabase.Status.Exists)
+			assert.Equal(t, !tt.existed, mysqlDatabase.Status.Created)
+			assert.True(t, meta.HasFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer))
+
+			// The Drop policy drops only the database created by the operator.
+			assert.NoError(t, r.removeDatabase(ctx, mysqlDatabase))
+			if tt.dropped {
+				assert.Equal(t, []string{"DROP DATABASE IF EXISTS `app`;"}, dropStmts(server))
+			} else {
+				assert.Empty(t, dropStmts(server))
+			}
+			assert.False(t, meta.HasFinalizer(&mysqlDatabase.ObjectMeta, databaseFinalizer))
+		})
+	}
+}
+
+// failingStatusClient fails to update the status of the objects.
+type failingStatusClient struct {
+	client.Client
+}
+
+func (c *failingStatusClient) Status() client.StatusWriter {
+	return &failingStatusWriter{c.Client.Status()}
+}
+
+type failingStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w *failingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return errors.New("status update failed")
+}
+
+func TestCreateDatabaseOwnership(t *testing.T) {
+	ctx := context.TODO()
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	database := &apiv1alpha1.MysqlDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.DatabaseSpec{
+			Database:       "app",
+			DatabaseOwner:  apiv1alpha1.UserOwner{ClusterName: "sample"},
+			CharacterSet:   "utf8mb4",
+			DeletionPolicy: apiv1alpha1.DatabaseDeletionDrop,
+		},
+	}
+	createStmts := func(server *sqltest.Server) []string {
+		stmts := []string{}
+		for _, stmt := range server.Stmts() {
+			if strings.HasPrefix(stmt, "CREATE DATABASE") {
+				stmts = append(stmts, stmt)
+			}
+		}
+		return stmts
+	}
+
+	// The database is not created if the ownership can not be recorded.
+	{
+		server := &sqltest.Server{}
+		cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret, database.DeepCopy()).Build()
+		r := &MysqlDatabaseReconciler{
+			Client:           &failingStatusClient{cli},
+			SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+		}
+		mysqlDatabase := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(database), mysqlDatabase.Unwrap()))
+		assert.Error(t, r.reconcileDatabaseInCluster(ctx, mysqlDatabase))
+		assert.Empty(t, createStmts(server))
+		assert.False(t, mysqlDatabase.Status.Created)
+	}
+	// The ownership and the finalizer are kept if the reconcile fails after the creation.
+	{
+		server := &sqltest.Server{
+			OnExec: func(s *sqltest.Server, stmt string) {
+				if strings.HasPrefix(stmt, "CREATE DATABASE") {
+					s.Errs = map[string]error{schemataQuery: errors.New("connection lost")}
+				}
+			},
+		}
+		r := &MysqlDatabaseReconciler{
+			Client:           fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret, database.DeepCopy()).Build(),
+			SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+		}
+		mysqlDatabase := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(database), mysqlDatabase.Unwrap()))
+		assert.Error(t, r.reconcileDatabaseInCluster(ctx, mysqlDatabase))
+		assert.Len(t, createStmts(server), 1)
+
+		stored := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(database), stored.Unwrap()))
+		assert.True(t, stored.Status.Created)
+		assert.True(t, meta.HasFinalizer(&stored.ObjectMeta, databaseFinalizer))
+
+		// The finalizer drops the created database.
+		assert.NoError(t, r.removeDatabase(ctx, stored))
+		assert.Equal(t, []string{"DROP DATABASE IF EXISTS `app`;"}, dropStmts(server))
+	}
+}
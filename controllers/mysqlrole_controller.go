@@ -0,0 +1,231 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/presslabs/controller-util/pkg/meta"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrole"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlRoleReconciler reconciles a MysqlRole object.
+type MysqlRoleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+}
+
+var (
+	roleLog       = log.Log.WithName("controller").WithName("mysqlrole")
+	roleFinalizer = "mysqlrole-finalizer"
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles/finalizers,verbs=update
+
+// Reconcile creates the role in the mysql cluster and grants the privileges to it.
+func (r *MysqlRoleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	role := mysqlrole.New(&apiv1alpha1.MysqlRole{})
+
+	err := r.Get(ctx, req.NamespacedName, role.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			roleLog.Info("mysql role not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	oldStatus := role.Status.DeepCopy()
+
+	// If mysql role has been deleted then delete it from mysql cluster.
+	if !role.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.removeRole(ctx, role)
+	}
+
+	rrErr := r.reconcileRoleInCluster(ctx, role)
+	if !reflect.DeepEqual(oldStatus, &role.Status) {
+		roleLog.Info("update mysql role status", "key", role.GetKey(), "diff", deep.Equal(oldStatus, &role.Status))
+		if err := r.Status().Update(ctx, role.Unwrap()); err != nil {
+			if rrErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rrErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rrErr != nil {
+		return ctrl.Result{}, rrErr
+	}
+
+	// Enqueue the resource again after to keep the resource up to date in mysql
+	// in case is changed directly into mysql.
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: 2 * time.Minute,
+	}, nil
+}
+
+// removeRole drops the corresponding role in mysql before mysql role cr is deleted.
+func (r *MysqlRoleReconciler) removeRole(ctx context.Context, mysqlRole *mysqlrole.MysqlRole) error {
+	if meta.HasFinalizer(&mysqlRole.ObjectMeta, roleFinalizer) {
+		if err := r.dropRoleFromDB(ctx, mysqlRole); err != nil {
+			return err
+		}
+
+		meta.RemoveFinalizer(&mysqlRole.ObjectMeta, roleFinalizer)
+
+		// Update resource so it will remove the finalizer.
+		if err := r.Update(ctx, mysqlRole.Unwrap()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileRoleInCluster creates the role and grants the privileges to it on MySQL 8.0.
+// MySQL 5.7 does not support roles, the privileges are granted to the users directly
+// by the MysqlUserReconciler.
+func (r *MysqlRoleReconciler) reconcileRoleInCluster(ctx context.Context, mysqlRole *mysqlrole.MysqlRole) (err error) {
+	// Catch the error and set the failed status.
+	defer func() {
+		if err != nil {
+			mysqlRole.UpdateStatusCondition(
+				apiv1alpha1.MySQLRoleReady, corev1.ConditionFalse,
+				mysqlrole.ProvisionFailedReason, fmt.Sprintf("The role provisioning has failed: %s", err),
+			)
+		}
+	}()
+
+	cluster := &apiv1alpha1.MysqlCluster{}
+	if err = r.Get(ctx, mysqlRole.GetClusterKey(), cluster); err != nil {
+		return
+	}
+	if apiv1alpha1.GetMysqlMajorVersion(cluster.Spec.MysqlVersion, cluster.Spec.MysqlOpts.Image) != "8.0" {
+		mysqlRole.Status.GrantDrifts = nil
+		mysqlRole.UpdateStatusCondition(
+			apiv1alpha1.MySQLRoleReady, corev1.ConditionTrue,
+			mysqlrole.ExpandedReason, "The role is not supported by MySQL 5.7, the privileges are granted to the users directly.",
+		)
+		return
+	}
+
+	if err = r.reconcileRoleInDB(mysqlRole); err != nil {
+		return
+	}
+
+	// Add finalizer if is not added on the resource.
+	if !meta.HasFinalizer(&mysqlRole.ObjectMeta, roleFinalizer) {
+		meta.AddFinalizer(&mysqlRole.ObjectMeta, roleFinalizer)
+		if err = r.Update(ctx, mysqlRole.Unwrap()); err != nil {
+			return
+		}
+	}
+
+	mysqlRole.UpdateStatusCondition(
+		apiv1alpha1.MySQLRoleReady, corev1.ConditionTrue,
+		mysqlrole.ProvisionSucceededReason, "The role provisioning has succeeded.",
+	)
+	return
+}
+
+// reconcileRoleInDB creates the role, then revokes the privileges not in the spec and grants
+// the missing ones.
+func (r *MysqlRoleReconciler) reconcileRoleInDB(mysqlRole *mysqlrole.MysqlRole) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, mysqlRole.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	if err := internal.CreateRole(sqlRunner, mysqlRole.Spec.Role); err != nil {
+		return err
+	}
+
+	stmts, err := internal.ShowGrants(sqlRunner, mysqlRole.Spec.Role, internal.RoleHost)
+	if err != nil {
+		return err
+	}
+	drifts := internal.DiffGrants(internal.RoleHost,
+		internal.GetDesiredGrants(mysqlRole.Spec.Permissions, false), internal.ParseGrants(stmts))
+	if len(drifts) == 0 {
+		mysqlRole.Status.GrantDrifts = nil
+		return nil
+	}
+
+	roleLog.Info("granting privileges to mysql role", "key", mysqlRole.GetKey(), "role", mysqlRole.Spec.Role, "drifts", drifts)
+	if err := sqlRunner.QueryExec(internal.BuildGrantDriftsQuery(mysqlRole.Spec.Role, drifts)); err != nil {
+		return fmt.Errorf("failed to correct the grants, err: %s", err)
+	}
+	// The privileges of a new role are not drifts.
+	if _, ok := mysqlRole.ConditionExists(apiv1alpha1.MySQLRoleReady); ok {
+		now := metav1.Now()
+		mysqlRole.Status.GrantDrifts = drifts
+		mysqlRole.Status.LastDriftTime = &now
+	}
+	return nil
+}
+
+func (r *MysqlRoleReconciler) dropRoleFromDB(ctx context.Context, mysqlRole *mysqlrole.MysqlRole) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, mysqlRole.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if errors.IsNotFound(err) {
+		// If the mysql cluster does not exists then we can safely assume that
+		// the role is deleted so exist successfully.
+		statusErr, ok := err.(*errors.StatusError)
+		if ok && mysqlcluster.IsClusterKind(statusErr.Status().Details.Kind) {
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	roleLog.Info("removing role from mysql cluster", "key", mysqlRole.GetKey(), "role", mysqlRole.Spec.Role, "cluster", mysqlRole.GetClusterKey())
+	return internal.DropRole(sqlRunner, mysqlRole.Spec.Role)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlRole{}).
+		Complete(r)
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-test/deep"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlschemamigration"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlSchemaMigrationReconciler reconciles a MysqlSchemaMigration object.
+type MysqlSchemaMigrationReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+}
+
+var migrationLog = log.Log.WithName("controller").WithName("mysqlschemamigration")
+
+const (
+	// migrationCheckInterval is the interval to check the new migrations in the ConfigMap.
+	migrationCheckInterval = 2 * time.Minute
+	// onlineSchemaChangeCheckInterval is the interval to check the online schema change job.
+	onlineSchemaChangeCheckInterval = 30 * time.Second
+	// annotationMigrationVersion is the annotation of the job which records the migration version.
+	annotationMigrationVersion = "mysql.radondb.com/migration-version"
+	// onlineSchemaChangeDefaultsFile is the option file which passes the root password to
+	// pt-online-schema-change, so that the password is not exposed in the command line.
+	onlineSchemaChangeDefaultsFile = "/etc/pt-osc/my.cnf"
+	// onlineSchemaChangeScript writes the root password into the option file, then runs
+	// pt-online-schema-change with the arguments of the job.
+	onlineSchemaChangeScript = `umask 077 && printf '[client]\npassword=%s\n' "$MYSQL_ROOT_PASSWORD" > ` +
+		onlineSchemaChangeDefaultsFile + ` && exec pt-online-schema-change "$@"`
+)
+
+// migrationFile is a SQL file in the ConfigMap.
+type migrationFile struct {
+	version  string
+	content  string
+	checksum string
+}
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlschemamigrations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlschemamigrations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlschemamigrations/finalizers,verbs=update
+
+// Reconcile applies the pending migrations in the ConfigMap to the database in order.
+func (r *MysqlSchemaMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	migration := mysqlschemamigration.New(&apiv1alpha1.MysqlSchemaMigration{})
+
+	err := r.Get(ctx, req.NamespacedName, migration.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			migrationLog.Info("mysql schema migration not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	oldStatus := migration.Status.DeepCopy()
+
+	requeueAfter, rmErr := r.reconcileMigrations(ctx, migration)
+	if !reflect.DeepEqual(oldStatus, &migration.Status) {
+		migrationLog.Info("update mysql schema migration status", "key", migration.GetKey(), "diff", deep.Equal(oldStatus, &migration.Status))
+		if err := r.Status().Update(ctx, migration.Unwrap()); err != nil {
+			if rmErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rmErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rmErr != nil {
+		return ctrl.Result{}, rmErr
+	}
+
+	// Enqueue the resource again to apply the migrations added to the ConfigMap.
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: requeueAfter,
+	}, nil
+}
+
+// reconcileMigrations applies the pending migrations one by one, it stops at the failed migration
+// until the SQL file is changed. It returns the interval to check the migrations again.
+func (r *MysqlSchemaMigrationReconciler) reconcileMigrations(ctx context.Context,
+	migration *mysqlschemamigration.MysqlSchemaMigration) (requeueAfter time.Duration, err error) {
+	// Catch the error and set the failed status.
+	defer func() {
+		if err != nil {
+			migration.UpdateStatusCondition(
+				apiv1alpha1.MySQLSchemaMigrationReady, corev1.ConditionFalse,
+				mysqlschemamigration.MigrationFailedReason, fmt.Sprintf("The migration has failed: %s", err),
+			)
+		}
+	}()
+
+	cluster := &apiv1alpha1.MysqlCluster{}
+	if err = r.Get(ctx, migration.GetClusterKey(), cluster); err != nil {
+		return
+	}
+	configMap := &corev1.ConfigMap{}
+	if err = r.Get(ctx, client.ObjectKey{Name: migration.Spec.ConfigMapName, Namespace: migration.Namespace}, configMap); err != nil {
+		return
+	}
+	files := getMigrationFiles(configMap)
+
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, migration.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if err != nil {
+		return
+	}
+	defer closeConn()
+
+	// The migrations are tracked by the namespaced name of the resource.
+	database, key := migration.Spec.Database, migration.GetKey().String()
+	if err = internal.EnsureMigrationsTable(sqlRunner, database); err != nil {
+		return
+	}
+	applied, err := internal.GetAppliedMigrations(sqlRunner, database, key)
+	if err != nil {
+		return
+	}
+	migration.Status.AppliedMigrations = applied
+
+	pending, err := getPendingMigrations(files, applied)
+	if err != nil {
+		return
+	}
+	for i, file := range pending {
+		migration.Status.PendingMigrations = int32(len(pending) - i)
+
+		// Stop at the failed migration until it is changed.
+		if failed := migration.Status.FailedMigration; failed != nil &&
+			failed.Version == file.version && failed.Checksum == file.checksum {
+			migration.UpdateStatusCondition(
+				apiv1alpha1.MySQLSchemaMigrationReady, corev1.ConditionFalse,
+				mysqlschemamigration.MigrationFailedReason, fmt.Sprintf("The migration %s has failed: %s", failed.Version, failed.Message),
+			)
+			return migrationCheckInterval, nil
+		}
+
+		var applyErr error
+		if table, alter, ok := internal.ParseOnlineAlter(file.content); ok && migration.Spec.OnlineSchemaChange != nil {
+			var completed bool
+			var failure string
+			completed, failure, err = r.runOnlineSchemaChange(ctx, migration, cluster, file, table, alter)
+			if err != nil {
+				return
+			}
+			if !completed {
+				migration.UpdateStatusCondition(
+					apiv1alpha1.MySQLSchemaMigrationReady, corev1.ConditionFalse,
+					mysqlschemamigration.MigratingReason, fmt.Sprintf("The migration %s is running by the job %s.",
+						file.version, migration.Status.OnlineSchemaChangeJob),
+				)
+				return onlineSchemaChangeCheckInterval, nil
+			}
+			migration.Status.OnlineSchemaChangeJob = ""
+			if failure != "" {
+				applyErr = fmt.Errorf("%s", failure)
+			} else {
+				applyErr = internal.RecordMigration(sqlRunner, database, key, file.version, file.checksum)
+			}
+		} else {
+			migrationLog.Info("applying migration", "key", migration.GetKey(), "database", database, "version", file.version)
+			applyErr = internal.ApplyMigration(sqlRunner, database, key, file.version, file.content)
+		}
+
+		if applyErr != nil {
+			migrationLog.Info("failed to apply migration", "key", migration.GetKey(), "version", file.version, "error", applyErr.Error())
+			migration.Status.FailedMigration = &apiv1alpha1.FailedMigration{
+				Version:    file.version,
+				Checksum:   file.checksum,
+				Message:    applyErr.Error(),
+				FailedTime: metav1.Now(),
+			}
+			migration.UpdateStatusCondition(
+				apiv1alpha1.MySQLSchemaMigrationReady, corev1.ConditionFalse,
+				mysqlschemamigration.MigrationFailedReason, fmt.Sprintf("The migration %s has failed: %s", file.version, applyErr),
+			)
+			return migrationCheckInterval, nil
+		}
+
+		migration.Status.FailedMigration = nil
+		migration.Status.AppliedMigrations = append(migration.Status.AppliedMigrations, apiv1alpha1.AppliedMigration{
+			Version:     file.version,
+			Checksum:    file.checksum,
+			AppliedTime: metav1.Now(),
+		})
+	}
+
+	migration.Status.PendingMigrations = 0
+	migration.Status.FailedMigration = nil
+	migration.UpdateStatusCondition(
+		apiv1alpha1.MySQLSchemaMigrationReady, corev1.ConditionTrue,
+		mysqlschemamigration.MigrationSucceededReason, "All the migrations have been applied.",
+	)
+	return migrationCheckInterval, nil
+}
+
+// runOnlineSchemaChange runs the ALTER TABLE statement by pt-online-schema-change in a job.
+// It returns whether the job has finished, and the failure message of the migration if the job failed.
+func (r *MysqlSchemaMigrationReconciler) runOnlineSchemaChange(ctx context.Context,
+	migration *mysqlschemamigration.MysqlSchemaMigration, cluster *apiv1alpha1.MysqlCluster,
+	file migrationFile, table, alter string) (bool, string, error) {
+	// The job reads the root password from the secret of the cluster.
+	if cluster.Namespace != migration.Namespace {
+		return true, "the online schema change requires the migration in the namespace of the cluster", nil
+	}
+
+	// The job name is limited to 63 characters.
+	prefix := migration.Name
+	if len(prefix) > 48 {
+		prefix = prefix[:48]
+	}
+	name := fmt.Sprintf("%s-osc-%s", prefix, file.checksum[:10])
+	migration.Status.OnlineSchemaChangeJob = name
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: migration.Namespace}, job)
+	if errors.IsNotFound(err) {
+		migrationLog.Info("creating online schema change job", "key", migration.GetKey(), "version", file.version, "job", name)
+		job = newOnlineSchemaChangeJob(name, migration, cluster, file, table, alter)
+		if err := controllerutil.SetControllerReference(migration.Unwrap(), job, r.Scheme); err != nil {
+			return false, "", err
+		}
+		return false, "", r.Create(ctx, job)
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return true, "", nil
+		case batchv1.JobFailed:
+			return true, fmt.Sprintf("the online schema change job %s failed: %s", name, cond.Message), nil
+		}
+	}
+	return false, "", nil
+}
+
+// newOnlineSchemaChangeJob returns the job which runs pt-online-schema-change against the leader,
+// the root password is read from an option file in memory.
+func newOnlineSchemaChangeJob(name string, migration *mysqlschemamigration.MysqlSchemaMigration,
+	cluster *apiv1alpha1.MysqlCluster, file migrationFile, table, alter string) *batchv1.Job {
+	osc := migration.Spec.OnlineSchemaChange
+	dsn := fmt.Sprintf("F=%s,h=%s-leader.%s,P=%d,u=%s,D=%s,t=%s", onlineSchemaChangeDefaultsFile,
+		cluster.Name, cluster.Namespace, utils.MysqlPort, utils.RootUser, migration.Spec.Database, table)
+	// The first argument is $0 of the script.
+	args := append([]string{"pt-online-schema-change", "--alter", alter}, osc.Args...)
+	args = append(args, "--execute", dsn)
+
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: migration.Namespace,
+			Labels: map[string]string{
+				"mysql.radondb.com/cluster":    cluster.Name,
+				"app.kubernetes.io/managed-by": "mysql.radondb.com",
+			},
+			Annotations: map[string]string{
+				annotationMigrationVersion: file.version,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "pt-online-schema-change",
+							Image:   osc.Image,
+							Command: []string{"sh", "-c", onlineSchemaChangeScript},
+							Args:    args,
+							Env: []corev1.EnvVar{
+								{
+									Name: "MYSQL_ROOT_PASSWORD",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: mysqlcluster.New(cluster).GetNameForResource(utils.Secret),
+											},
+											Key: "internal-root-password",
+										},
+									},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "defaults",
+									MountPath: path.Dir(onlineSchemaChangeDefaultsFile),
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "defaults",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// getMigrationFiles returns the SQL files in the ConfigMap in the lexical order of the keys.
+func getMigrationFiles(configMap *corev1.ConfigMap) []migrationFile {
+	var files []migrationFile
+	for key, content := range configMap.Data {
+		if !strings.HasSuffix(key, ".sql") {
+			continue
+		}
+		files = append(files, migrationFile{
+			version:  strings.TrimSuffix(key, ".sql"),
+			content:  content,
+			checksum: internal.MigrationChecksum(content),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].version < files[j].version
+	})
+	return files
+}
+
+// getPendingMigrations returns the SQL files which have not been applied, the applied SQL files
+// should not be changed.
+func getPendingMigrations(files []migrationFile, applied []apiv1alpha1.AppliedMigration) ([]migrationFile, error) {
+	checksums := map[string]string{}
+	for _, migration := range applied {
+		checksums[migration.Version] = migration.Checksum
+	}
+
+	var pending []migrationFile
+	for _, file := range files {
+		checksum, ok := checksums[file.version]
+		if !ok {
+			pending = append(pending, file)
+			continue
+		}
+		if checksum != file.checksum {
+			return nil, fmt.Errorf("the applied migration %s has been changed", file.version)
+		}
+	}
+	return pending, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlSchemaMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlSchemaMigration{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlschemamigration"
+)
+
+func TestNewOnlineSchemaChangeJob(t *testing.T) {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+	}
+	migration := mysqlschemamigration.New(&apiv1alpha1.MysqlSchemaMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "schema", Namespace: "default"},
+		Spec: apiv1alpha1.SchemaMigrationSpec{
+			Database: "app",
+			OnlineSchemaChange: &apiv1alpha1.OnlineSchemaChange{
+				Image: "percona/percona-toolkit:3.5.0",
+				Args:  []string{"--max-load=Threads_running=50"},
+			},
+		},
+	})
+	file := migrationFile{version: "V002", content: "ALTER TABLE users ADD INDEX idx_name (name);"}
+
+	job := newOnlineSchemaChangeJob("schema-osc", migration, cluster, file, "users", "ADD INDEX idx_name (name)")
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"sh", "-c", onlineSchemaChangeScript}, container.Command)
+	assert.Equal(t, []string{
+		"pt-online-schema-change", "--alter", "ADD INDEX idx_name (name)", "--max-load=Threads_running=50",
+		"--execute", "F=/etc/pt-osc/my.cnf,h=sample-leader.default,P=3306,u=root,D=app,t=users",
+	}, container.Args)
+	// The password is passed by the option file, not by the command line.
+	for _, arg := range append(container.Command, container.Args...) {
+		assert.False(t, strings.Contains(arg, "p=$(MYSQL_ROOT_PASSWORD)"), arg)
+	}
+	assert.Equal(t, "/etc/pt-osc", container.VolumeMounts[0].MountPath)
+	assert.Equal(t, "V002", job.Annotations[annotationMigrationVersion])
+}
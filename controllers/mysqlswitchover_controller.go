@@ -0,0 +1,362 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-test/deep"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlswitchover"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlSwitchoverReconciler reconciles a MysqlSwitchover object.
+type MysqlSwitchoverReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+	// XenonExecutor is used to execute Xenon HTTP instructions.
+	internal.XenonExecutor
+	// XenonChecker runs the leader hooks of xenon to drain and restore the old leader.
+	internal.XenonChecker
+}
+
+var switchoverLog = log.Log.WithName("controller").WithName("mysqlswitchover")
+
+// switchoverCheckInterval is the interval to check the progress of the switchover.
+const switchoverCheckInterval = 2 * time.Second
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlswitchovers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlswitchovers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlswitchovers/finalizers,verbs=update
+
+// Reconcile moves the leader of the cluster to the target pod. The old leader is drained by the steps of
+// the xenon leaderStop first, then the target is asked to be the leader after it caught up. The old leader
+// is restored writable by the steps of the xenon leaderStart if the target does not become the leader in time.
+func (r *MysqlSwitchoverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	switchover := mysqlswitchover.New(&apiv1alpha1.MysqlSwitchover{})
+
+	err := r.Get(ctx, req.NamespacedName, switchover.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			switchoverLog.Info("mysql switchover not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if switchover.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	oldStatus := switchover.Status.DeepCopy()
+
+	requeueAfter, rsErr := r.reconcileSwitchover(ctx, switchover)
+	if !reflect.DeepEqual(oldStatus, &switchover.Status) {
+		switchoverLog.Info("update mysql switchover status", "key", switchover.GetKey(), "diff", deep.Equal(oldStatus, &switchover.Status))
+		if err := r.Status().Update(ctx, switchover.Unwrap()); err != nil {
+			if rsErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rsErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rsErr != nil {
+		return ctrl.Result{}, rsErr
+	}
+	if switchover.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: requeueAfter,
+	}, nil
+}
+
+func (r *MysqlSwitchoverReconciler) reconcileSwitchover(ctx context.Context, switchover *mysqlswitchover.MysqlSwitchover) (time.Duration, error) {
+	cluster := &apiv1alpha1.MysqlCluster{}
+	if err := r.Get(ctx, switchover.GetClusterKey(), cluster); err != nil {
+		if errors.IsNotFound(err) {
+			r.fail(switchover, fmt.Sprintf("the cluster %s is not found", switchover.Spec.ClusterName))
+			return 0, nil
+		}
+		return 0, err
+	}
+	r.XenonExecutor.SetRootPassword(cluster.Spec.MysqlOpts.RootPassword)
+
+	switch switchover.Status.Phase {
+	case apiv1alpha1.SwitchoverDraining:
+		return r.waitForCatchUp(ctx, switchover, cluster)
+	case apiv1alpha1.SwitchoverPromoting:
+		return r.waitForLeader(ctx, switchover, cluster)
+	default:
+		return r.startSwitchover(ctx, switchover, cluster)
+	}
+}
+
+// startSwitchover checks that the target is a healthy follower without errant transactions,
+// then starts draining the leader.
+func (r *MysqlSwitchoverReconciler) startSwitchover(ctx context.Context, switchover *mysqlswitchover.MysqlSwitchover,
+	cluster *apiv1alpha1.MysqlCluster) (time.Duration, error) {
+	// Only one switchover of the cluster runs at the same time.
+	list := &apiv1alpha1.MysqlSwitchoverList{}
+	if err := r.List(ctx, list, client.InNamespace(switchover.Namespace)); err != nil {
+		return 0, err
+	}
+	for i := range list.Items {
+		other := mysqlswitchover.New(&list.Items[i])
+		if other.Name != switchover.Name && other.Spec.ClusterName == switchover.Spec.ClusterName &&
+			other.Status.Phase != "" && !other.IsFinished() {
+			switchover.Status.Message = fmt.Sprintf("waiting for the switchover %s to finish", other.Name)
+			return switchoverCheckInterval, nil
+		}
+	}
+
+	leader := getLeaderNode(cluster)
+	if leader == nil {
+		r.fail(switchover, "the cluster has no leader")
+		return 0, nil
+	}
+	target := getNode(cluster, getPodHost(cluster, switchover.Spec.TargetPod))
+	if target == nil {
+		r.fail(switchover, fmt.Sprintf("the pod %s is not a node of the cluster", switchover.Spec.TargetPod))
+		return 0, nil
+	}
+	if target.Name == leader.Name {
+		switchover.Status.FromLeader = switchover.Spec.TargetPod
+		switchover.SetPhase(apiv1alpha1.SwitchoverSucceeded, "the target is already the leader")
+		r.Recorder.Event(switchover.Unwrap(), corev1.EventTypeNormal, "SwitchoverSkipped", switchover.Status.Message)
+		return 0, nil
+	}
+	if !isNodeConditionTrue(target, apiv1alpha1.IndexReplicating) || isNodeConditionTrue(target, apiv1alpha1.IndexLagged) {
+		r.fail(switchover, fmt.Sprintf("the target %s is not replicating or lagged", switchover.Spec.TargetPod))
+		return 0, nil
+	}
+
+	leaderGtid, targetGtid, err := r.getGtidSets(switchover, leader.Name, target.Name)
+	if err != nil {
+		return 0, err
+	}
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, switchover.GetClusterKey(), utils.RootUser, target.Name))
+	if err != nil {
+		return 0, err
+	}
+	defer closeConn()
+	noErrant, err := internal.IsGtidSubset(sqlRunner, targetGtid, leaderGtid)
+	if err != nil {
+		return 0, err
+	}
+	if !noErrant {
+		r.fail(switchover, fmt.Sprintf("the target %s has errant transactions", switchover.Spec.TargetPod))
+		return 0, nil
+	}
+
+	now := metav1.Now()
+	switchover.Status.FromLeader = getPodName(leader.Name)
+	switchover.Status.StartTime = &now
+	switchoverLog.Info("start switchover", "key", switchover.GetKey(), "from", switchover.Status.FromLeader, "to", switchover.Spec.TargetPod)
+	r.Recorder.Eventf(switchover.Unwrap(), corev1.EventTypeNormal, "SwitchoverStarted",
+		"Switching the leader from %s to %s", switchover.Status.FromLeader, switchover.Spec.TargetPod)
+
+	// The old leader is drained once: the event scheduler is stopped, super_read_only is set and
+	// the connections are killed.
+	if err := r.XenonChecker.RunXenonChecker(cluster.Namespace, switchover.Status.FromLeader, "drain"); err != nil {
+		return 0, r.rollback(switchover, cluster, fmt.Sprintf("failed to drain the leader: %s", err))
+	}
+	switchover.SetPhase(apiv1alpha1.SwitchoverDraining, fmt.Sprintf("waiting for the target %s to catch up", switchover.Spec.TargetPod))
+	return switchoverCheckInterval, nil
+}
+
+// waitForCatchUp asks the target to be the leader after it executed all the GTIDs of the drained leader.
+func (r *MysqlSwitchoverReconciler) waitForCatchUp(ctx context.Context, switchover *mysqlswitchover.MysqlSwitchover,
+	cluster *apiv1alpha1.MysqlCluster) (time.Duration, error) {
+	if switchover.IsTimedOut(time.Now()) {
+		return 0, r.rollback(switchover, cluster, "the target did not catch up in time")
+	}
+
+	leaderHost := getPodHost(cluster, switchover.Status.FromLeader)
+	targetHost := getPodHost(cluster, switchover.Spec.TargetPod)
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, switchover.GetClusterKey(), utils.RootUser, leaderHost))
+	if err != nil {
+		return 0, err
+	}
+	defer closeConn()
+
+	leaderGtid, targetGtid, err := r.getGtidSets(switchover, leaderHost, targetHost)
+	if err != nil {
+		return 0, err
+	}
+	caughtUp, err := internal.IsGtidSubset(sqlRunner, leaderGtid, targetGtid)
+	if err != nil {
+		return 0, err
+	}
+	if !caughtUp {
+		return switchoverCheckInterval, nil
+	}
+
+	switchoverLog.Info("promoting the target", "key", switchover.GetKey(), "target", switchover.Spec.TargetPod)
+	if err := r.XenonExecutor.RaftTryToLeader(targetHost); err != nil {
+		return 0, r.rollback(switchover, cluster, err.Error())
+	}
+	now := metav1.Now()
+	switchover.Status.PromoteTime = &now
+	switchover.SetPhase(apiv1alpha1.SwitchoverPromoting, fmt.Sprintf("waiting for the target %s to be the leader", switchover.Spec.TargetPod))
+	r.Recorder.Eventf(switchover.Unwrap(), corev1.EventTypeNormal, "SwitchoverPromoting",
+		"The target %s caught up and is trying to be the leader", switchover.Spec.TargetPod)
+	return switchoverCheckInterval, nil
+}
+
+// waitForLeader waits for the role labels of the pods to converge, the target is labeled as the leader
+// and the old leader is not.
+func (r *MysqlSwitchoverReconciler) waitForLeader(ctx context.Context, switchover *mysqlswitchover.MysqlSwitchover,
+	cluster *apiv1alpha1.MysqlCluster) (time.Duration, error) {
+	target, oldLeader := &corev1.Pod{}, &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Name: switchover.Spec.TargetPod, Namespace: cluster.Namespace}, target); err != nil {
+		return 0, err
+	}
+	if err := r.Get(ctx, client.ObjectKey{Name: switchover.Status.FromLeader, Namespace: cluster.Namespace}, oldLeader); err != nil {
+		return 0, err
+	}
+	if target.Labels["role"] == string(utils.Leader) && oldLeader.Labels["role"] != string(utils.Leader) {
+		message := fmt.Sprintf("the leader was switched from %s to %s in %s", switchover.Status.FromLeader,
+			switchover.Spec.TargetPod, time.Since(switchover.Status.StartTime.Time).Round(time.Second))
+		switchover.SetPhase(apiv1alpha1.SwitchoverSucceeded, message)
+		switchoverLog.Info("switchover succeeded", "key", switchover.GetKey(), "message", message)
+		r.Recorder.Event(switchover.Unwrap(), corev1.EventTypeNormal, "SwitchoverSucceeded", message)
+		return 0, nil
+	}
+	if !switchover.IsTimedOut(time.Now()) {
+		return switchoverCheckInterval, nil
+	}
+
+	// The old leader is restored only if it is still the leader, otherwise the raft may have
+	// elected the target or another node.
+	leaderHost := getPodHost(cluster, switchover.Status.FromLeader)
+	raftStatus, err := r.XenonExecutor.RaftStatus(leaderHost)
+	if err != nil {
+		return 0, err
+	}
+	if raftStatus.Role == string(utils.Leader) {
+		return 0, r.rollback(switchover, cluster, "the target did not become the leader in time")
+	}
+	r.fail(switchover, fmt.Sprintf("the role labels did not converge in time, the leader is %s", raftStatus.Leader))
+	return 0, nil
+}
+
+// rollback restores the old leader writable: the max connections are restored, super_read_only is
+// disabled and the event scheduler is started.
+func (r *MysqlSwitchoverReconciler) rollback(switchover *mysqlswitchover.MysqlSwitchover,
+	cluster *apiv1alpha1.MysqlCluster, reason string) error {
+	switchoverLog.Info("rolling back switchover", "key", switchover.GetKey(), "reason", reason)
+	if err := r.XenonChecker.RunXenonChecker(cluster.Namespace, switchover.Status.FromLeader, "undrain"); err != nil {
+		r.fail(switchover, fmt.Sprintf("%s, failed to roll back: %s", reason, err))
+		return nil
+	}
+
+	message := fmt.Sprintf("%s, the leader %s was restored", reason, switchover.Status.FromLeader)
+	switchover.SetPhase(apiv1alpha1.SwitchoverRolledBack, message)
+	r.Recorder.Event(switchover.Unwrap(), corev1.EventTypeWarning, "SwitchoverRolledBack", message)
+	return nil
+}
+
+// fail marks the switchover failed.
+func (r *MysqlSwitchoverReconciler) fail(switchover *mysqlswitchover.MysqlSwitchover, message string) {
+	switchoverLog.Info("switchover failed", "key", switchover.GetKey(), "message", message)
+	switchover.SetPhase(apiv1alpha1.SwitchoverFailed, message)
+	r.Recorder.Event(switchover.Unwrap(), corev1.EventTypeWarning, "SwitchoverFailed", message)
+}
+
+// getGtidSets returns the executed GTID sets of the leader and the target.
+func (r *MysqlSwitchoverReconciler) getGtidSets(switchover *mysqlswitchover.MysqlSwitchover, leaderHost, targetHost string) (string, string, error) {
+	sets := []string{}
+	for _, host := range []string{leaderHost, targetHost} {
+		sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+			r.Client, switchover.GetClusterKey(), utils.RootUser, host))
+		if err != nil {
+			return "", "", err
+		}
+		gtid, err := internal.GetGtidExecuted(sqlRunner)
+		closeConn()
+		if err != nil {
+			return "", "", err
+		}
+		sets = append(sets, gtid)
+	}
+	return sets[0], sets[1], nil
+}
+
+// getPodHost returns the host of the pod in the cluster status.
+func getPodHost(cluster *apiv1alpha1.MysqlCluster, pod string) string {
+	return fmt.Sprintf("%s.%s.%s", pod, mysqlcluster.New(cluster).GetNameForResource(utils.HeadlessSVC), cluster.Namespace)
+}
+
+// getPodName returns the pod name of the host.
+func getPodName(host string) string {
+	return strings.Split(host, ".")[0]
+}
+
+// getNode returns the node of the host in the cluster status.
+func getNode(cluster *apiv1alpha1.MysqlCluster, host string) *apiv1alpha1.NodeStatus {
+	for i := range cluster.Status.Nodes {
+		if cluster.Status.Nodes[i].Name == host {
+			return &cluster.Status.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// getLeaderNode returns the leader node in the cluster status.
+func getLeaderNode(cluster *apiv1alpha1.MysqlCluster) *apiv1alpha1.NodeStatus {
+	for i := range cluster.Status.Nodes {
+		if cluster.Status.Nodes[i].RaftStatus.Role == string(utils.Leader) {
+			return &cluster.Status.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func isNodeConditionTrue(node *apiv1alpha1.NodeStatus, index apiv1alpha1.NodeConditionsIndex) bool {
+	return len(node.Conditions) > int(index) && node.Conditions[index].Status == corev1.ConditionTrue
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlSwitchoverReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlSwitchover{}).
+		Complete(r)
+}
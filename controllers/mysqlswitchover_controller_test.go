@@ -0,0 +1,169 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlswitchover"
+)
+
+// fakeXenon records the requests to xenon.
+type fakeXenon struct {
+	internal.XenonExecutor
+	tryToLeader []string
+}
+
+func (x *fakeXenon) SetRootPassword(rootPassword string) {}
+
+func (x *fakeXenon) RaftTryToLeader(host string) error {
+	x.tryToLeader = append(x.tryToLeader, host)
+	return nil
+}
+
+// fakeXenonChecker records the commands of xenonchecker run in the pods.
+type fakeXenonChecker struct {
+	commands []string
+	errs     map[string]error
+}
+
+func (c *fakeXenonChecker) RunXenonChecker(namespace, podName, command string) error {
+	c.commands = append(c.commands, podName+" "+command)
+	return c.errs[command]
+}
+
+const switchoverTestGtid = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"
+
+func newSwitchoverTest(t *testing.T) (*MysqlSwitchoverReconciler, *sqltest.Server, *fakeXenon, *fakeXenonChecker,
+	*mysqlswitchover.MysqlSwitchover) {
+	follower := make([]apiv1alpha1.NodeCondition, apiv1alpha1.IndexReplicating+1)
+	follower[apiv1alpha1.IndexLagged].Status = corev1.ConditionFalse
+	follower[apiv1alpha1.IndexReplicating].Status = corev1.ConditionTrue
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Status: apiv1alpha1.MysqlClusterStatus{
+			Nodes: []apiv1alpha1.NodeStatus{
+				{Name: "sample-mysql-0.sample-mysql.default", RaftStatus: apiv1alpha1.RaftStatus{Role: "LEADER"}},
+				{Name: "sample-mysql-1.sample-mysql.default", RaftStatus: apiv1alpha1.RaftStatus{Role: "FOLLOWER"}, Conditions: follower},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	switchover := mysqlswitchover.New(&apiv1alpha1.MysqlSwitchover{
+		ObjectMeta: metav1.ObjectMeta{Name: "switchover", Namespace: "default"},
+		Spec: apiv1alpha1.SwitchoverSpec{
+			ClusterName: "sample",
+			TargetPod:   "sample-mysql-1",
+			Timeout:     metav1.Duration{Duration: time.Minute},
+		},
+	})
+
+	server := &sqltest.Server{}
+	server.SetResult("SELECT @@global.gtid_executed;", &sqltest.Result{Columns: []string{"gtid"},
+		Rows: [][]driver.Value{{switchoverTestGtid}}})
+	setGtidSubset(server, 1)
+	xenon, checker := &fakeXenon{}, &fakeXenonChecker{errs: map[string]error{}}
+	r := &MysqlSwitchoverReconciler{
+		Client:           fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret, switchover.Unwrap()).Build(),
+		Recorder:         record.NewFakeRecorder(10),
+		SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+		XenonExecutor:    xenon,
+		XenonChecker:     checker,
+	}
+	return r, server, xenon, checker, switchover
+}
+
+func setGtidSubset(server *sqltest.Server, subset int64) {
+	server.SetResult("SELECT GTID_SUBSET("+switchoverTestGtid+", "+switchoverTestGtid+");",
+		&sqltest.Result{Columns: []string{"subset"}, Rows: [][]driver.Value{{subset}}})
+}
+
+func TestSwitchover(t *testing.T) {
+	ctx := context.TODO()
+	r, _, xenon, checker, switchover := newSwitchoverTest(t)
+
+	// The leader is drained once by xenonchecker.
+	_, err := r.reconcileSwitchover(ctx, switchover)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.SwitchoverDraining, switchover.Status.Phase)
+	assert.Equal(t, "sample-mysql-0", switchover.Status.FromLeader)
+	assert.Equal(t, []string{"sample-mysql-0 drain"}, checker.commands)
+
+	// The target caught up and is asked to be the leader.
+	_, err = r.reconcileSwitchover(ctx, switchover)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.SwitchoverPromoting, switchover.Status.Phase)
+	assert.Equal(t, []string{"sample-mysql-1.sample-mysql.default"}, xenon.tryToLeader)
+	assert.Equal(t, []string{"sample-mysql-0 drain"}, checker.commands)
+}
+
+func TestSwitchoverRollback(t *testing.T) {
+	ctx := context.TODO()
+
+	// The drain failed, the leader is restored.
+	{
+		r, _, _, checker, switchover := newSwitchoverTest(t)
+		checker.errs["drain"] = errors.New("lock wait timeout")
+		_, err := r.reconcileSwitchover(ctx, switchover)
+		assert.NoError(t, err)
+		assert.Equal(t, apiv1alpha1.SwitchoverRolledBack, switchover.Status.Phase)
+		assert.Equal(t, []string{"sample-mysql-0 drain", "sample-mysql-0 undrain"}, checker.commands)
+	}
+	// The target did not catch up in time.
+	{
+		r, server, xenon, checker, switchover := newSwitchoverTest(t)
+		_, err := r.reconcileSwitchover(ctx, switchover)
+		assert.NoError(t, err)
+		setGtidSubset(server, 0)
+		_, err = r.reconcileSwitchover(ctx, switchover)
+		assert.NoError(t, err)
+		assert.Equal(t, apiv1alpha1.SwitchoverDraining, switchover.Status.Phase)
+
+		startTime := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+		switchover.Status.StartTime = &startTime
+		_, err = r.reconcileSwitchover(ctx, switchover)
+		assert.NoError(t, err)
+		assert.Equal(t, apiv1alpha1.SwitchoverRolledBack, switchover.Status.Phase)
+		assert.Empty(t, xenon.tryToLeader)
+		assert.Equal(t, []string{"sample-mysql-0 drain", "sample-mysql-0 undrain"}, checker.commands)
+	}
+	// The rollback failed.
+	{
+		r, _, _, checker, switchover := newSwitchoverTest(t)
+		checker.errs["drain"] = errors.New("lock wait timeout")
+		checker.errs["undrain"] = errors.New("connection refused")
+		_, err := r.reconcileSwitchover(ctx, switchover)
+		assert.NoError(t, err)
+		assert.Equal(t, apiv1alpha1.SwitchoverFailed, switchover.Status.Phase)
+	}
+}
@@ -20,12 +20,16 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-test/deep"
 	"github.com/presslabs/controller-util/pkg/meta"
+	"github.com/presslabs/controller-util/pkg/rand"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -35,6 +39,7 @@ import (
 	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
 	"github.com/radondb/radondb-mysql-kubernetes/internal"
 	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrole"
 	mysqluser "github.com/radondb/radondb-mysql-kubernetes/mysqluser"
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
@@ -54,6 +59,11 @@ var (
 	userFinalizer = "mysqluser-finalizer"
 )
 
+const (
+	// The length of the generated password.
+	passwordLength = 16
+)
+
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers/finalizers,verbs=update
@@ -169,6 +179,20 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 	}
 	defer closeConn()
 
+	cluster := &apiv1alpha1.MysqlCluster{}
+	if err := r.Get(ctx, mysqlUser.GetClusterKey(), cluster); err != nil {
+		return err
+	}
+	version := apiv1alpha1.GetMysqlMajorVersion(cluster.Spec.MysqlVersion, cluster.Spec.MysqlOpts.Image)
+
+	if diff := utils.StringDiffIn(mysqlUser.Spec.DefaultRoles, mysqlUser.Spec.Roles); len(diff) != 0 {
+		return fmt.Errorf("the default roles %v are not in the roles", diff)
+	}
+	permissions, err := r.getPermissions(ctx, mysqlUser, version)
+	if err != nil {
+		return err
+	}
+
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{Name: mysqlUser.Spec.SecretSelector.SecretName, Namespace: mysqlUser.Namespace}
 
@@ -176,6 +200,10 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 		return err
 	}
 
+	if err := r.rotatePassword(ctx, sqlRunner, mysqlUser, secret, version); err != nil {
+		return err
+	}
+
 	password := string(secret.Data[mysqlUser.Spec.SecretSelector.SecretKey])
 	if password == "" {
 		return fmt.Errorf("the MySQL user's password must not be empty")
@@ -188,23 +216,334 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 		}
 	}
 	// build user management  sql and calculate hash.
-	SQL, err := internal.BuildUserManagementSQL(mysqlUser.Unwrap(), password)
+	user := mysqlUser.Unwrap().DeepCopy()
+	user.Spec.Permissions = permissions
+	serverVersion, err := internal.GetServerVersion(sqlRunner)
+	if err != nil {
+		return err
+	}
+	SQL, err := internal.BuildUserManagementSQL(user, password, serverVersion)
 	if err != nil {
 		return err
 	}
 	argsToString := fmt.Sprintf("%v", SQL.Args())
 	SQLhash, err := utils.Hash(SQL.String() + argsToString)
-	if err == nil && SQLhash == mysqlUser.Status.Revision {
-		// If the user has not been changed, then skip the following steps.
+	// If the user has not been changed, then skip creating/updating the user.
+	if err != nil || SQLhash != mysqlUser.Status.Revision {
+		// Create/Update user in database.
+		userLog.Info("creating mysql user", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User, "cluster", mysqlUser.GetClusterKey())
+		if err := sqlRunner.QueryExec(SQL); err != nil {
+			return err
+		}
+
+		mysqlUser.Status.Revision = SQLhash
+	}
+
+	if err := reconcileGrantsInDB(sqlRunner, mysqlUser, permissions, version); err != nil {
+		return err
+	}
+
+	return r.reconcileUserOnNodes(sqlRunner, mysqlUser, cluster, SQL, permissions, version)
+}
+
+// reconcileUserOnNodes checks that the user exists with the same account and grants as the leader
+// on every other node of the cluster, including the read-only nodes. The nodes may diverge from the
+// leader if the replication was broken, they are repaired without writing the binlog. The errors of
+// the nodes are reported in the status only.
+func (r *MysqlUserReconciler) reconcileUserOnNodes(leaderRunner internal.SQLRunner, mysqlUser *mysqluser.MysqlUser,
+	cluster *apiv1alpha1.MysqlCluster, userSQL internal.Query, permissions []apiv1alpha1.UserPermission, version string) error {
+	expected, err := internal.GetUserAuthentications(leaderRunner, mysqlUser.Spec.User, version)
+	if err != nil {
+		return err
+	}
+	desired := internal.GetDesiredGrants(permissions, mysqlUser.Spec.WithGrantOption)
+
+	lastRepairTimes := map[string]*metav1.Time{}
+	for _, node := range mysqlUser.Status.Nodes {
+		lastRepairTimes[node.Name] = node.LastRepairTime
+	}
+	var nodes []apiv1alpha1.UserNodeStatus
+	for _, node := range cluster.Status.Nodes {
+		// The leader is the source of the repairs.
+		if node.RaftStatus.Role == string(utils.Leader) {
+			continue
+		}
+		nodeStatus := apiv1alpha1.UserNodeStatus{
+			Name:           node.Name,
+			LastRepairTime: lastRepairTimes[node.Name],
+		}
+		r.syncUserOnNode(mysqlUser, &nodeStatus, expected, desired, userSQL, version)
+		nodes = append(nodes, nodeStatus)
+	}
+	mysqlUser.Status.Nodes = nodes
+	return nil
+}
+
+// syncUserOnNode compares the user on the node with the leader and repairs the differences.
+func (r *MysqlUserReconciler) syncUserOnNode(mysqlUser *mysqluser.MysqlUser, nodeStatus *apiv1alpha1.UserNodeStatus,
+	expected map[string]internal.UserAuthentication, desired internal.UserGrants, userSQL internal.Query, version string) {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, mysqlUser.GetClusterKey(), utils.RootUser, nodeStatus.Name))
+	if err != nil {
+		nodeStatus.State, nodeStatus.Message = apiv1alpha1.UserNodeUnknown, err.Error()
+		return
+	}
+	defer closeConn()
+
+	auths, err := internal.GetUserAuthentications(sqlRunner, mysqlUser.Spec.User, version)
+	if err != nil {
+		nodeStatus.State, nodeStatus.Message = apiv1alpha1.UserNodeUnknown, err.Error()
+		return
+	}
+
+	var reasons []string
+	var repairs []internal.Query
+	for _, host := range mysqlUser.Spec.Hosts {
+		if _, ok := auths[host]; !ok {
+			reasons = append(reasons, fmt.Sprintf("user %s@%s is missing", mysqlUser.Spec.User, host))
+		}
+	}
+	// The missing users are created with their grants. The user management SQL changes the passwords
+	// of all the hosts, so the accounts of all the hosts are copied from the leader.
+	created := len(reasons) != 0
+	if created {
+		repairs = append(repairs, userSQL)
+	}
+	for _, host := range mysqlUser.Spec.Hosts {
+		expectedAuth, ok := expected[host]
+		if !ok {
+			continue
+		}
+		auth, exists := auths[host]
+		if exists && !auth.Equal(expectedAuth) {
+			reasons = append(reasons, fmt.Sprintf("account of %s@%s differs", mysqlUser.Spec.User, host))
+		}
+		if created || !auth.Equal(expectedAuth) {
+			repair, err := internal.BuildUserAuthenticationQuery(mysqlUser.Spec.User, host, auth, expectedAuth)
+			if err != nil {
+				nodeStatus.State = apiv1alpha1.UserNodeDiverged
+				nodeStatus.Message = fmt.Sprintf("%s, failed to repair: %s", strings.Join(reasons, "; "), err)
+				return
+			}
+			repairs = append(repairs, repair)
+		}
+		if !exists {
+			continue
+		}
+
+		stmts, err := internal.ShowGrants(sqlRunner, mysqlUser.Spec.User, host)
+		if err != nil {
+			nodeStatus.State, nodeStatus.Message = apiv1alpha1.UserNodeUnknown, err.Error()
+			return
+		}
+		if drifts := internal.DiffGrants(host, desired, internal.ParseGrants(stmts)); len(drifts) != 0 {
+			reasons = append(reasons, fmt.Sprintf("grants of %s@%s drifted", mysqlUser.Spec.User, host))
+			repairs = append(repairs, internal.BuildGrantDriftsQuery(mysqlUser.Spec.User, drifts))
+		}
+	}
+	if len(repairs) == 0 {
+		nodeStatus.State, nodeStatus.Message = apiv1alpha1.UserNodeSynced, ""
+		return
+	}
+
+	userLog.Info("repairing mysql user on node", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User,
+		"node", nodeStatus.Name, "reasons", reasons)
+	if err := internal.ExecWithoutBinlog(sqlRunner, internal.ConcatenateQueries(repairs...)); err != nil {
+		nodeStatus.State = apiv1alpha1.UserNodeDiverged
+		nodeStatus.Message = fmt.Sprintf("%s, failed to repair: %s", strings.Join(reasons, "; "), err)
+		return
+	}
+	now := metav1.Now()
+	nodeStatus.State, nodeStatus.Message = apiv1alpha1.UserNodeSynced, ""
+	nodeStatus.LastRepairTime = &now
+}
+
+// getPermissions returns the permissions of the user. MySQL 5.7 does not support roles,
+// the permissions of the roles are granted to the user directly.
+func (r *MysqlUserReconciler) getPermissions(ctx context.Context, mysqlUser *mysqluser.MysqlUser, version string) ([]apiv1alpha1.UserPermission, error) {
+	if version == "8.0" || len(mysqlUser.Spec.Roles) == 0 {
+		return mysqlUser.Spec.Permissions, nil
+	}
+
+	roles := &apiv1alpha1.MysqlRoleList{}
+	if err := r.List(ctx, roles, client.InNamespace(mysqlUser.Namespace)); err != nil {
+		return nil, err
+	}
+	permissions := append([]apiv1alpha1.UserPermission{}, mysqlUser.Spec.Permissions...)
+	for _, name := range mysqlUser.Spec.Roles {
+		found := false
+		for i := range roles.Items {
+			role := mysqlrole.New(&roles.Items[i])
+			if role.Spec.Role == name && role.GetClusterKey() == mysqlUser.GetClusterKey() {
+				permissions = append(permissions, role.Spec.Permissions...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the role %s is not found", name)
+		}
+	}
+	return permissions, nil
+}
+
+// rotatePassword rotates the password of the user by the rotationPolicy. If generatePassword is set,
+// a new password is generated and written into the secret when the interval elapses. On MySQL 8.0,
+// the old password is retained until the retainPeriod elapses, so that the applications can roll over.
+func (r *MysqlUserReconciler) rotatePassword(ctx context.Context, sqlRunner internal.SQLRunner, mysqlUser *mysqluser.MysqlUser, secret *corev1.Secret, version string) error {
+	policy := mysqlUser.Spec.RotationPolicy
+	status := mysqlUser.Status.PasswordRotation
+	now := time.Now()
+	// The hosts which exist in mysql.
+	hosts := []string{}
+	for _, host := range mysqlUser.Spec.Hosts {
+		for _, allowed := range mysqlUser.Status.AllowedHosts {
+			if host == allowed {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	// Discard the old password after the retain period, or the rotation policy is removed.
+	if status != nil && status.OldPasswordDiscardTime != nil && (policy == nil || !now.Before(status.OldPasswordDiscardTime.Time)) {
+		for _, host := range hosts {
+			if err := internal.DiscardOldPassword(sqlRunner, mysqlUser.Spec.User, host); err != nil {
+				return err
+			}
+		}
+		userLog.Info("discarded the old password", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User)
+		status.OldPasswordDiscardTime = nil
+	}
+	if policy == nil {
+		mysqlUser.Status.PasswordRotation = nil
 		return nil
 	}
-	// Create/Update user in database.
-	userLog.Info("creating mysql user", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User, "cluster", mysqlUser.GetClusterKey())
-	if err := sqlRunner.QueryExec(SQL); err != nil {
+	if status == nil {
+		status = &apiv1alpha1.PasswordRotationStatus{}
+		mysqlUser.Status.PasswordRotation = status
+	}
+
+	key := mysqlUser.Spec.SecretSelector.SecretKey
+	if policy.GeneratePassword && (len(secret.Data[key]) == 0 ||
+		status.NextRotationTime != nil && !now.Before(status.NextRotationTime.Time)) {
+		// NOTE: use only alpha-numeric string, the password may be used unescaped by the applications.
+		password, err := rand.AlphaNumericString(passwordLength)
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = []byte(password)
+		if err := r.Update(ctx, secret); err != nil {
+			return err
+		}
+		userLog.Info("generated a new password", "key", mysqlUser.GetKey(), "secret", secret.Name)
+	}
+
+	password := string(secret.Data[key])
+	if password == "" {
+		return nil
+	}
+	revision, err := utils.Hash(password)
+	if err != nil {
 		return err
 	}
+	if revision == status.PasswordRevision {
+		return nil
+	}
 
-	mysqlUser.Status.Revision = SQLhash
+	// The password has been changed, the first revision is only recorded.
+	rotated := len(status.PasswordRevision) != 0
+	retain := rotated && version == "8.0" && policy.RetainPeriod.Duration > 0
+	if rotated {
+		lastTime := metav1.NewTime(now)
+		status.LastRotationTime = &lastTime
+	}
+	if retain {
+		discardTime := metav1.NewTime(now.Add(policy.RetainPeriod.Duration))
+		status.OldPasswordDiscardTime = &discardTime
+	}
+	nextTime := metav1.NewTime(now.Add(policy.Interval.Duration))
+	status.NextRotationTime = &nextTime
+	status.PasswordRevision = revision
+	if !retain {
+		if rotated {
+			userLog.Info("rotated the password", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User)
+		}
+		return nil
+	}
+
+	// RETAIN CURRENT PASSWORD is not idempotent, running it again with the same password
+	// replaces the retained old password. The revision is persisted before, so that it runs
+	// at most once, the hosts failed to retain get the new password without retaining by
+	// the user management sql.
+	if err := r.Status().Update(ctx, mysqlUser.Unwrap()); err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if err := internal.RetainCurrentPassword(sqlRunner, mysqlUser.Spec.User, host, password); err != nil {
+			return err
+		}
+	}
+	userLog.Info("rotated the password", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User)
+	return nil
+}
+
+// reconcileGrantsInDB compares the grants of each user@host in MySQL with the permissions, revokes
+// the unexpected privileges and grants the missing ones. The drifts are recorded in the status.
+// The roles of the user are reconciled on MySQL 8.0.
+func reconcileGrantsInDB(sqlRunner internal.SQLRunner, mysqlUser *mysqluser.MysqlUser, permissions []apiv1alpha1.UserPermission, version string) error {
+	desired := internal.GetDesiredGrants(permissions, mysqlUser.Spec.WithGrantOption)
+	drifts := []apiv1alpha1.GrantDrift{}
+	for _, host := range mysqlUser.Spec.Hosts {
+		stmts, err := internal.ShowGrants(sqlRunner, mysqlUser.Spec.User, host)
+		if err != nil {
+			return err
+		}
+		drifts = append(drifts, internal.DiffGrants(host, desired, internal.ParseGrants(stmts))...)
+		if version == "8.0" {
+			if err := reconcileRolesInDB(sqlRunner, mysqlUser, host, internal.ParseGrantedRoles(stmts)); err != nil {
+				return err
+			}
+		}
+	}
+	if len(drifts) == 0 {
+		mysqlUser.Status.GrantDrifts = nil
+		return nil
+	}
+
+	userLog.Info("the grants drifted from the spec", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User, "drifts", drifts)
+	if err := sqlRunner.QueryExec(internal.BuildGrantDriftsQuery(mysqlUser.Spec.User, drifts)); err != nil {
+		return fmt.Errorf("failed to correct the grants, err: %s", err)
+	}
+	now := metav1.Now()
+	mysqlUser.Status.GrantDrifts = drifts
+	mysqlUser.Status.LastDriftTime = &now
+	return nil
+}
+
+// reconcileRolesInDB grants the roles in the spec to user@host, revokes the others and sets the default roles.
+func reconcileRolesInDB(sqlRunner internal.SQLRunner, mysqlUser *mysqluser.MysqlUser, host string, current []string) error {
+	missing := utils.StringDiffIn(mysqlUser.Spec.Roles, current)
+	unexpected := utils.StringDiffIn(current, mysqlUser.Spec.Roles)
+	if len(missing) != 0 || len(unexpected) != 0 {
+		userLog.Info("granting roles to mysql user", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User,
+			"host", host, "missing", missing, "unexpected", unexpected)
+		if err := sqlRunner.QueryExec(internal.BuildRolesQuery(mysqlUser.Spec.User, host, missing, unexpected)); err != nil {
+			return fmt.Errorf("failed to grant roles, err: %s", err)
+		}
+	}
+
+	defaultRoles, err := internal.GetDefaultRoles(sqlRunner, mysqlUser.Spec.User, host)
+	if err != nil {
+		return err
+	}
+	desired := append([]string{}, mysqlUser.Spec.DefaultRoles...)
+	sort.Strings(desired)
+	if !reflect.DeepEqual(defaultRoles, desired) {
+		return internal.SetDefaultRoles(sqlRunner, mysqlUser.Spec.User, host, desired)
+	}
 	return nil
 }
 
@@ -0,0 +1,259 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqluser"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, apiv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// retainStmts returns the RETAIN CURRENT PASSWORD statements run on the server.
+func retainStmts(server *sqltest.Server) []string {
+	stmts := []string{}
+	for _, stmt := range server.Stmts() {
+		if strings.Contains(stmt, "RETAIN CURRENT PASSWORD") {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func TestRotatePassword(t *testing.T) {
+	ctx := context.TODO()
+	user := &apiv1alpha1.MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.UserSpec{
+			User:  "app",
+			Hosts: []string{"%", "localhost"},
+			SecretSelector: apiv1alpha1.SecretSelector{
+				SecretName: "app-secret",
+				SecretKey:  "password",
+			},
+			RotationPolicy: &apiv1alpha1.RotationPolicy{
+				Interval:     metav1.Duration{Duration: time.Hour},
+				RetainPeriod: metav1.Duration{Duration: time.Minute},
+			},
+		},
+		Status: apiv1alpha1.UserStatus{AllowedHosts: []string{"%", "localhost"}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	r := &MysqlUserReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(user, secret).Build(),
+	}
+	getUser := func() *mysqluser.MysqlUser {
+		got := &apiv1alpha1.MysqlUser{}
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(user), got))
+		return mysqluser.New(got)
+	}
+
+	// The first revision is only recorded.
+	server := &sqltest.Server{}
+	sqlRunner := internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	mysqlUser := getUser()
+	assert.NoError(t, r.rotatePassword(ctx, sqlRunner, mysqlUser, secret, "8.0"))
+	assert.Empty(t, server.Stmts())
+	assert.NoError(t, r.Status().Update(ctx, mysqlUser.Unwrap()))
+
+	// The password is changed, retaining it fails on the second host.
+	secret.Data["password"] = []byte("new")
+	retainLocalhost := "ALTER USER app@localhost IDENTIFIED BY new RETAIN CURRENT PASSWORD;"
+	server = &sqltest.Server{Errs: map[string]error{retainLocalhost: errors.New("lost connection")}}
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	mysqlUser = getUser()
+	assert.Error(t, r.rotatePassword(ctx, sqlRunner, mysqlUser, secret, "8.0"))
+	assert.Equal(t, []string{
+		"ALTER USER app@% IDENTIFIED BY new RETAIN CURRENT PASSWORD;",
+		retainLocalhost,
+	}, retainStmts(server))
+	// The rotation has been persisted before retaining.
+	persisted := getUser()
+	assert.NotNil(t, persisted.Status.PasswordRotation.OldPasswordDiscardTime)
+	assert.NotNil(t, persisted.Status.PasswordRotation.LastRotationTime)
+
+	// The retry does not retain the new password again.
+	server = &sqltest.Server{}
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, r.rotatePassword(ctx, sqlRunner, persisted, secret, "8.0"))
+	assert.Empty(t, retainStmts(server))
+
+	// The old password is not retained on MySQL 5.7.
+	secret.Data["password"] = []byte("newer")
+	mysqlUser = getUser()
+	assert.NoError(t, r.rotatePassword(ctx, sqlRunner, mysqlUser, secret, "5.7"))
+	assert.Empty(t, retainStmts(server))
+	assert.Equal(t, persisted.Status.PasswordRotation.OldPasswordDiscardTime, mysqlUser.Status.PasswordRotation.OldPasswordDiscardTime)
+}
+
+func TestGetPermissions(t *testing.T) {
+	ctx := context.TODO()
+	owner := apiv1alpha1.UserOwner{ClusterName: "sample", NameSpace: "default"}
+	newRole := func(name, role string, owner apiv1alpha1.UserOwner, database string) *apiv1alpha1.MysqlRole {
+		return &apiv1alpha1.MysqlRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: apiv1alpha1.RoleSpec{
+				Role:      role,
+				RoleOwner: owner,
+				Permissions: []apiv1alpha1.UserPermission{
+					{Database: database, Tables: []string{"*"}, Privileges: []string{"SELECT"}},
+				},
+			},
+		}
+	}
+	r := &MysqlUserReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+			newRole("reader", "reader", owner, "app"),
+			// The same role name bound to another cluster.
+			newRole("reader-other", "reader", apiv1alpha1.UserOwner{ClusterName: "other"}, "other"),
+			newRole("writer", "writer", owner, "logs"),
+		).Build(),
+	}
+	own := apiv1alpha1.UserPermission{Database: "app", Tables: []string{"t1"}, Privileges: []string{"INSERT"}}
+	newUser := func(roles ...string) *mysqluser.MysqlUser {
+		return mysqluser.New(&apiv1alpha1.MysqlUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: apiv1alpha1.UserSpec{
+				User:        "app",
+				UserOwner:   owner,
+				Roles:       roles,
+				Permissions: []apiv1alpha1.UserPermission{own},
+			},
+		})
+	}
+
+	// MySQL 5.7 has no roles, the permissions of the roles are granted to the user directly.
+	got, err := r.getPermissions(ctx, newUser("reader", "writer"), "5.7")
+	assert.NoError(t, err)
+	assert.Equal(t, []apiv1alpha1.UserPermission{
+		own,
+		{Database: "app", Tables: []string{"*"}, Privileges: []string{"SELECT"}},
+		{Database: "logs", Tables: []string{"*"}, Privileges: []string{"SELECT"}},
+	}, got)
+
+	// MySQL 8.0 grants the roles, the permissions are not expanded.
+	got, err = r.getPermissions(ctx, newUser("reader", "writer"), "8.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []apiv1alpha1.UserPermission{own}, got)
+
+	// The role must exist in the cluster of the user.
+	_, err = r.getPermissions(ctx, newUser("admin"), "5.7")
+	assert.EqualError(t, err, "the role admin is not found")
+}
+
+func TestReconcileUserOnNodes(t *testing.T) {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Status: apiv1alpha1.MysqlClusterStatus{
+			Nodes: []apiv1alpha1.NodeStatus{
+				{Name: "sample-mysql-0.sample-mysql.default", RaftStatus: apiv1alpha1.RaftStatus{Role: "LEADER"}},
+				{Name: "sample-mysql-1.sample-mysql.default", RaftStatus: apiv1alpha1.RaftStatus{Role: "FOLLOWER"}},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	user := mysqluser.New(&apiv1alpha1.MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.UserSpec{
+			User:      "app",
+			Hosts:     []string{"%"},
+			UserOwner: apiv1alpha1.UserOwner{ClusterName: "sample"},
+		},
+	})
+	server := &sqltest.Server{}
+	db := sqltest.NewDB(t, server)
+	r := &MysqlUserReconciler{
+		Client:           fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret).Build(),
+		SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(db),
+	}
+
+	err := r.reconcileUserOnNodes(internal.NewSQLRunnerFromDB(db), user, cluster, internal.NewQuery("CREATE USER app;"), nil, "5.7")
+	assert.NoError(t, err)
+	// The leader is the source of the repairs, only the follower is checked.
+	assert.Len(t, user.Status.Nodes, 1)
+	assert.Equal(t, "sample-mysql-1.sample-mysql.default", user.Status.Nodes[0].Name)
+	selects := 0
+	for _, stmt := range server.Stmts() {
+		if strings.HasPrefix(stmt, "SELECT host, plugin, authentication_string") {
+			selects++
+		}
+	}
+	assert.Equal(t, 2, selects)
+}
+
+func TestSyncUserOnSuperReadOnlyNode(t *testing.T) {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	user := mysqluser.New(&apiv1alpha1.MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.UserSpec{
+			User:      "app",
+			Hosts:     []string{"%"},
+			UserOwner: apiv1alpha1.UserOwner{ClusterName: "sample"},
+		},
+	})
+	server := &sqltest.Server{}
+	server.SetResult("select @@global.super_read_only;", &sqltest.Result{Columns: []string{"super_read_only"}, Rows: [][]driver.Value{{int64(1)}}})
+	r := &MysqlUserReconciler{
+		Client:           fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(cluster, secret).Build(),
+		SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+	}
+
+	// The read-only node is repaired with super_read_only disabled, then it is restored.
+	nodeStatus := apiv1alpha1.UserNodeStatus{Name: "sample-mysql-ro-0.sample-mysql-ro.default"}
+	r.syncUserOnNode(user, &nodeStatus, nil, nil, internal.NewQuery("CREATE USER app;"), "5.7")
+	assert.Equal(t, apiv1alpha1.UserNodeSynced, nodeStatus.State)
+	assert.NotNil(t, nodeStatus.LastRepairTime)
+	stmts := server.Stmts()
+	assert.Equal(t, []string{
+		"SET SESSION sql_log_bin = 0;\nSET GLOBAL super_read_only = OFF;\nCREATE USER app;\nSET SESSION sql_log_bin = 1;",
+		"SET GLOBAL super_read_only = ON;",
+	}, stmts[len(stmts)-2:])
+}
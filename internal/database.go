@@ -0,0 +1,96 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DatabaseInfo contains the information of a database read from information_schema.
+type DatabaseInfo struct {
+	Exists       bool
+	CharacterSet string
+	Collation    string
+	SizeBytes    int64
+}
+
+// BuildDatabaseQuery returns the query which creates the database if it does not exist, or
+// alters the database with the character set and collation if alter is true.
+func BuildDatabaseQuery(database, charset, collation string, alter bool) Query {
+	stmt := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", escapeID(database))
+	if alter {
+		stmt = fmt.Sprintf("ALTER DATABASE %s", escapeID(database))
+	}
+	stmt += fmt.Sprintf(" CHARACTER SET %s", charset)
+	if collation != "" {
+		stmt += fmt.Sprintf(" COLLATE %s", collation)
+	}
+
+	return NewQuery(stmt + ";")
+}
+
+// CreateDatabase creates the database with the character set and collation if it does not exist.
+func CreateDatabase(sqlRunner SQLRunner, database, charset, collation string) error {
+	if err := sqlRunner.QueryExec(BuildDatabaseQuery(database, charset, collation, false)); err != nil {
+		return fmt.Errorf("failed to create database, err: %s", err)
+	}
+
+	return nil
+}
+
+// AlterDatabase changes the default character set and collation of the database.
+func AlterDatabase(sqlRunner SQLRunner, database, charset, collation string) error {
+	if err := sqlRunner.QueryExec(BuildDatabaseQuery(database, charset, collation, true)); err != nil {
+		return fmt.Errorf("failed to alter database, err: %s", err)
+	}
+
+	return nil
+}
+
+// DropDatabase removes the database and all its tables if it exists.
+func DropDatabase(sqlRunner SQLRunner, database string) error {
+	query := NewQuery(fmt.Sprintf("DROP DATABASE IF EXISTS %s;", escapeID(database)))
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to drop database, err: %s", err)
+	}
+
+	return nil
+}
+
+// GetDatabaseInfo reads the character set, collation and size of the database from
+// information_schema, the size is the sum of the data and the indexes of its tables.
+func GetDatabaseInfo(sqlRunner SQLRunner, database string) (*DatabaseInfo, error) {
+	info := &DatabaseInfo{}
+	err := sqlRunner.QueryRow(NewQuery("SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME "+
+		"FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", database), &info.CharacterSet, &info.Collation)
+	if err == sql.ErrNoRows {
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database information, err: %s", err)
+	}
+	info.Exists = true
+
+	if err := sqlRunner.QueryRow(NewQuery("SELECT COALESCE(SUM(DATA_LENGTH + INDEX_LENGTH), 0) "+
+		"FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?", database), &info.SizeBytes); err != nil {
+		return nil, fmt.Errorf("failed to get database size, err: %s", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDatabaseQuery(t *testing.T) {
+	// create with the default collation of the character set.
+	{
+		query := BuildDatabaseQuery("app", "utf8mb4", "", false)
+		assert.Equal(t, "CREATE DATABASE IF NOT EXISTS `app` CHARACTER SET utf8mb4;", query.String())
+		assert.Empty(t, query.Args())
+	}
+	// alter with the collation.
+	{
+		query := BuildDatabaseQuery("app", "utf8mb4", "utf8mb4_bin", true)
+		assert.Equal(t, "ALTER DATABASE `app` CHARACTER SET utf8mb4 COLLATE utf8mb4_bin;", query.String())
+	}
+}
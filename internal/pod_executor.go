@@ -29,6 +29,17 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 )
 
+// XenonChecker runs the commands of xenonchecker in the xenon container of the pods.
+type XenonChecker interface {
+	RunXenonChecker(namespace, podName, command string) error
+}
+
+// XenonSemiChecker enables or disables the semi-sync check of xenon in the pods.
+type XenonSemiChecker interface {
+	EnableXenonSemiCheck(namespace, podName string) error
+	CloseXenonSemiCheck(namespace, podName string) error
+}
+
 type PodExecutor struct {
 	client corev1client.CoreV1Interface
 	config *rest.Config
@@ -116,3 +127,26 @@ func (p *PodExecutor) CloseXenonSemiCheck(namespace, podName string) error {
 	}
 	return nil
 }
+
+func (p *PodExecutor) EnableXenonSemiCheck(namespace, podName string) error {
+	cmd := []string{"xenoncli", "raft", "enablechecksemisync"}
+	_, stderr, err := p.Exec(namespace, podName, "xenon", cmd...)
+	if err != nil {
+		return err
+	}
+	if len(stderr) != 0 {
+		return fmt.Errorf("run command %s in xenon failed: %s", cmd, stderr)
+	}
+	return nil
+}
+
+// RunXenonChecker runs the command of xenonchecker in the xenon container, the steps are logged
+// into the stderr, so only the exit code is checked.
+func (p *PodExecutor) RunXenonChecker(namespace, podName, command string) error {
+	cmd := []string{"/xenonchecker", command}
+	_, stderr, err := p.Exec(namespace, podName, "xenon", cmd...)
+	if err != nil {
+		return fmt.Errorf("run command %s in xenon failed: %s, %s", cmd, err, stderr)
+	}
+	return nil
+}
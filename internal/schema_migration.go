@@ -0,0 +1,138 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// MigrationsTable is the table which tracks the applied migrations in the target database,
+// the migrations are keyed by the MysqlSchemaMigration so that several of them can share a database.
+const MigrationsTable = "radondb_schema_migrations"
+
+// onlineAlterRegexp matches a single ALTER TABLE statement of a table in the target database,
+// the table may be quoted by backticks.
+var onlineAlterRegexp = regexp.MustCompile("(?is)^ALTER\\s+TABLE\\s+(`[^`.]+`|\\w+)\\s+(.+?)\\s*;?$")
+
+// MigrationChecksum returns the sha256 checksum of the SQL file.
+func MigrationChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnsureMigrationsTable creates the tracking table in the database if it does not exist.
+func EnsureMigrationsTable(sqlRunner SQLRunner, database string) error {
+	query := NewQuery(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s ("+
+		"migration VARCHAR(320) NOT NULL, "+
+		"version VARCHAR(255) NOT NULL, "+
+		"checksum CHAR(64) NOT NULL, "+
+		"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, "+
+		"PRIMARY KEY (migration, version))", escapeID(database), escapeID(MigrationsTable)))
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to create the migrations table, err: %s", err)
+	}
+
+	return nil
+}
+
+// GetAppliedMigrations returns the migrations of the MysqlSchemaMigration recorded in the tracking
+// table in the applied order, the migration is identified by its namespaced name.
+func GetAppliedMigrations(sqlRunner SQLRunner, database, migration string) ([]apiv1alpha1.AppliedMigration, error) {
+	rows, err := sqlRunner.QueryRows(NewQuery(fmt.Sprintf("SELECT version, checksum, UNIX_TIMESTAMP(applied_at) FROM %s.%s "+
+		"WHERE migration = ? ORDER BY applied_at, version", escapeID(database), escapeID(MigrationsTable)), migration))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the applied migrations, err: %s", err)
+	}
+	defer rows.Close()
+
+	var migrations []apiv1alpha1.AppliedMigration
+	for rows.Next() {
+		var migration apiv1alpha1.AppliedMigration
+		var appliedAt int64
+		if err := rows.Scan(&migration.Version, &migration.Checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to get the applied migrations, err: %s", err)
+		}
+		migration.AppliedTime = metav1.NewTime(time.Unix(appliedAt, 0))
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, rows.Err()
+}
+
+// ApplyMigration runs the SQL file in the database, then records it in the tracking table.
+// The statements are run in one session, the DDL statements are committed implicitly by MySQL,
+// so a failed migration may be applied partially.
+func ApplyMigration(sqlRunner SQLRunner, database, migration, version, content string) error {
+	// The SQL file is run without arguments so that the placeholders in it are not interpolated.
+	query := NewQuery(fmt.Sprintf("USE %s;\n%s", escapeID(database), strings.TrimSpace(content)))
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to apply the migration %s, err: %s", version, err)
+	}
+
+	return RecordMigration(sqlRunner, database, migration, version, MigrationChecksum(content))
+}
+
+// RecordMigration records the applied migration of the MysqlSchemaMigration in the tracking table.
+func RecordMigration(sqlRunner SQLRunner, database, migration, version, checksum string) error {
+	query := NewQuery(fmt.Sprintf("INSERT INTO %s.%s (migration, version, checksum) VALUES (?, ?, ?)",
+		escapeID(database), escapeID(MigrationsTable)), migration, version, checksum)
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to record the migration %s, err: %s", version, err)
+	}
+
+	return nil
+}
+
+// ParseOnlineAlter returns the table and the alter clause if the SQL file contains only one
+// ALTER TABLE statement, which can be run by pt-online-schema-change.
+func ParseOnlineAlter(content string) (table, alter string, ok bool) {
+	stmt := strings.TrimSpace(stripSQLComments(content))
+	// Multiple statements are not supported by the online tool.
+	if strings.Contains(strings.TrimSuffix(stmt, ";"), ";") {
+		return "", "", false
+	}
+	matches := onlineAlterRegexp.FindStringSubmatch(stmt)
+	if matches == nil {
+		return "", "", false
+	}
+
+	return strings.Trim(matches[1], "`"), matches[2], true
+}
+
+// stripSQLComments removes the comment lines of the SQL file.
+func stripSQLComments(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
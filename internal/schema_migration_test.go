@@ -0,0 +1,70 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+)
+
+func TestParseOnlineAlter(t *testing.T) {
+	// a single ALTER TABLE statement.
+	{
+		table, alter, ok := ParseOnlineAlter("-- add the index of name\nALTER TABLE `users`\n  ADD INDEX idx_name (name);\n")
+		assert.True(t, ok)
+		assert.Equal(t, "users", table)
+		assert.Equal(t, "ADD INDEX idx_name (name)", alter)
+	}
+	// multiple statements are applied directly.
+	{
+		_, _, ok := ParseOnlineAlter("ALTER TABLE users ADD COLUMN age INT;\nUPDATE users SET age = 0;")
+		assert.False(t, ok)
+	}
+	// the table of another database is applied directly.
+	{
+		_, _, ok := ParseOnlineAlter("ALTER TABLE db1.users ADD COLUMN age INT;")
+		assert.False(t, ok)
+	}
+	// not an ALTER TABLE statement.
+	{
+		_, _, ok := ParseOnlineAlter("CREATE TABLE users (id INT PRIMARY KEY);")
+		assert.False(t, ok)
+	}
+}
+
+func TestMigrationsKeyedByMigration(t *testing.T) {
+	server := &sqltest.Server{}
+	sqlRunner := NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+
+	assert.NoError(t, EnsureMigrationsTable(sqlRunner, "app"))
+	_, err := GetAppliedMigrations(sqlRunner, "app", "default/schema")
+	assert.NoError(t, err)
+	assert.NoError(t, ApplyMigration(sqlRunner, "app", "default/schema", "V001", "CREATE TABLE t1 (id INT);"))
+	assert.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS `app`.`radondb_schema_migrations` (migration VARCHAR(320) NOT NULL, " +
+			"version VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, " +
+			"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (migration, version));",
+		"SELECT version, checksum, UNIX_TIMESTAMP(applied_at) FROM `app`.`radondb_schema_migrations` " +
+			"WHERE migration = default/schema ORDER BY applied_at, version;",
+		"USE `app`;\nCREATE TABLE t1 (id INT);",
+		"INSERT INTO `app`.`radondb_schema_migrations` (migration, version, checksum) VALUES (default/schema, V001, " +
+			MigrationChecksum("CREATE TABLE t1 (id INT);") + ");",
+	}, server.Stmts())
+}
@@ -19,6 +19,8 @@ package internal
 import (
 	"context"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -159,6 +161,22 @@ func NewSQLRunner(cfg *Config, errs ...error) (SQLRunner, closeFunc, error) {
 	return &sqlRunner{db: db}, close, nil
 }
 
+// NewSQLRunnerFromDB returns a SQLRunner on the opened database.
+func NewSQLRunnerFromDB(db *sql.DB) SQLRunner {
+	return &sqlRunner{db: db}
+}
+
+// NewSQLRunnerFactoryFromDB returns a SQLRunnerFactory which runs on the opened database
+// whatever the config is, the database is left open when the runner is closed.
+func NewSQLRunnerFactoryFromDB(db *sql.DB) SQLRunnerFactory {
+	return func(cfg *Config, errs ...error) (SQLRunner, closeFunc, error) {
+		if len(errs) > 0 && errs[0] != nil {
+			return nil, nil, errs[0]
+		}
+		return NewSQLRunnerFromDB(db), func() {}, nil
+	}
+}
+
 // QueryExec used to run the query with args.
 func (s sqlRunner) QueryExec(query Query) error {
 	if _, err := s.db.Exec(query.String(), query.args...); err != nil {
@@ -238,6 +256,7 @@ func CheckSlaveStatus(sqlRunner SQLRunner) (isLagged, isReplicating corev1.Condi
 	ioRunning := columnValue(scanArgs, cols, "Slave_IO_Running")
 	lastSQLError := columnValue(scanArgs, cols, "Last_SQL_Error")
 	secondsBehindMaster := columnValue(scanArgs, cols, "Seconds_Behind_Master")
+	sqlDelay := columnValue(scanArgs, cols, "SQL_Delay")
 
 	if utils.StringInArray(slaveIOState, errorConnectionStates) {
 		return isLagged, corev1.ConditionFalse, fmt.Errorf("Slave_IO_State: %s", slaveIOState)
@@ -256,8 +275,10 @@ func CheckSlaveStatus(sqlRunner SQLRunner) (isLagged, isReplicating corev1.Condi
 		return
 	}
 
-	// Check whether the slave is lagged.
+	// Check whether the slave is lagged, the intended delay (MASTER_DELAY) is not the lag.
 	sec, _ := strconv.ParseFloat(secondsBehindMaster, 64)
+	delay, _ := strconv.ParseFloat(sqlDelay, 64)
+	sec -= delay
 	if sec > longQueryTime*100 {
 		isLagged = corev1.ConditionTrue
 	} else {
@@ -286,6 +307,11 @@ func GetGlobalVariable(sqlRunner SQLRunner, param string, val interface{}) error
 	return sqlRunner.QueryRow(NewQuery("select @@global.?", param), val)
 }
 
+// SetGlobalVariable sets the global variable online, the name should be validated by the caller.
+func SetGlobalVariable(sqlRunner SQLRunner, name string, val interface{}) error {
+	return sqlRunner.QueryExec(NewQuery(fmt.Sprintf("SET GLOBAL %s = ?", name), val))
+}
+
 func CheckProcesslist(sqlRunner SQLRunner) (bool, error) {
 	var rows *sql.Rows
 	rows, err := sqlRunner.QueryRows(NewQuery("show processlist;"))
@@ -336,8 +362,9 @@ func columnValue(scanArgs []interface{}, slaveCols []string, colName string) str
 	return string(*scanArgs[columnIndex].(*sql.RawBytes))
 }
 
-// BuildUserManagementSQL returns a Query that creates a user and grants it permissions.
-func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass string) (q Query, err error) {
+// BuildUserManagementSQL returns a Query that creates a user and grants it permissions, the
+// version is the version of the server.
+func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass, version string) (q Query, err error) {
 	userName := user.Spec.User
 	hosts := user.Spec.Hosts
 	permissions := user.Spec.Permissions
@@ -347,9 +374,14 @@ func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass string) (q Query,
 		return query, errors.New("no allowedHosts specified")
 	}
 
+	options, err := getUserOptions(&user.Spec, version)
+	if err != nil {
+		return query, err
+	}
+
 	queries := []Query{
-		getCreateUserQuery(userName, pass, hosts, user.Spec.TLSOptions),
-		getAlterUserQuery(userName, pass, hosts),
+		getCreateUserQuery(userName, pass, hosts, user.Spec.TLSOptions, options),
+		getAlterUserQuery(userName, pass, hosts, options),
 	}
 
 	if len(permissions) > 0 {
@@ -361,24 +393,91 @@ func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass string) (q Query,
 	return query, nil
 }
 
-func getCreateUserQuery(user, pwd string, allowedHosts []string, tlsOption apiv1alpha1.TLSOptions) Query {
+func getCreateUserQuery(user, pwd string, allowedHosts []string, tlsOption apiv1alpha1.TLSOptions, options string) Query {
 	idsTmpl, idsArgs := getUsersIdentification(user, &pwd, allowedHosts)
 	idsTmpl += getUserTLSRequire(tlsOption)
 
-	return NewQuery(fmt.Sprintf("CREATE USER IF NOT EXISTS%s", idsTmpl), idsArgs...)
+	return NewQuery(fmt.Sprintf("CREATE USER IF NOT EXISTS%s%s", idsTmpl, options), idsArgs...)
 }
 
 func getUserTLSRequire(tlsOption apiv1alpha1.TLSOptions) string {
 	return fmt.Sprintf(" REQUIRE %s", tlsOption.Type)
 }
 
-// Only support changing passwords.
-func getAlterUserQuery(user, pwd string, allowedHosts []string) Query {
+// GetServerVersion returns the version of the server, such as 8.0.25-15.
+func GetServerVersion(sqlRunner SQLRunner) (string, error) {
+	var version string
+	if err := sqlRunner.QueryRow(NewQuery("SELECT VERSION()"), &version); err != nil {
+		return "", fmt.Errorf("failed to get the server version, err: %s", err)
+	}
+	return version, nil
+}
+
+// versionAtLeast returns true if the server version, such as 8.0.25-15, is not lower than
+// the version numbers.
+func versionAtLeast(version string, numbers ...int) bool {
+	parts := strings.Split(strings.SplitN(version, "-", 2)[0], ".")
+	for i, n := range numbers {
+		v := 0
+		if i < len(parts) {
+			v, _ = strconv.Atoi(parts[i])
+		}
+		if v != n {
+			return v > n
+		}
+	}
+	return true
+}
+
+// getUserOptions returns the resource limits, the password management options and the
+// account locking options of the user. All the options are rendered, so that the options
+// removed from the spec are reset. The version is the version of the server.
+func getUserOptions(spec *apiv1alpha1.UserSpec, version string) (string, error) {
+	limits := apiv1alpha1.UserResourceLimits{}
+	if spec.ResourceLimits != nil {
+		limits = *spec.ResourceLimits
+	}
+	options := fmt.Sprintf(" WITH MAX_QUERIES_PER_HOUR %d MAX_UPDATES_PER_HOUR %d MAX_CONNECTIONS_PER_HOUR %d MAX_USER_CONNECTIONS %d",
+		limits.MaxQueriesPerHour, limits.MaxUpdatesPerHour, limits.MaxConnectionsPerHour, limits.MaxUserConnections)
+
+	passwordOptions := apiv1alpha1.UserPasswordOptions{}
+	if spec.PasswordOptions != nil {
+		passwordOptions = *spec.PasswordOptions
+	}
+	switch {
+	case passwordOptions.ExpireDays == nil:
+		options += " PASSWORD EXPIRE DEFAULT"
+	case *passwordOptions.ExpireDays == 0:
+		options += " PASSWORD EXPIRE NEVER"
+	default:
+		options += fmt.Sprintf(" PASSWORD EXPIRE INTERVAL %d DAY", *passwordOptions.ExpireDays)
+	}
+	// The failed-login tracking is supported since MySQL 8.0.19.
+	if versionAtLeast(version, 8, 0, 19) {
+		lockTime := fmt.Sprintf("%d", passwordOptions.PasswordLockDays)
+		if passwordOptions.PasswordLockDays < 0 {
+			lockTime = "UNBOUNDED"
+		}
+		options += fmt.Sprintf(" FAILED_LOGIN_ATTEMPTS %d PASSWORD_LOCK_TIME %s", passwordOptions.FailedLoginAttempts, lockTime)
+	} else if passwordOptions.FailedLoginAttempts != 0 || passwordOptions.PasswordLockDays != 0 {
+		return "", fmt.Errorf("failedLoginAttempts and passwordLockDays are not supported before MySQL 8.0.19, the version is %s", version)
+	}
+
+	if spec.AccountLocked {
+		options += " ACCOUNT LOCK"
+	} else {
+		options += " ACCOUNT UNLOCK"
+	}
+	return options, nil
+}
+
+// Only support changing passwords and the options of the user.
+func getAlterUserQuery(user, pwd string, allowedHosts []string, options string) Query {
 	args := []interface{}{}
 	q := "ALTER USER"
 
 	ids, idsArgs := getUsersIdentification(user, &pwd, allowedHosts)
-	q += ids
+	q += ids + options
 	args = append(args, idsArgs...)
 
 	return NewQuery(q, args...)
@@ -414,6 +513,320 @@ func DropUser(sqlRunner SQLRunner, user, host string) error {
 	return nil
 }
 
+// RetainCurrentPassword changes the password of user@host and retains the current password as the
+// secondary password, so that the clients can use both passwords. It is supported since MySQL 8.0.14.
+func RetainCurrentPassword(sqlRunner SQLRunner, user, host, pwd string) error {
+	query := NewQuery("ALTER USER ?@? IDENTIFIED BY ? RETAIN CURRENT PASSWORD;", user, host, pwd)
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to change password, err: %s", err)
+	}
+
+	return nil
+}
+
+// DiscardOldPassword discards the secondary password of user@host.
+func DiscardOldPassword(sqlRunner SQLRunner, user, host string) error {
+	query := NewQuery("ALTER USER ?@? DISCARD OLD PASSWORD;", user, host)
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to discard old password, err: %s", err)
+	}
+
+	return nil
+}
+
+// userAccountColumns are the columns of the account in mysql.user besides the authentication.
+var userAccountColumns = []string{
+	"ssl_type", "ssl_cipher", "x509_issuer", "x509_subject",
+	"max_questions", "max_updates", "max_connections", "max_user_connections",
+	"password_expired", "password_lifetime", "account_locked",
+}
+
+// userAccountColumns80 are the account columns added in MySQL 8.0, the User_attributes holds the
+// secondary password retained by RETAIN CURRENT PASSWORD and the failed-login tracking.
+var userAccountColumns80 = []string{
+	"Password_reuse_history", "Password_reuse_time", "Password_require_current", "User_attributes",
+}
+
+// UserAuthentication is the account of user@host in mysql.user.
+type UserAuthentication struct {
+	Plugin     string
+	AuthString string
+	// Attributes are the other account columns indexed by the column name, e.g. the TLS options,
+	// the resource limits and the password policies.
+	Attributes map[string]sql.NullString
+}
+
+// Equal returns whether the accounts are the same.
+func (a UserAuthentication) Equal(b UserAuthentication) bool {
+	if a.Plugin != b.Plugin || a.AuthString != b.AuthString || len(a.Attributes) != len(b.Attributes) {
+		return false
+	}
+	for column, value := range a.Attributes {
+		if other, ok := b.Attributes[column]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// getUserAccountColumns returns the account columns of the MySQL major version.
+func getUserAccountColumns(version string) []string {
+	columns := append([]string{}, userAccountColumns...)
+	if version == "8.0" {
+		columns = append(columns, userAccountColumns80...)
+	}
+	return columns
+}
+
+// GetUserAuthentications returns the accounts of the user indexed by the host.
+func GetUserAuthentications(sqlRunner SQLRunner, user, version string) (map[string]UserAuthentication, error) {
+	columns := getUserAccountColumns(version)
+	rows, err := sqlRunner.QueryRows(NewQuery(fmt.Sprintf("SELECT host, plugin, authentication_string, %s FROM mysql.user WHERE user = ?",
+		strings.Join(columns, ", ")), user))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user authentications, err: %s", err)
+	}
+	defer rows.Close()
+
+	auths := map[string]UserAuthentication{}
+	for rows.Next() {
+		var host string
+		auth := UserAuthentication{Attributes: map[string]sql.NullString{}}
+		values := make([]sql.NullString, len(columns))
+		dest := []interface{}{&host, &auth.Plugin, &auth.AuthString}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to get user authentications, err: %s", err)
+		}
+		for i, column := range columns {
+			auth.Attributes[column] = values[i]
+		}
+		auths[host] = auth
+	}
+
+	return auths, rows.Err()
+}
+
+// userAttributes is the User_attributes column of mysql.user in MySQL 8.0.
+type userAttributes struct {
+	AdditionalPassword *string                `json:"additional_password,omitempty"`
+	PasswordLocking    *passwordLocking       `json:"Password_locking,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type passwordLocking struct {
+	FailedLoginAttempts  int `json:"failed_login_attempts"`
+	PasswordLockTimeDays int `json:"password_lock_time_days"`
+}
+
+// parseUserAttributes parses the User_attributes of the account, it is empty if the column is NULL.
+func parseUserAttributes(auth UserAuthentication) (userAttributes, error) {
+	attrs := userAttributes{}
+	value := auth.Attributes["User_attributes"]
+	if !value.Valid || len(value.String) == 0 {
+		return attrs, nil
+	}
+	if err := json.Unmarshal([]byte(value.String), &attrs); err != nil {
+		return attrs, fmt.Errorf("failed to parse User_attributes, err: %s", err)
+	}
+	return attrs, nil
+}
+
+// BuildUserAuthenticationQuery returns the ALTER USER queries which set the account of user@host to the one
+// copied from another node. The current is the account on the node to repair, it is empty if the user is
+// missing. The caching_sha2_password hash contains binary characters, which are written as a hexadecimal
+// literal. Only the account columns read from the other node are set. The secondary password can not be set
+// by ALTER USER without its plaintext, so an error is returned if it has to be copied.
+func BuildUserAuthenticationQuery(user, host string, current, expected UserAuthentication) (Query, error) {
+	q := "ALTER USER ?@? IDENTIFIED WITH ? AS ?"
+	args := []interface{}{user, host, expected.Plugin, expected.AuthString}
+	for _, c := range []byte(expected.AuthString) {
+		if c < 0x20 || c > 0x7e {
+			q = fmt.Sprintf("ALTER USER ?@? IDENTIFIED WITH ? AS 0x%s", hex.EncodeToString([]byte(expected.AuthString)))
+			args = []interface{}{user, host, expected.Plugin}
+			break
+		}
+	}
+	attr := func(column string) (sql.NullString, bool) {
+		value, ok := expected.Attributes[column]
+		return value, ok
+	}
+	number := func(value sql.NullString) int64 {
+		n, _ := strconv.ParseInt(value.String, 10, 64)
+		return n
+	}
+
+	if sslType, ok := attr("ssl_type"); ok {
+		switch strings.ToUpper(sslType.String) {
+		case "ANY":
+			q += " REQUIRE SSL"
+		case "X509":
+			q += " REQUIRE X509"
+		case "SPECIFIED":
+			var requires []string
+			for _, column := range []string{"x509_issuer", "x509_subject", "ssl_cipher"} {
+				if value, _ := attr(column); len(value.String) != 0 {
+					name := map[string]string{"x509_issuer": "ISSUER", "x509_subject": "SUBJECT", "ssl_cipher": "CIPHER"}[column]
+					requires = append(requires, name+" ?")
+					args = append(args, value.String)
+				}
+			}
+			q += " REQUIRE " + strings.Join(requires, " AND ")
+		default:
+			q += " REQUIRE NONE"
+		}
+	}
+
+	var limits []string
+	for _, column := range []string{"max_questions", "max_updates", "max_connections", "max_user_connections"} {
+		if value, ok := attr(column); ok {
+			name := map[string]string{
+				"max_questions":        "MAX_QUERIES_PER_HOUR",
+				"max_updates":          "MAX_UPDATES_PER_HOUR",
+				"max_connections":      "MAX_CONNECTIONS_PER_HOUR",
+				"max_user_connections": "MAX_USER_CONNECTIONS",
+			}[column]
+			limits = append(limits, fmt.Sprintf("%s %d", name, number(value)))
+		}
+	}
+	if len(limits) != 0 {
+		q += " WITH " + strings.Join(limits, " ")
+	}
+
+	if value, ok := attr("password_lifetime"); ok {
+		switch {
+		case !value.Valid:
+			q += " PASSWORD EXPIRE DEFAULT"
+		case number(value) == 0:
+			q += " PASSWORD EXPIRE NEVER"
+		default:
+			q += fmt.Sprintf(" PASSWORD EXPIRE INTERVAL %d DAY", number(value))
+		}
+	}
+	if value, ok := attr("Password_reuse_history"); ok {
+		if value.Valid {
+			q += fmt.Sprintf(" PASSWORD HISTORY %d", number(value))
+		} else {
+			q += " PASSWORD HISTORY DEFAULT"
+		}
+	}
+	if value, ok := attr("Password_reuse_time"); ok {
+		if value.Valid {
+			q += fmt.Sprintf(" PASSWORD REUSE INTERVAL %d DAY", number(value))
+		} else {
+			q += " PASSWORD REUSE INTERVAL DEFAULT"
+		}
+	}
+	if value, ok := attr("Password_require_current"); ok {
+		switch {
+		case !value.Valid:
+			q += " PASSWORD REQUIRE CURRENT DEFAULT"
+		case strings.EqualFold(value.String, "Y"):
+			q += " PASSWORD REQUIRE CURRENT"
+		default:
+			q += " PASSWORD REQUIRE CURRENT OPTIONAL"
+		}
+	}
+
+	var after []Query
+	if _, ok := attr("User_attributes"); ok {
+		currentAttrs, err := parseUserAttributes(current)
+		if err != nil {
+			return Query{}, err
+		}
+		expectedAttrs, err := parseUserAttributes(expected)
+		if err != nil {
+			return Query{}, err
+		}
+		// The failed-login tracking is reset if it is not set on the other node.
+		switch {
+		case expectedAttrs.PasswordLocking != nil:
+			lockTime := fmt.Sprintf("%d", expectedAttrs.PasswordLocking.PasswordLockTimeDays)
+			if expectedAttrs.PasswordLocking.PasswordLockTimeDays < 0 {
+				lockTime = "UNBOUNDED"
+			}
+			q += fmt.Sprintf(" FAILED_LOGIN_ATTEMPTS %d PASSWORD_LOCK_TIME %s", expectedAttrs.PasswordLocking.FailedLoginAttempts, lockTime)
+		case currentAttrs.PasswordLocking != nil:
+			q += " FAILED_LOGIN_ATTEMPTS 0 PASSWORD_LOCK_TIME 0"
+		}
+
+		switch {
+		case expectedAttrs.AdditionalPassword != nil:
+			if currentAttrs.AdditionalPassword == nil || *currentAttrs.AdditionalPassword != *expectedAttrs.AdditionalPassword {
+				return Query{}, fmt.Errorf("the secondary password of %s@%s can not be copied by ALTER USER", user, host)
+			}
+		case currentAttrs.AdditionalPassword != nil:
+			after = append(after, NewQuery("ALTER USER ?@? DISCARD OLD PASSWORD;", user, host))
+		}
+
+		// ATTRIBUTE merges the metadata, the keys missing on the other node are removed by null.
+		metadata := map[string]interface{}{}
+		for key := range currentAttrs.Metadata {
+			metadata[key] = nil
+		}
+		for key, value := range expectedAttrs.Metadata {
+			metadata[key] = value
+		}
+		if len(metadata) != 0 {
+			data, err := json.Marshal(metadata)
+			if err != nil {
+				return Query{}, err
+			}
+			after = append(after, NewQuery("ALTER USER ?@? ATTRIBUTE ?;", user, host, string(data)))
+		}
+	}
+
+	if value, ok := attr("account_locked"); ok {
+		if strings.EqualFold(value.String, "Y") {
+			q += " ACCOUNT LOCK"
+		} else {
+			q += " ACCOUNT UNLOCK"
+		}
+	}
+	// The password is expired after it is set, since setting it clears the expiration.
+	if value, ok := attr("password_expired"); ok && strings.EqualFold(value.String, "Y") {
+		after = append(after, NewQuery("ALTER USER ?@? PASSWORD EXPIRE;", user, host))
+	}
+
+	return ConcatenateQueries(append([]Query{NewQuery(q+";", args...)}, after...)...), nil
+}
+
+// ExecWithoutBinlog runs the query on a replica without writing the binlog, so that no errant
+// transaction is generated. The super_read_only of the followers, the read-only nodes and the
+// standby nodes is disabled for the repair and restored after it, even if the repair failed.
+// The read_only is kept enabled, so that only the users with SUPER can write meanwhile.
+func ExecWithoutBinlog(sqlRunner SQLRunner, query Query) (err error) {
+	superReadOnly, err := CheckSuperReadOnly(sqlRunner)
+	if err != nil {
+		return fmt.Errorf("failed to get super_read_only, err: %s", err)
+	}
+	if superReadOnly != corev1.ConditionTrue {
+		// The statements run in one session, sql_log_bin only affects the current session.
+		return sqlRunner.QueryExec(buildWithoutBinlogQuery(query))
+	}
+
+	defer func() {
+		// super_read_only is a global variable, it is restored on any connection.
+		if rErr := SetGlobalVariable(sqlRunner, "super_read_only", "ON"); rErr != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to restore super_read_only, err: %s", rErr)
+			} else {
+				err = fmt.Errorf("%s, failed to restore super_read_only, err: %s", err, rErr)
+			}
+		}
+	}()
+	return sqlRunner.QueryExec(buildWithoutBinlogQuery(
+		ConcatenateQueries(NewQuery("SET GLOBAL super_read_only = OFF"), query)))
+}
+
+func buildWithoutBinlogQuery(query Query) Query {
+	return ConcatenateQueries(NewQuery("SET SESSION sql_log_bin = 0"), query, NewQuery("SET SESSION sql_log_bin = 1"))
+}
+
 func permissionsToQuery(permissions []apiv1alpha1.UserPermission, user string, allowedHosts []string, withGrant bool) Query {
 	permQueries := []Query{}
 
@@ -516,3 +929,70 @@ func CheckSuperReadOnly(sqlRunner SQLRunner) (corev1.ConditionStatus, error) {
 
 	return corev1.ConditionTrue, nil
 }
+
+// GetMasterHost returns the Master_Host of the replication channel, empty if the node is not a slave.
+func GetMasterHost(sqlRunner SQLRunner) (string, error) {
+	values, err := GetSlaveStatusValues(sqlRunner, "Master_Host")
+	if err != nil {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// GetMasterAddress returns the Master_Host and Master_Port of the replication channel,
+// empty if the node is not a slave.
+func GetMasterAddress(sqlRunner SQLRunner) (string, int32, error) {
+	values, err := GetSlaveStatusValues(sqlRunner, "Master_Host", "Master_Port")
+	if err != nil || len(values[0]) == 0 {
+		return "", 0, err
+	}
+	port, err := strconv.ParseInt(values[1], 10, 32)
+	if err != nil {
+		return "", 0, err
+	}
+	return values[0], int32(port), nil
+}
+
+// GetSlaveStatusValues returns the values of the columns of show slave status in order,
+// they are empty if the node is not a slave.
+func GetSlaveStatusValues(sqlRunner SQLRunner, colNames ...string) ([]string, error) {
+	values := make([]string, len(colNames))
+	rows, err := sqlRunner.QueryRows(NewQuery("show slave status;"))
+	if err != nil {
+		return values, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return values, rows.Err()
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return values, err
+	}
+
+	scanArgs := make([]interface{}, len(cols))
+	for i := range scanArgs {
+		scanArgs[i] = &sql.RawBytes{}
+	}
+
+	if err = rows.Scan(scanArgs...); err != nil {
+		return values, err
+	}
+
+	for i, name := range colNames {
+		values[i] = columnValue(scanArgs, cols, name)
+	}
+	return values, nil
+}
+
+// GetSlaveSQLDelay returns the SQL_Delay of the replication channel, 0 if the node is not a slave.
+func GetSlaveSQLDelay(sqlRunner SQLRunner) (int, error) {
+	values, err := GetSlaveStatusValues(sqlRunner, "SQL_Delay")
+	if err != nil || len(values[0]) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(values[0])
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+)
+
+func TestBuildUserManagementSQL(t *testing.T) {
+	user := &apiv1alpha1.MysqlUser{
+		Spec: apiv1alpha1.UserSpec{
+			User:       "test",
+			Hosts:      []string{"%"},
+			TLSOptions: apiv1alpha1.TLSOptions{Type: "NONE"},
+		},
+	}
+	// default options.
+	{
+		query, err := BuildUserManagementSQL(user, "pwd", "5.7.34-37")
+		assert.NoError(t, err)
+		assert.Equal(t, "BEGIN;\n"+
+			"CREATE USER IF NOT EXISTS ?@? IDENTIFIED BY ? REQUIRE NONE WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 "+
+			"MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 0 PASSWORD EXPIRE DEFAULT ACCOUNT UNLOCK;\n"+
+			"ALTER USER ?@? IDENTIFIED BY ? WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 "+
+			"MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 0 PASSWORD EXPIRE DEFAULT ACCOUNT UNLOCK;\n"+
+			"COMMIT;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "pwd", "test", "%", "pwd"}, query.Args())
+	}
+	// resource limits, password options and account locking.
+	{
+		expireDays := int32(90)
+		user := user.DeepCopy()
+		user.Spec.ResourceLimits = &apiv1alpha1.UserResourceLimits{
+			MaxQueriesPerHour:  1000,
+			MaxUserConnections: 10,
+		}
+		user.Spec.PasswordOptions = &apiv1alpha1.UserPasswordOptions{
+			ExpireDays:          &expireDays,
+			FailedLoginAttempts: 3,
+			PasswordLockDays:    -1,
+		}
+		user.Spec.AccountLocked = true
+
+		options, err := getUserOptions(&user.Spec, "8.0.19")
+		assert.NoError(t, err)
+		assert.Equal(t, " WITH MAX_QUERIES_PER_HOUR 1000 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 10"+
+			" PASSWORD EXPIRE INTERVAL 90 DAY FAILED_LOGIN_ATTEMPTS 3 PASSWORD_LOCK_TIME UNBOUNDED ACCOUNT LOCK", options)
+
+		// the failed-login tracking is not supported before 8.0.19.
+		for _, version := range []string{"5.7.34-37", "8.0.18-9"} {
+			_, err = BuildUserManagementSQL(user, "pwd", version)
+			assert.Error(t, err, version)
+		}
+		user.Spec.PasswordOptions.FailedLoginAttempts = 0
+		user.Spec.PasswordOptions.PasswordLockDays = 0
+		options, err = getUserOptions(&user.Spec, "8.0.18-9")
+		assert.NoError(t, err)
+		assert.NotContains(t, options, "FAILED_LOGIN_ATTEMPTS")
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	assert.True(t, versionAtLeast("8.0.19", 8, 0, 19))
+	assert.True(t, versionAtLeast("8.0.25-15", 8, 0, 19))
+	assert.True(t, versionAtLeast("8.1.0", 8, 0, 19))
+	assert.False(t, versionAtLeast("8.0.18-9", 8, 0, 19))
+	assert.False(t, versionAtLeast("5.7.34-37-log", 8, 0, 19))
+}
+
+func TestBuildUserAuthenticationQuery(t *testing.T) {
+	// the mysql_native_password hash is printable.
+	{
+		query, err := BuildUserAuthenticationQuery("test", "%", UserAuthentication{}, UserAuthentication{
+			Plugin:     "mysql_native_password",
+			AuthString: "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ALTER USER ?@? IDENTIFIED WITH ? AS ?;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "mysql_native_password", "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29"}, query.Args())
+	}
+	// the caching_sha2_password hash contains binary characters.
+	{
+		query, err := BuildUserAuthenticationQuery("test", "%", UserAuthentication{}, UserAuthentication{
+			Plugin:     "caching_sha2_password",
+			AuthString: "$A$005$\x01\x7f",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ALTER USER ?@? IDENTIFIED WITH ? AS 0x24412430303524017f;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "caching_sha2_password"}, query.Args())
+	}
+	// the other account columns are set by ALTER USER.
+	{
+		query, err := BuildUserAuthenticationQuery("test", "%", UserAuthentication{}, UserAuthentication{
+			Plugin:     "mysql_native_password",
+			AuthString: "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+			Attributes: map[string]sql.NullString{
+				"ssl_type":                 {String: "SPECIFIED", Valid: true},
+				"ssl_cipher":               {String: "", Valid: true},
+				"x509_issuer":              {String: "/CN=ca", Valid: true},
+				"x509_subject":             {String: "/CN=test", Valid: true},
+				"max_questions":            {String: "10", Valid: true},
+				"max_updates":              {String: "0", Valid: true},
+				"max_connections":          {String: "0", Valid: true},
+				"max_user_connections":     {String: "5", Valid: true},
+				"password_expired":         {String: "Y", Valid: true},
+				"password_lifetime":        {},
+				"account_locked":           {String: "N", Valid: true},
+				"Password_reuse_history":   {String: "3", Valid: true},
+				"Password_reuse_time":      {},
+				"Password_require_current": {String: "N", Valid: true},
+				"User_attributes": {String: `{"Password_locking": {"failed_login_attempts": 3, "password_lock_time_days": -1}, ` +
+					`"metadata": {"team": "app"}}`, Valid: true},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ALTER USER ?@? IDENTIFIED WITH ? AS ? REQUIRE ISSUER ? AND SUBJECT ?"+
+			" WITH MAX_QUERIES_PER_HOUR 10 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 5"+
+			" PASSWORD EXPIRE DEFAULT PASSWORD HISTORY 3 PASSWORD REUSE INTERVAL DEFAULT PASSWORD REQUIRE CURRENT OPTIONAL"+
+			" FAILED_LOGIN_ATTEMPTS 3 PASSWORD_LOCK_TIME UNBOUNDED ACCOUNT UNLOCK;\n"+
+			"ALTER USER ?@? ATTRIBUTE ?;\n"+
+			"ALTER USER ?@? PASSWORD EXPIRE;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "mysql_native_password", "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+			"/CN=ca", "/CN=test", "test", "%", `{"team":"app"}`, "test", "%"}, query.Args())
+	}
+	// the secondary password and the metadata missing on the leader are removed.
+	{
+		query, err := BuildUserAuthenticationQuery("test", "%", UserAuthentication{
+			Attributes: map[string]sql.NullString{
+				"User_attributes": {String: `{"additional_password": "*6BB4837EB74329105EE4568DDA7DC67ED2CA2AD9", ` +
+					`"Password_locking": {"failed_login_attempts": 3, "password_lock_time_days": 1}, "metadata": {"team": "app"}}`, Valid: true},
+			},
+		}, UserAuthentication{
+			Plugin:     "mysql_native_password",
+			AuthString: "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+			Attributes: map[string]sql.NullString{
+				"ssl_type":        {String: "ANY", Valid: true},
+				"User_attributes": {},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ALTER USER ?@? IDENTIFIED WITH ? AS ? REQUIRE SSL FAILED_LOGIN_ATTEMPTS 0 PASSWORD_LOCK_TIME 0;\n"+
+			"ALTER USER ?@? DISCARD OLD PASSWORD;\n"+
+			"ALTER USER ?@? ATTRIBUTE ?;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "mysql_native_password", "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+			"test", "%", "test", "%", `{"team":null}`}, query.Args())
+	}
+	// the secondary password can not be copied by ALTER USER.
+	{
+		_, err := BuildUserAuthenticationQuery("test", "%", UserAuthentication{}, UserAuthentication{
+			Plugin:     "mysql_native_password",
+			AuthString: "*94BDCEBE19083CE2A1F959FD02F964C7AF4CFC29",
+			Attributes: map[string]sql.NullString{
+				"User_attributes": {String: `{"additional_password": "*6BB4837EB74329105EE4568DDA7DC67ED2CA2AD9"}`, Valid: true},
+			},
+		})
+		assert.Error(t, err)
+	}
+	// the repair is not written into the binlog.
+	{
+		query := buildWithoutBinlogQuery(NewQuery("DROP USER IF EXISTS ?@?;", "test", "%"))
+		assert.Equal(t, "SET SESSION sql_log_bin = 0;\nDROP USER IF EXISTS ?@?;\nSET SESSION sql_log_bin = 1;", query.String())
+	}
+}
+
+func TestExecWithoutBinlog(t *testing.T) {
+	const (
+		selectSuperReadOnly = "select @@global.super_read_only;"
+		repair              = "SET SESSION sql_log_bin = 0;\nSET GLOBAL super_read_only = OFF;\nDROP USER IF EXISTS test@%;\nSET SESSION sql_log_bin = 1;"
+		restore             = "SET GLOBAL super_read_only = ON;"
+	)
+	superReadOnly := &sqltest.Result{Columns: []string{"super_read_only"}, Rows: [][]driver.Value{{int64(1)}}}
+	// super_read_only is disabled for the repair and restored after it.
+	{
+		server := &sqltest.Server{}
+		server.SetResult(selectSuperReadOnly, superReadOnly)
+		err := ExecWithoutBinlog(NewSQLRunnerFromDB(sqltest.NewDB(t, server)), NewQuery("DROP USER IF EXISTS ?@?;", "test", "%"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{selectSuperReadOnly, repair, restore}, server.Stmts())
+	}
+	// super_read_only is restored even if the repair failed.
+	{
+		server := &sqltest.Server{Errs: map[string]error{repair: errors.New("lock wait timeout")}}
+		server.SetResult(selectSuperReadOnly, superReadOnly)
+		err := ExecWithoutBinlog(NewSQLRunnerFromDB(sqltest.NewDB(t, server)), NewQuery("DROP USER IF EXISTS ?@?;", "test", "%"))
+		assert.Error(t, err)
+		assert.Equal(t, []string{selectSuperReadOnly, repair, restore}, server.Stmts())
+	}
+	// super_read_only is left alone if it is disabled.
+	{
+		server := &sqltest.Server{}
+		server.SetResult(selectSuperReadOnly, &sqltest.Result{Columns: []string{"super_read_only"}, Rows: [][]driver.Value{{int64(0)}}})
+		err := ExecWithoutBinlog(NewSQLRunnerFromDB(sqltest.NewDB(t, server)), NewQuery("DROP USER IF EXISTS ?@?;", "test", "%"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{selectSuperReadOnly,
+			"SET SESSION sql_log_bin = 0;\nDROP USER IF EXISTS test@%;\nSET SESSION sql_log_bin = 1;"}, server.Stmts())
+	}
+}
+
+func TestGetUserAuthentications(t *testing.T) {
+	server := &sqltest.Server{}
+	server.SetResult("SELECT host, plugin, authentication_string, ssl_type, ssl_cipher, x509_issuer, x509_subject, "+
+		"max_questions, max_updates, max_connections, max_user_connections, password_expired, password_lifetime, "+
+		"account_locked, Password_reuse_history, Password_reuse_time, Password_require_current, User_attributes "+
+		"FROM mysql.user WHERE user = test;", &sqltest.Result{
+		Columns: []string{"host", "plugin", "authentication_string", "ssl_type", "ssl_cipher", "x509_issuer", "x509_subject",
+			"max_questions", "max_updates", "max_connections", "max_user_connections", "password_expired", "password_lifetime",
+			"account_locked", "Password_reuse_history", "Password_reuse_time", "Password_require_current", "User_attributes"},
+		Rows: [][]driver.Value{{"%", "caching_sha2_password", "$A$005$new", "", "", "", "", "0", "0", "0", "0", "N", nil,
+			"N", nil, nil, nil, `{"additional_password": "$A$005$old"}`}},
+	})
+	sqlRunner := NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+
+	auths, err := GetUserAuthentications(sqlRunner, "test", "8.0")
+	assert.NoError(t, err)
+	auth := auths["%"]
+	assert.Equal(t, "$A$005$new", auth.AuthString)
+	// the secondary password is copied with the account.
+	assert.Equal(t, sql.NullString{String: `{"additional_password": "$A$005$old"}`, Valid: true}, auth.Attributes["User_attributes"])
+	assert.Equal(t, sql.NullString{}, auth.Attributes["password_lifetime"])
+
+	other := UserAuthentication{Plugin: auth.Plugin, AuthString: auth.AuthString, Attributes: map[string]sql.NullString{}}
+	for column, value := range auth.Attributes {
+		other.Attributes[column] = value
+	}
+	assert.True(t, auth.Equal(other))
+	other.Attributes["User_attributes"] = sql.NullString{}
+	assert.False(t, auth.Equal(other))
+}
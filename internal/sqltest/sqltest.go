@@ -0,0 +1,212 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sqltest provides a fake database/sql driver for the tests of the code that
+// talks to MySQL. The fake server records the statements and answers them with the
+// preset results or errors.
+package sqltest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Result is the result set of a query.
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+// Server records the statements and answers them with the preset results or errors.
+// The arguments are inlined into the statements without quoting, the statements are
+// matched exactly and the unknown ones return an empty result.
+type Server struct {
+	mu      sync.Mutex
+	stmts   []string
+	Results map[string]*Result
+	Errs    map[string]error
+	// OnExec is called after a statement is executed without error, it can be used to
+	// change the results as the server would.
+	OnExec func(s *Server, stmt string)
+}
+
+// Stmts returns the executed statements in order.
+func (s *Server) Stmts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.stmts...)
+}
+
+// SetResult sets the result of the query.
+func (s *Server) SetResult(query string, result *Result) {
+	if s.Results == nil {
+		s.Results = map[string]*Result{}
+	}
+	s.Results[query] = result
+}
+
+func (s *Server) do(query string, args []driver.Value) (*Result, error) {
+	stmt := inline(query, args)
+	s.mu.Lock()
+	s.stmts = append(s.stmts, stmt)
+	result, err := s.Results[stmt], s.Errs[stmt]
+	onExec := s.OnExec
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if onExec != nil {
+		onExec(s, stmt)
+	}
+	if result == nil {
+		return &Result{}, nil
+	}
+	return result, nil
+}
+
+// inline replaces the placeholders of the query with the arguments.
+func inline(query string, args []driver.Value) string {
+	var b strings.Builder
+	for _, c := range query {
+		if c == '?' && len(args) > 0 {
+			if v, ok := args[0].([]byte); ok {
+				b.Write(v)
+			} else {
+				fmt.Fprintf(&b, "%v", args[0])
+			}
+			args = args[1:]
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+var (
+	serversMu sync.Mutex
+	servers   = map[string]*Server{}
+	serverID  int
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	server, ok := servers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown server %s", name)
+	}
+	return &fakeConn{server: server}, nil
+}
+
+type fakeConn struct {
+	server *Server
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{server: c.server, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	server *Server
+	query  string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if _, err := s.server.do(s.query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	result, err := s.server.do(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: result.Columns, rows: result.Rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.columns) != 0 {
+		return r.columns
+	}
+	if len(r.rows) == 0 {
+		return []string{"c0"}
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("sqltest", fakeDriver{})
+}
+
+// NewDB returns a database connected to the fake server, it is closed with the test.
+func NewDB(t testing.TB, server *Server) *sql.DB {
+	serversMu.Lock()
+	serverID++
+	name := fmt.Sprintf("%s-%d", t.Name(), serverID)
+	servers[name] = server
+	serversMu.Unlock()
+	db, err := sql.Open("sqltest", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		serversMu.Lock()
+		delete(servers, name)
+		serversMu.Unlock()
+	})
+	return db
+}
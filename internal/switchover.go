@@ -0,0 +1,41 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+)
+
+// GetGtidExecuted returns the executed GTID set of the node.
+func GetGtidExecuted(sqlRunner SQLRunner) (string, error) {
+	var gtid string
+	if err := sqlRunner.QueryRow(NewQuery("SELECT @@global.gtid_executed"), &gtid); err != nil {
+		return "", fmt.Errorf("failed to get gtid_executed, err: %s", err)
+	}
+
+	return gtid, nil
+}
+
+// IsGtidSubset returns whether all the GTIDs in subset are also in set.
+func IsGtidSubset(sqlRunner SQLRunner, subset, set string) (bool, error) {
+	var isSubset int
+	if err := sqlRunner.QueryRow(NewQuery("SELECT GTID_SUBSET(?, ?)", subset, set), &isSubset); err != nil {
+		return false, fmt.Errorf("failed to compare the gtid sets, err: %s", err)
+	}
+
+	return isSubset == 1, nil
+}
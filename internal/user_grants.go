@@ -0,0 +1,363 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// RoleHost is the host of the roles created by the operator.
+const RoleHost = "%"
+
+const (
+	allPrivileges = "ALL PRIVILEGES"
+	grantOption   = "GRANT OPTION"
+	usage         = "USAGE"
+)
+
+// grantRegexp matches the privilege grants of SHOW GRANTS, the role grants and the proxy
+// grants have no ON clause, such as "GRANT `role`@`%` TO `user`@`%`".
+var grantRegexp = regexp.MustCompile("^GRANT (.+) ON (.+) TO .+?( WITH GRANT OPTION)?$")
+
+var (
+	// roleGrantRegexp matches the role grants of SHOW GRANTS.
+	roleGrantRegexp = regexp.MustCompile("^GRANT (.+) TO .+?( WITH ADMIN OPTION)?$")
+	// roleRegexp matches the role in the role grants, such as `role`@`%`.
+	roleRegexp = regexp.MustCompile("`([^`]+)`@`([^`]*)`")
+)
+
+// allPrivilegesImplies is used to check whether the ALL PRIVILEGES is granted, MySQL 8.0 lists
+// the static privileges instead of ALL PRIVILEGES on *.*.
+var allPrivilegesImplies = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "ALTER", "INDEX"}
+
+// UserGrants is the privileges of the user on each object, such as `db`.`table`.
+type UserGrants map[string]map[string]bool
+
+func (g UserGrants) add(object string, privileges ...string) {
+	if _, ok := g[object]; !ok {
+		g[object] = map[string]bool{}
+	}
+	for _, priv := range privileges {
+		g[object][priv] = true
+	}
+}
+
+// ShowGrants returns the result of SHOW GRANTS for user@host.
+func ShowGrants(sqlRunner SQLRunner, user, host string) ([]string, error) {
+	rows, err := sqlRunner.QueryRows(NewQuery("SHOW GRANTS FOR ?@?", user, host))
+	if err != nil {
+		return nil, fmt.Errorf("failed to show grants, err: %s", err)
+	}
+	defer rows.Close()
+
+	stmts := []string{}
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stmts, nil
+}
+
+// ParseGrants parses the result of SHOW GRANTS, the USAGE privilege is skipped.
+func ParseGrants(stmts []string) UserGrants {
+	grants := UserGrants{}
+	for _, stmt := range stmts {
+		m := grantRegexp.FindStringSubmatch(strings.TrimSpace(stmt))
+		if m == nil {
+			continue
+		}
+		privileges := []string{}
+		for _, priv := range splitPrivileges(m[1]) {
+			if priv = normalizePrivilege(priv); priv != "" && priv != usage && priv != "PROXY" {
+				privileges = append(privileges, priv)
+			}
+		}
+		if len(m[3]) != 0 {
+			privileges = append(privileges, grantOption)
+		}
+		if len(privileges) != 0 {
+			grants.add(m[2], privileges...)
+		}
+	}
+	return grants
+}
+
+// ParseGrantedRoles parses the roles granted to the user in the result of SHOW GRANTS,
+// such as "GRANT `r1`@`%`,`r2`@`%` TO `user`@`%`".
+func ParseGrantedRoles(stmts []string) []string {
+	roles := []string{}
+	for _, stmt := range stmts {
+		m := roleGrantRegexp.FindStringSubmatch(strings.TrimSpace(stmt))
+		if m == nil || grantRegexp.MatchString(strings.TrimSpace(stmt)) {
+			continue
+		}
+		for _, role := range roleRegexp.FindAllStringSubmatch(m[1], -1) {
+			if role[2] == RoleHost {
+				roles = append(roles, role[1])
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// GetDesiredGrants returns the privileges of the user in the spec.
+func GetDesiredGrants(permissions []apiv1alpha1.UserPermission, withGrant bool) UserGrants {
+	grants := UserGrants{}
+	for _, perm := range permissions {
+		privileges := []string{}
+		for _, privs := range perm.Privileges {
+			for _, priv := range splitPrivileges(privs) {
+				if priv = normalizePrivilege(Escape(priv)); priv != "" && priv != usage {
+					privileges = append(privileges, priv)
+				}
+			}
+		}
+		if withGrant {
+			privileges = append(privileges, grantOption)
+		}
+		for _, table := range perm.Tables {
+			grants.add(fmt.Sprintf("%s.%s", escapeID(perm.Database), escapeID(table)), privileges...)
+		}
+	}
+	return grants
+}
+
+// DiffGrants returns the drifts between the privileges in MySQL and the desired privileges,
+// sorted by the objects.
+func DiffGrants(host string, desired, current UserGrants) []apiv1alpha1.GrantDrift {
+	objects := []string{}
+	for object := range desired {
+		objects = append(objects, object)
+	}
+	for object := range current {
+		if _, ok := desired[object]; !ok {
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+
+	drifts := []apiv1alpha1.GrantDrift{}
+	for _, object := range objects {
+		want, have := desired[object], current[object]
+		drift := apiv1alpha1.GrantDrift{Host: host, Object: object}
+		if want[allPrivileges] {
+			// ALL PRIVILEGES contains all the privileges except GRANT OPTION.
+			if !have[allPrivileges] && !containsAll(have, allPrivilegesImplies) {
+				drift.Missing = append(drift.Missing, allPrivileges)
+			}
+			if want[grantOption] && !have[grantOption] {
+				drift.Missing = append(drift.Missing, grantOption)
+			}
+			if !want[grantOption] && have[grantOption] {
+				drift.Unexpected = append(drift.Unexpected, grantOption)
+			}
+		} else {
+			drift.Missing = sortedDiff(want, have)
+			drift.Unexpected = sortedDiff(have, want)
+		}
+		if len(drift.Missing) != 0 || len(drift.Unexpected) != 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// BuildGrantDriftsQuery returns the query which revokes the unexpected privileges and grants
+// the missing privileges.
+func BuildGrantDriftsQuery(user string, drifts []apiv1alpha1.GrantDrift) Query {
+	queries := []Query{}
+	for _, drift := range drifts {
+		if len(drift.Unexpected) != 0 {
+			queries = append(queries, NewQuery(fmt.Sprintf("REVOKE %s ON %s FROM ?@?",
+				strings.Join(drift.Unexpected, ", "), drift.Object), user, drift.Host))
+		}
+		if len(drift.Missing) != 0 {
+			privileges := []string{}
+			withGrant := false
+			for _, priv := range drift.Missing {
+				if priv == grantOption {
+					withGrant = true
+					continue
+				}
+				privileges = append(privileges, priv)
+			}
+			if len(privileges) == 0 {
+				privileges = append(privileges, usage)
+			}
+			query := fmt.Sprintf("GRANT %s ON %s TO ?@?", strings.Join(privileges, ", "), drift.Object)
+			if withGrant {
+				query += " WITH GRANT OPTION"
+			}
+			queries = append(queries, NewQuery(query, user, drift.Host))
+		}
+	}
+	return ConcatenateQueries(queries...)
+}
+
+// splitPrivileges splits the privileges by the commas outside the column list,
+// such as "SELECT (`a`, `b`), INSERT".
+func splitPrivileges(privileges string) []string {
+	result := []string{}
+	depth, start := 0, 0
+	for i, c := range privileges {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, privileges[start:i])
+				start = i + 1
+			}
+		}
+	}
+	result = append(result, privileges[start:])
+	return result
+}
+
+// normalizePrivilege uppercases the privilege keyword, the column list keeps the case of
+// the identifiers and is formatted as SHOW GRANTS does, such as "SELECT (`a`, `B`)".
+func normalizePrivilege(priv string) string {
+	var columns string
+	if i := strings.Index(priv, "("); i >= 0 {
+		priv, columns = priv[:i], strings.TrimSuffix(strings.TrimSpace(priv[i+1:]), ")")
+	}
+	priv = strings.ToUpper(strings.Join(strings.Fields(priv), " "))
+	if len(columns) == 0 {
+		if priv == "ALL" {
+			return allPrivileges
+		}
+		return priv
+	}
+	ids := []string{}
+	for _, column := range strings.Split(columns, ",") {
+		ids = append(ids, escapeID(strings.TrimSpace(column)))
+	}
+	return fmt.Sprintf("%s (%s)", priv, strings.Join(ids, ", "))
+}
+
+// sortedDiff returns the sorted privileges in a but not in b.
+func sortedDiff(a, b map[string]bool) []string {
+	diff := []string{}
+	for priv := range a {
+		if !b[priv] {
+			diff = append(diff, priv)
+		}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func containsAll(set map[string]bool, privileges []string) bool {
+	for _, priv := range privileges {
+		if !set[priv] {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateRole creates the role if it does not exist, the roles are supported since MySQL 8.0.
+func CreateRole(sqlRunner SQLRunner, role string) error {
+	query := NewQuery("CREATE ROLE IF NOT EXISTS ?@?;", role, RoleHost)
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to create role, err: %s", err)
+	}
+
+	return nil
+}
+
+// DropRole removes the role if it exists, the role is revoked from the users.
+func DropRole(sqlRunner SQLRunner, role string) error {
+	query := NewQuery("DROP ROLE IF EXISTS ?@?;", role, RoleHost)
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to delete role, err: %s", err)
+	}
+
+	return nil
+}
+
+// BuildRolesQuery returns the query which grants the missing roles to user@host and revokes
+// the unexpected roles.
+func BuildRolesQuery(user, host string, missing, unexpected []string) Query {
+	queries := []Query{}
+	for _, role := range unexpected {
+		queries = append(queries, NewQuery("REVOKE ?@? FROM ?@?", role, RoleHost, user, host))
+	}
+	for _, role := range missing {
+		queries = append(queries, NewQuery("GRANT ?@? TO ?@?", role, RoleHost, user, host))
+	}
+	return ConcatenateQueries(queries...)
+}
+
+// GetDefaultRoles returns the sorted default roles of user@host.
+func GetDefaultRoles(sqlRunner SQLRunner, user, host string) ([]string, error) {
+	rows, err := sqlRunner.QueryRows(NewQuery(
+		"SELECT DEFAULT_ROLE_USER FROM mysql.default_roles WHERE USER = ? AND HOST = ? AND DEFAULT_ROLE_HOST = ? ORDER BY DEFAULT_ROLE_USER",
+		user, host, RoleHost))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default roles, err: %s", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// SetDefaultRoles sets the roles activated when user@host connects.
+func SetDefaultRoles(sqlRunner SQLRunner, user, host string, roles []string) error {
+	query := NewQuery("SET DEFAULT ROLE NONE TO ?@?", user, host)
+	if len(roles) != 0 {
+		ids, args := []string{}, []interface{}{}
+		for _, role := range roles {
+			ids = append(ids, "?@?")
+			args = append(args, role, RoleHost)
+		}
+		query = NewQuery(fmt.Sprintf("SET DEFAULT ROLE %s TO ?@?", strings.Join(ids, ", ")), append(args, user, host)...)
+	}
+
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to set default roles, err: %s", err)
+	}
+
+	return nil
+}
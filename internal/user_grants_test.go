@@ -0,0 +1,116 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func TestParseGrants(t *testing.T) {
+	grants := ParseGrants([]string{
+		"GRANT USAGE ON *.* TO 'test'@'%'",
+		"GRANT SELECT, INSERT ON `db1`.* TO 'test'@'%' WITH GRANT OPTION",
+		"GRANT SELECT (`a`, `Col_B`), UPDATE ON `db2`.`t1` TO `test`@`%`",
+		"GRANT `role1`@`%` TO `test`@`%`",
+	})
+	assert.Equal(t, UserGrants{
+		"`db1`.*":    {"SELECT": true, "INSERT": true, "GRANT OPTION": true},
+		"`db2`.`t1`": {"SELECT (`a`, `Col_B`)": true, "UPDATE": true},
+	}, grants)
+}
+
+func TestNormalizePrivilege(t *testing.T) {
+	assert.Equal(t, "SELECT", normalizePrivilege(" select "))
+	assert.Equal(t, "ALL PRIVILEGES", normalizePrivilege("all"))
+	assert.Equal(t, "LOCK TABLES", normalizePrivilege("lock  tables"))
+	assert.Equal(t, "SELECT (`a`, `Col_B`)", normalizePrivilege("select (a,Col_B)"))
+	assert.Equal(t, "UPDATE (`Col_A`)", normalizePrivilege("update(`Col_A`)"))
+}
+
+func TestDiffGrants(t *testing.T) {
+	permissions := []apiv1alpha1.UserPermission{
+		{
+			Database:   "db1",
+			Tables:     []string{"*"},
+			Privileges: []string{"select", "DELETE"},
+		},
+		{
+			Database:   "db3",
+			Tables:     []string{"t1"},
+			Privileges: []string{"ALL"},
+		},
+	}
+	current := ParseGrants([]string{
+		"GRANT SELECT, INSERT ON `db1`.* TO 'test'@'%' WITH GRANT OPTION",
+		"GRANT SELECT ON `db2`.* TO 'test'@'%'",
+		"GRANT ALL PRIVILEGES ON `db3`.`t1` TO 'test'@'%'",
+	})
+
+	// revoke the privileges and the databases removed from the spec.
+	{
+		drifts := DiffGrants("%", GetDesiredGrants(permissions, false), current)
+		assert.Equal(t, []apiv1alpha1.GrantDrift{
+			{Host: "%", Object: "`db1`.*", Missing: []string{"DELETE"}, Unexpected: []string{"GRANT OPTION", "INSERT"}},
+			{Host: "%", Object: "`db2`.*", Unexpected: []string{"SELECT"}},
+		}, drifts)
+
+		query := BuildGrantDriftsQuery("test", drifts)
+		assert.Equal(t, "REVOKE GRANT OPTION, INSERT ON `db1`.* FROM ?@?;\n"+
+			"GRANT DELETE ON `db1`.* TO ?@?;\n"+
+			"REVOKE SELECT ON `db2`.* FROM ?@?;", query.String())
+		assert.Equal(t, []interface{}{"test", "%", "test", "%", "test", "%"}, query.Args())
+	}
+	// grant the missing grant option.
+	{
+		drifts := DiffGrants("%", GetDesiredGrants(permissions[1:], true), current)
+		assert.Equal(t, []apiv1alpha1.GrantDrift{
+			{Host: "%", Object: "`db1`.*", Unexpected: []string{"GRANT OPTION", "INSERT", "SELECT"}},
+			{Host: "%", Object: "`db2`.*", Unexpected: []string{"SELECT"}},
+			{Host: "%", Object: "`db3`.`t1`", Missing: []string{"GRANT OPTION"}},
+		}, drifts)
+
+		query := BuildGrantDriftsQuery("test", drifts[2:])
+		assert.Equal(t, "GRANT USAGE ON `db3`.`t1` TO ?@? WITH GRANT OPTION;", query.String())
+	}
+	// no drift.
+	{
+		drifts := DiffGrants("%", GetDesiredGrants(permissions, false), ParseGrants([]string{
+			"GRANT USAGE ON *.* TO 'test'@'%'",
+			"GRANT SELECT, DELETE ON `db1`.* TO 'test'@'%'",
+			"GRANT ALL PRIVILEGES ON `db3`.`t1` TO 'test'@'%'",
+		}))
+		assert.Empty(t, drifts)
+	}
+}
+
+func TestParseGrantedRoles(t *testing.T) {
+	roles := ParseGrantedRoles([]string{
+		"GRANT USAGE ON *.* TO `test`@`%`",
+		"GRANT SELECT ON `db1`.* TO `test`@`%`",
+		"GRANT `readonly`@`%`,`app_rw`@`%`,`other`@`localhost` TO `test`@`%`",
+		"GRANT `migrator`@`%` TO `test`@`%` WITH ADMIN OPTION",
+	})
+	assert.Equal(t, []string{"app_rw", "migrator", "readonly"}, roles)
+
+	query := BuildRolesQuery("test", "%", []string{"app_rw"}, []string{"readonly"})
+	assert.Equal(t, "REVOKE ?@? FROM ?@?;\nGRANT ?@? TO ?@?;", query.String())
+	assert.Equal(t, []interface{}{"readonly", "%", "test", "%", "app_rw", "%", "test", "%"}, query.Args())
+}
@@ -75,6 +75,8 @@ func (c *backupSidecar) getEnvVars() []corev1.EnvVar {
 			getEnvVarFromSecret(sctNameBakup, "S3_ACCESSKEY", "s3-access-key", true),
 			getEnvVarFromSecret(sctNameBakup, "S3_SECRETKEY", "s3-secret-key", true),
 			getEnvVarFromSecret(sctNameBakup, "S3_BUCKET", "s3-bucket", true),
+			// the key to encrypt the backups, the restore decrypts with the same item.
+			getEnvVarFromSecret(sctNameBakup, "BACKUP_ENCRYPT_KEY", "encrypt-key", true),
 		)
 	}
 	return envs
@@ -101,8 +103,9 @@ func (c *backupSidecar) getLivenessProbe() *corev1.Probe {
 	return &corev1.Probe{
 		ProbeHandler: corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/health",
-				Port: intstr.FromInt(utils.XBackupPort),
+				Path:   "/health",
+				Port:   intstr.FromInt(utils.XBackupPort),
+				Scheme: c.getScheme(),
 			},
 		},
 		InitialDelaySeconds: 15,
@@ -117,8 +120,9 @@ func (c *backupSidecar) getReadinessProbe() *corev1.Probe {
 	return &corev1.Probe{
 		ProbeHandler: corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/health",
-				Port: intstr.FromInt(utils.XBackupPort),
+				Path:   "/health",
+				Port:   intstr.FromInt(utils.XBackupPort),
+				Scheme: c.getScheme(),
 			},
 		},
 		InitialDelaySeconds: 5,
@@ -129,8 +133,16 @@ func (c *backupSidecar) getReadinessProbe() *corev1.Probe {
 	}
 }
 
+// getScheme returns HTTPS if the backup server serves over TLS.
+func (c *backupSidecar) getScheme() corev1.URIScheme {
+	if len(c.GetTLSSecretName()) != 0 {
+		return corev1.URISchemeHTTPS
+	}
+	return corev1.URISchemeHTTP
+}
+
 func (c *backupSidecar) getVolumeMounts() []corev1.VolumeMount {
-	return []corev1.VolumeMount{
+	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      utils.MysqlConfVolumeName,
 			MountPath: utils.MysqlConfVolumeMountPath,
@@ -148,4 +160,12 @@ func (c *backupSidecar) getVolumeMounts() []corev1.VolumeMount {
 			MountPath: utils.SysLocalTimeZoneMountPath,
 		},
 	}
+	if len(c.GetTLSSecretName()) != 0 {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      utils.TlsVolumeName + "-sidecar",
+			MountPath: utils.XBackupTlsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumeMounts
 }
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// binlogArchiver used for binlog-archiver container, it ships the closed binlogs of the leader.
+type binlogArchiver struct {
+	*mysqlcluster.MysqlCluster
+
+	// The name of the binlog-archiver container.
+	name string
+}
+
+// getName get the container name.
+func (c *binlogArchiver) getName() string {
+	return c.name
+}
+
+// getImage get the container image.
+func (c *binlogArchiver) getImage() string {
+	return c.Spec.PodPolicy.SidecarImage
+}
+
+// getCommand get the container command.
+func (c *binlogArchiver) getCommand() []string {
+	return []string{"sidecar", "archive"}
+}
+
+// getEnvVars get the container env.
+func (c *binlogArchiver) getEnvVars() []corev1.EnvVar {
+	sctName := c.GetNameForResource(utils.Secret)
+	envs := []corev1.EnvVar{
+		{
+			Name:  "CONTAINER_TYPE",
+			Value: utils.ContainerBinlogName,
+		},
+		{
+			Name: "POD_HOSTNAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.name",
+				},
+			},
+		},
+		{
+			Name:  "NAMESPACE",
+			Value: c.Namespace,
+		},
+		{
+			Name:  "CLUSTER_NAME",
+			Value: c.Name,
+		},
+		{
+			Name:  "ARCHIVE_INTERVAL",
+			Value: strconv.Itoa(int(c.Spec.BinlogArchive.Interval)),
+		},
+		// the operator user flushes the binary logs every interval.
+		getEnvVarFromSecret(sctName, "OPERATOR_USER", "operator-user", true),
+		getEnvVarFromSecret(sctName, "OPERATOR_PASSWORD", "operator-password", true),
+	}
+	if len(c.Spec.BinlogArchive.NFSServerAddress) != 0 {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "ARCHIVE_TYPE",
+			Value: "nfs",
+		})
+	} else {
+		s3SctName := c.Spec.BinlogArchive.S3SecretName
+		envs = append(envs,
+			corev1.EnvVar{
+				Name:  "ARCHIVE_TYPE",
+				Value: "s3",
+			},
+			getEnvVarFromSecret(s3SctName, "S3_ENDPOINT", "s3-endpoint", false),
+			getEnvVarFromSecret(s3SctName, "S3_ACCESSKEY", "s3-access-key", true),
+			getEnvVarFromSecret(s3SctName, "S3_SECRETKEY", "s3-secret-key", true),
+			getEnvVarFromSecret(s3SctName, "S3_BUCKET", "s3-bucket", true),
+		)
+	}
+	return envs
+}
+
+// getLifecycle get the container lifecycle.
+func (c *binlogArchiver) getLifecycle() *corev1.Lifecycle {
+	return nil
+}
+
+// getResources get the container resources.
+func (c *binlogArchiver) getResources() corev1.ResourceRequirements {
+	return c.Spec.PodPolicy.ExtraResources
+}
+
+// getPorts get the container ports.
+func (c *binlogArchiver) getPorts() []corev1.ContainerPort {
+	return nil
+}
+
+// getLivenessProbe get the container livenessProbe.
+func (c *binlogArchiver) getLivenessProbe() *corev1.Probe {
+	return nil
+}
+
+// getReadinessProbe get the container readinessProbe.
+func (c *binlogArchiver) getReadinessProbe() *corev1.Probe {
+	return nil
+}
+
+// getVolumeMounts get the container volumeMounts.
+func (c *binlogArchiver) getVolumeMounts() []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      utils.DataVolumeName,
+			MountPath: utils.DataVolumeMountPath,
+		},
+		{
+			Name:      utils.SysLocalTimeZone,
+			MountPath: utils.SysLocalTimeZoneMountPath,
+		},
+	}
+	if len(c.Spec.BinlogArchive.NFSServerAddress) != 0 {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      utils.BinlogArchivePV,
+			MountPath: utils.XtrabckupLocal,
+		})
+	}
+	return volumeMounts
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+var (
+	binlogMysqlCluster = mysqlv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample",
+			Namespace: "default",
+		},
+		Spec: mysqlv1alpha1.MysqlClusterSpec{
+			PodPolicy: mysqlv1alpha1.PodPolicy{
+				SidecarImage: "sidecar image",
+			},
+			BinlogArchive: &mysqlv1alpha1.BinlogArchiveOpts{
+				NFSServerAddress: "10.0.0.1",
+				Interval:         60,
+			},
+		},
+	}
+	testBinlogCluster = mysqlcluster.MysqlCluster{
+		MysqlCluster: &binlogMysqlCluster,
+	}
+	binlogCase    = EnsureContainer(utils.ContainerBinlogName, &testBinlogCluster)
+	binlogEnvVars = []corev1.EnvVar{
+		{
+			Name:  "CONTAINER_TYPE",
+			Value: utils.ContainerBinlogName,
+		},
+		{
+			Name: "POD_HOSTNAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.name",
+				},
+			},
+		},
+		{
+			Name:  "NAMESPACE",
+			Value: "default",
+		},
+		{
+			Name:  "CLUSTER_NAME",
+			Value: "sample",
+		},
+		{
+			Name:  "ARCHIVE_INTERVAL",
+			Value: "60",
+		},
+		getEnvVarFromSecret("sample-secret", "OPERATOR_USER", "operator-user", true),
+		getEnvVarFromSecret("sample-secret", "OPERATOR_PASSWORD", "operator-password", true),
+		{
+			Name:  "ARCHIVE_TYPE",
+			Value: "nfs",
+		},
+	}
+	binlogVolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      utils.DataVolumeName,
+			MountPath: utils.DataVolumeMountPath,
+		},
+		{
+			Name:      utils.SysLocalTimeZone,
+			MountPath: utils.SysLocalTimeZoneMountPath,
+		},
+		{
+			Name:      utils.BinlogArchivePV,
+			MountPath: utils.XtrabckupLocal,
+		},
+	}
+)
+
+func TestGetBinlogName(t *testing.T) {
+	assert.Equal(t, utils.ContainerBinlogName, binlogCase.Name)
+}
+
+func TestGetBinlogCommand(t *testing.T) {
+	assert.Equal(t, []string{"sidecar", "archive"}, binlogCase.Command)
+}
+
+func TestGetBinlogEnvVar(t *testing.T) {
+	// NFS
+	{
+		assert.Equal(t, binlogEnvVars, binlogCase.Env)
+	}
+	// S3
+	{
+		testCluster := binlogMysqlCluster
+		testCluster.Spec.BinlogArchive = &mysqlv1alpha1.BinlogArchiveOpts{
+			S3SecretName: "s3-secret",
+			Interval:     60,
+		}
+		binlogCase := EnsureContainer(utils.ContainerBinlogName, &mysqlcluster.MysqlCluster{MysqlCluster: &testCluster})
+		envs := make([]corev1.EnvVar, len(binlogEnvVars)-1)
+		copy(envs, binlogEnvVars)
+		envs = append(envs,
+			corev1.EnvVar{
+				Name:  "ARCHIVE_TYPE",
+				Value: "s3",
+			},
+			getEnvVarFromSecret("s3-secret", "S3_ENDPOINT", "s3-endpoint", false),
+			getEnvVarFromSecret("s3-secret", "S3_ACCESSKEY", "s3-access-key", true),
+			getEnvVarFromSecret("s3-secret", "S3_SECRETKEY", "s3-secret-key", true),
+			getEnvVarFromSecret("s3-secret", "S3_BUCKET", "s3-bucket", true),
+		)
+		assert.Equal(t, envs, binlogCase.Env)
+		assert.Equal(t, binlogVolumeMounts[:2], binlogCase.VolumeMounts)
+	}
+}
+
+func TestGetBinlogVolumeMounts(t *testing.T) {
+	assert.Equal(t, binlogVolumeMounts, binlogCase.VolumeMounts)
+}
@@ -82,6 +82,8 @@ func EnsureContainer(name string, c *mysqlcluster.MysqlCluster) corev1.Container
 		ctr = &errorLog{c, name}
 	case utils.ContainerBackupName:
 		ctr = &backupSidecar{c, name}
+	case utils.ContainerBinlogName:
+		ctr = &binlogArchiver{c, name}
 	}
 
 	return corev1.Container{
@@ -45,7 +45,12 @@ func (c *initMysql) getImage() string {
 // getCommand get the container command.
 func (c *initMysql) getCommand() []string {
 	// Because initialize mysql contain error, so do it in commands.
-	return []string{"sh", "-c", "/docker-entrypoint.sh mysqld;if test -f /docker-entrypoint-initdb.d/plugin.sh; then /docker-entrypoint-initdb.d/plugin.sh; fi "}
+	cmd := "/docker-entrypoint.sh mysqld;if test -f /docker-entrypoint-initdb.d/plugin.sh; then /docker-entrypoint-initdb.d/plugin.sh; fi "
+	if c.Spec.RestoreTarget != nil {
+		// Replay the archived binlogs after restore, pitr.sh is built by init-sidecar.
+		cmd += "&& if test -f /docker-entrypoint-initdb.d/pitr.sh; then /docker-entrypoint-initdb.d/pitr.sh; fi "
+	}
+	return []string{"sh", "-c", cmd}
 }
 
 // getEnvVars get the container env.
@@ -71,6 +76,16 @@ func (c *initMysql) getEnvVars() []corev1.EnvVar {
 		getEnvVarFromSecret(sctName, "MYSQL_ROOT_PASSWORD", "root-password", false),
 	)
 
+	if len(c.Spec.RemoteSourceSecretName) != 0 {
+		// clone.sh reads the remote mysql and its credentials from the env.
+		envs = append(envs,
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_HOST", "host", false),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_PORT", "port", true),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_USER", "user", false),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_PASSWORD", "password", false),
+		)
+	}
+
 	if c.Spec.MysqlOpts.InitTokuDB {
 		envs = append(envs, corev1.EnvVar{
 			Name:  "INIT_TOKUDB",
@@ -135,6 +135,24 @@ func TestGetInitMysqlEnvVar(t *testing.T) {
 		})
 		assert.Equal(t, testEnv, tokudbCase.Env)
 	}
+	// RemoteSourceSecretName not empty
+	{
+		testRemoteMysqlCluster := initMysqlMysqlCluster
+		testRemoteMysqlCluster.Spec.RemoteSourceSecretName = "remote-secret"
+		testRemoteCluster := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testRemoteMysqlCluster,
+		}
+		remoteCase := EnsureContainer("init-mysql", &testRemoteCluster)
+		testEnv := make([]corev1.EnvVar, len(initMysqlEnvs))
+		copy(testEnv, initMysqlEnvs)
+		testEnv = append(testEnv,
+			getEnvVarFromSecret("remote-secret", "REMOTE_HOST", "host", false),
+			getEnvVarFromSecret("remote-secret", "REMOTE_PORT", "port", true),
+			getEnvVarFromSecret("remote-secret", "REMOTE_USER", "user", false),
+			getEnvVarFromSecret("remote-secret", "REMOTE_PASSWORD", "password", false),
+		)
+		assert.Equal(t, testEnv, remoteCase.Env)
+	}
 }
 
 func TestGetInitMysqlLifecycle(t *testing.T) {
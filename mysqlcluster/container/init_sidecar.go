@@ -115,12 +115,49 @@ func (c *initSidecar) getEnvVars() []corev1.EnvVar {
 		getEnvVarFromSecret(sctName, "BACKUP_PASSWORD", "backup-password", true),
 	}
 
+	if len(c.Spec.RestoreStorage) != 0 {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "RESTORE_STORAGE",
+			Value: c.Spec.RestoreStorage,
+		})
+	}
 	if len(c.Spec.BackupSecretName) != 0 {
+		switch c.Spec.RestoreStorage {
+		case "azure":
+			envs = append(envs,
+				getEnvVarFromSecret(sctNamebackup, "AZURE_STORAGE_ACCOUNT", "azure-storage-account", false),
+				getEnvVarFromSecret(sctNamebackup, "AZURE_ACCESS_KEY", "azure-access-key", false),
+				getEnvVarFromSecret(sctNamebackup, "AZURE_CONTAINER_NAME", "azure-container-name", false),
+				getEnvVarFromSecret(sctNamebackup, "AZURE_ENDPOINT", "azure-endpoint", true),
+			)
+		case "gcs":
+			envs = append(envs,
+				getEnvVarFromSecret(sctNamebackup, "GCS_ENDPOINT", "gcs-endpoint", true),
+				getEnvVarFromSecret(sctNamebackup, "GCS_ACCESSKEY", "gcs-access-key", false),
+				getEnvVarFromSecret(sctNamebackup, "GCS_SECRETKEY", "gcs-secret-key", false),
+				getEnvVarFromSecret(sctNamebackup, "GCS_BUCKET", "gcs-bucket", false),
+			)
+		default:
+			envs = append(envs,
+				getEnvVarFromSecret(sctNamebackup, "S3_ENDPOINT", "s3-endpoint", false),
+				getEnvVarFromSecret(sctNamebackup, "S3_ACCESSKEY", "s3-access-key", true),
+				getEnvVarFromSecret(sctNamebackup, "S3_SECRETKEY", "s3-secret-key", true),
+				getEnvVarFromSecret(sctNamebackup, "S3_BUCKET", "s3-bucket", true),
+			)
+		}
+		envs = append(envs,
+			getEnvVarFromSecret(sctNamebackup, "BACKUP_ENCRYPT_KEY", "encrypt-key", true),
+		)
+	}
+	if len(c.Spec.RemoteSourceSecretName) != 0 {
+		// the remote mysql that the first pod clones from, the credentials are only
+		// passed to init-mysql.
 		envs = append(envs,
-			getEnvVarFromSecret(sctNamebackup, "S3_ENDPOINT", "s3-endpoint", false),
-			getEnvVarFromSecret(sctNamebackup, "S3_ACCESSKEY", "s3-access-key", true),
-			getEnvVarFromSecret(sctNamebackup, "S3_SECRETKEY", "s3-secret-key", true),
-			getEnvVarFromSecret(sctNamebackup, "S3_BUCKET", "s3-bucket", true),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_HOST", "host", false),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_PORT", "port", true),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_BACKUP_PORT", "backup-port", true),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_BACKUP_USER", "backup-user", true),
+			getEnvVarFromSecret(c.Spec.RemoteSourceSecretName, "REMOTE_BACKUP_PASSWORD", "backup-password", true),
 		)
 	}
 	if len(c.Spec.NFSServerAddress) != 0 {
@@ -129,6 +166,32 @@ func (c *initSidecar) getEnvVars() []corev1.EnvVar {
 			Value: c.Spec.NFSServerAddress,
 		})
 	}
+	if c.Spec.RestoreTarget != nil {
+		envs = append(envs,
+			corev1.EnvVar{
+				Name:  "RESTORE_BINLOG_CLUSTER",
+				Value: c.Spec.RestoreTarget.ClusterName,
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_TIME",
+				Value: c.Spec.RestoreTarget.Time,
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_GTID",
+				Value: c.Spec.RestoreTarget.GTID,
+			},
+		)
+		if len(c.Spec.NFSServerAddress) == 0 {
+			// the binlogs may be archived to another bucket than the backup.
+			sctNameBinlog := c.GetRestoreBinlogSecretName()
+			envs = append(envs,
+				getEnvVarFromSecret(sctNameBinlog, "BINLOG_S3_ENDPOINT", "s3-endpoint", false),
+				getEnvVarFromSecret(sctNameBinlog, "BINLOG_S3_ACCESSKEY", "s3-access-key", true),
+				getEnvVarFromSecret(sctNameBinlog, "BINLOG_S3_SECRETKEY", "s3-secret-key", true),
+				getEnvVarFromSecret(sctNameBinlog, "BINLOG_S3_BUCKET", "s3-bucket", true),
+			)
+		}
+	}
 	if c.Spec.MysqlOpts.InitTokuDB {
 		envs = append(envs, corev1.EnvVar{
 			Name:  "INIT_TOKUDB",
@@ -204,7 +267,7 @@ func (c *initSidecar) getVolumeMounts() []corev1.VolumeMount {
 			MountPath: utils.RadonDBBinDir,
 		},
 	}
-	if c.Spec.TlsSecretName != "" {
+	if c.GetTLSSecretName() != "" {
 		volumeMounts = append(volumeMounts,
 			corev1.VolumeMount{
 				Name:      utils.TlsVolumeName + "-sidecar",
@@ -213,6 +276,22 @@ func (c *initSidecar) getVolumeMounts() []corev1.VolumeMount {
 				Name:      utils.TlsVolumeName,
 				MountPath: utils.TlsMountPath,
 			},
+			// the client certificate to clone from the backup server.
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName + "-sidecar",
+				MountPath: utils.XBackupTlsMountPath,
+				ReadOnly:  true,
+			},
+		)
+	}
+	if len(c.Spec.RemoteSourceSecretName) != 0 {
+		// the client certificate to clone from the backup server of the remote source.
+		volumeMounts = append(volumeMounts,
+			corev1.VolumeMount{
+				Name:      utils.RemoteSourceVolumeName,
+				MountPath: utils.RemoteSourceTlsMountPath,
+				ReadOnly:  true,
+			},
 		)
 	}
 	if c.Spec.MysqlOpts.InitTokuDB {
@@ -224,7 +303,8 @@ func (c *initSidecar) getVolumeMounts() []corev1.VolumeMount {
 		)
 	}
 
-	if len(c.Spec.NFSServerAddress) != 0 {
+	if len(c.Spec.NFSServerAddress) != 0 || len(c.Spec.RestoreClaimName) != 0 ||
+		len(c.Spec.RestoreHostPath) != 0 {
 		volumeMounts = append(volumeMounts,
 			corev1.VolumeMount{
 				Name:      utils.XtrabackupPV,
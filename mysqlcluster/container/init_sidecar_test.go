@@ -408,9 +408,99 @@ func TestGetInitSidecarEnvVar(t *testing.T) {
 					},
 				},
 			},
+			corev1.EnvVar{
+				Name: "BACKUP_ENCRYPT_KEY",
+
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: testBackupMysqlClusterWraper.Spec.BackupSecretName,
+						},
+						Key:      "encrypt-key",
+						Optional: &optTrue,
+					},
+				},
+			},
 		)
 		assert.Equal(t, testBackupEnv, BackupCase.Env)
 	}
+	// RestoreStorage is azure
+	{
+		testAzureMysqlCluster := initSidecarMysqlCluster
+		testAzureMysqlCluster.Spec.BackupSecretName = "backup-secret"
+		testAzureMysqlCluster.Spec.RestoreStorage = "azure"
+		testAzureMysqlClusterWraper := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testAzureMysqlCluster,
+		}
+		azureCase := EnsureContainer("init-sidecar", &testAzureMysqlClusterWraper)
+		testAzureEnv := make([]corev1.EnvVar, len(defaultInitSidecarEnvs))
+		copy(testAzureEnv, defaultInitSidecarEnvs)
+		testAzureEnv = append(testAzureEnv,
+			corev1.EnvVar{
+				Name:  "RESTORE_STORAGE",
+				Value: "azure",
+			},
+			getEnvVarFromSecret("backup-secret", "AZURE_STORAGE_ACCOUNT", "azure-storage-account", false),
+			getEnvVarFromSecret("backup-secret", "AZURE_ACCESS_KEY", "azure-access-key", false),
+			getEnvVarFromSecret("backup-secret", "AZURE_CONTAINER_NAME", "azure-container-name", false),
+			getEnvVarFromSecret("backup-secret", "AZURE_ENDPOINT", "azure-endpoint", true),
+			getEnvVarFromSecret("backup-secret", "BACKUP_ENCRYPT_KEY", "encrypt-key", true),
+		)
+		assert.Equal(t, testAzureEnv, azureCase.Env)
+	}
+	// RemoteSourceSecretName not empty
+	{
+		testRemoteMysqlCluster := initSidecarMysqlCluster
+		testRemoteMysqlCluster.Spec.RemoteSourceSecretName = "remote-secret"
+		testRemoteMysqlClusterWraper := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testRemoteMysqlCluster,
+		}
+		remoteCase := EnsureContainer("init-sidecar", &testRemoteMysqlClusterWraper)
+		testRemoteEnv := make([]corev1.EnvVar, len(defaultInitSidecarEnvs))
+		copy(testRemoteEnv, defaultInitSidecarEnvs)
+		testRemoteEnv = append(testRemoteEnv,
+			getEnvVarFromSecret("remote-secret", "REMOTE_HOST", "host", false),
+			getEnvVarFromSecret("remote-secret", "REMOTE_PORT", "port", true),
+			getEnvVarFromSecret("remote-secret", "REMOTE_BACKUP_PORT", "backup-port", true),
+			getEnvVarFromSecret("remote-secret", "REMOTE_BACKUP_USER", "backup-user", true),
+			getEnvVarFromSecret("remote-secret", "REMOTE_BACKUP_PASSWORD", "backup-password", true),
+		)
+		assert.Equal(t, testRemoteEnv, remoteCase.Env)
+	}
+	// RestoreTarget not nil
+	{
+		testPitrMysqlCluster := initSidecarMysqlCluster
+		testPitrMysqlCluster.Spec.RestoreTarget = &mysqlv1alpha1.RestoreTarget{
+			ClusterName:  "sample",
+			Time:         "2022-01-01 00:00:00",
+			S3SecretName: "binlog-secret",
+		}
+		testPitrMysqlClusterWraper := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testPitrMysqlCluster,
+		}
+		pitrCase := EnsureContainer("init-sidecar", &testPitrMysqlClusterWraper)
+		testPitrEnv := make([]corev1.EnvVar, len(defaultInitSidecarEnvs))
+		copy(testPitrEnv, defaultInitSidecarEnvs)
+		testPitrEnv = append(testPitrEnv,
+			corev1.EnvVar{
+				Name:  "RESTORE_BINLOG_CLUSTER",
+				Value: "sample",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_TIME",
+				Value: "2022-01-01 00:00:00",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_GTID",
+				Value: "",
+			},
+			getEnvVarFromSecret("binlog-secret", "BINLOG_S3_ENDPOINT", "s3-endpoint", false),
+			getEnvVarFromSecret("binlog-secret", "BINLOG_S3_ACCESSKEY", "s3-access-key", true),
+			getEnvVarFromSecret("binlog-secret", "BINLOG_S3_SECRETKEY", "s3-secret-key", true),
+			getEnvVarFromSecret("binlog-secret", "BINLOG_S3_BUCKET", "s3-bucket", true),
+		)
+		assert.Equal(t, testPitrEnv, pitrCase.Env)
+	}
 }
 
 func TestGetInitSidecarLifecycle(t *testing.T) {
@@ -473,4 +563,64 @@ func TestGetInitSidecarVolumeMounts(t *testing.T) {
 		})
 		assert.Equal(t, persistenceVolumeMounts, persistenceCase.VolumeMounts)
 	}
+	// restore from pvc
+	{
+		testPVCMysqlCluster := initSidecarMysqlCluster
+		testPVCMysqlCluster.Spec.RestoreClaimName = "backup-pvc"
+		testPVCCluster := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testPVCMysqlCluster,
+		}
+		pvcCase := EnsureContainer("init-sidecar", &testPVCCluster)
+		pvcVolumeMounts := make([]corev1.VolumeMount, 9)
+		copy(pvcVolumeMounts, defaultInitsidecarVolumeMounts)
+		pvcVolumeMounts = append(pvcVolumeMounts, corev1.VolumeMount{
+			Name:      utils.XtrabackupPV,
+			MountPath: utils.XtrabckupLocal,
+		})
+		assert.Equal(t, pvcVolumeMounts, pvcCase.VolumeMounts)
+	}
+	// tls
+	{
+		testTLSMysqlCluster := initSidecarMysqlCluster
+		testTLSMysqlCluster.Spec.TlsSecretName = "tls-secret"
+		testTLSCluster := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testTLSMysqlCluster,
+		}
+		tlsCase := EnsureContainer("init-sidecar", &testTLSCluster)
+		tlsVolumeMounts := make([]corev1.VolumeMount, 9)
+		copy(tlsVolumeMounts, defaultInitsidecarVolumeMounts)
+		tlsVolumeMounts = append(tlsVolumeMounts,
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName + "-sidecar",
+				MountPath: "/tmp/mysql-ssl",
+			},
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName,
+				MountPath: utils.TlsMountPath,
+			},
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName + "-sidecar",
+				MountPath: utils.XBackupTlsMountPath,
+				ReadOnly:  true,
+			},
+		)
+		assert.Equal(t, tlsVolumeMounts, tlsCase.VolumeMounts)
+	}
+	// remote source
+	{
+		testRemoteMysqlCluster := initSidecarMysqlCluster
+		testRemoteMysqlCluster.Spec.RemoteSourceSecretName = "remote-secret"
+		testRemoteCluster := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testRemoteMysqlCluster,
+		}
+		remoteCase := EnsureContainer("init-sidecar", &testRemoteCluster)
+		remoteVolumeMounts := make([]corev1.VolumeMount, 9)
+		copy(remoteVolumeMounts, defaultInitsidecarVolumeMounts)
+		remoteVolumeMounts = append(remoteVolumeMounts, corev1.VolumeMount{
+			Name:      utils.RemoteSourceVolumeName,
+			MountPath: utils.RemoteSourceTlsMountPath,
+			ReadOnly:  true,
+		})
+		assert.Equal(t, remoteVolumeMounts, remoteCase.VolumeMounts)
+	}
 }
@@ -177,8 +177,14 @@ func (c *mysql) getVolumeMounts() []corev1.VolumeMount {
 			Name:      utils.MySQLcheckerVolumeName,
 			MountPath: utils.RadonDBBinDir,
 		},
+		// the readiness reads the marks of xenon, such as the drained leader.
+		{
+			Name:      utils.XenonMetaVolumeName,
+			MountPath: utils.XenonMetaVolumeMountPath,
+			ReadOnly:  true,
+		},
 	}
-	if c.Spec.TlsSecretName != "" {
+	if c.GetTLSSecretName() != "" {
 		volumeMounts = append(volumeMounts,
 			corev1.VolumeMount{
 				Name:      utils.TlsVolumeName,
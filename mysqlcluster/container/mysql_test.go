@@ -182,6 +182,11 @@ func TestGetMysqlVolumeMounts(t *testing.T) {
 			Name:      utils.MySQLcheckerVolumeName,
 			MountPath: "/opt/radondb",
 		},
+		{
+			Name:      utils.XenonMetaVolumeName,
+			MountPath: "/var/lib/xenon",
+			ReadOnly:  true,
+		},
 	}
 	assert.Equal(t, volumeMounts, mysqlCase.VolumeMounts)
 }
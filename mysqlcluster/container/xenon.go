@@ -47,7 +47,8 @@ func (c *xenon) getCommand() []string {
 		return []string{"xenon", "-c", "/etc/xenon/xenon.json", "-r", "LEADER"}
 	}
 	// If return nil, statefulset never update command , And I don't know why.
-	return []string{"xenon", "-c", "/etc/xenon/xenon.json"}
+	// The leader that lost the quorum is fenced in the background, see cmd/xenon.
+	return []string{"sh", "-c", "/xenonchecker fence & exec xenon -c /etc/xenon/xenon.json"}
 }
 
 // getEnvVars get the container env.
@@ -56,6 +57,10 @@ func (c *xenon) getEnvVars() []corev1.EnvVar {
 	if c.Spec.XenonOpts.EnableAutoRebuild {
 		autoRebuild = "true"
 	}
+	standby := "false"
+	if c.IsStandby() {
+		standby = "true"
+	}
 	return []corev1.EnvVar{
 		{
 			Name: "NAMESPACE",
@@ -79,6 +84,10 @@ func (c *xenon) getEnvVars() []corev1.EnvVar {
 			Name:  "AUTO_REBUILD",
 			Value: autoRebuild,
 		},
+		{
+			Name:  "STANDBY",
+			Value: standby,
+		},
 	}
 }
 
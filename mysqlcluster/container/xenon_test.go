@@ -64,7 +64,7 @@ func TestGetXenonImage(t *testing.T) {
 }
 
 func TestGetXenonCommand(t *testing.T) {
-	assert.Equal(t, []string{"xenon", "-c", "/etc/xenon/xenon.json"}, xenonCase.Command)
+	assert.Equal(t, []string{"sh", "-c", "/xenonchecker fence & exec xenon -c /etc/xenon/xenon.json"}, xenonCase.Command)
 }
 
 func TestGetXenonEnvVar(t *testing.T) {
@@ -91,6 +91,10 @@ func TestGetXenonEnvVar(t *testing.T) {
 			Name:  "AUTO_REBUILD",
 			Value: "false",
 		},
+		{
+			Name:  "STANDBY",
+			Value: "false",
+		},
 	}, xenonCase.Env)
 }
 
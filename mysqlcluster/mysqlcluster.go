@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	corev1 "k8s.io/api/core/v1"
@@ -48,6 +50,9 @@ const (
 	gb
 )
 
+// gtidSetRegexp matches the gtid set, such as uuid:1-100,uuid:1-5:7.
+var gtidSetRegexp = regexp.MustCompile(`^[0-9a-fA-F\-]+(:[0-9]+(-[0-9]+)?)+(,\s*[0-9a-fA-F\-]+(:[0-9]+(-[0-9]+)?)+)*$`)
+
 // MysqlCluster is the wrapper for apiv1alpha1.MysqlCluster type.
 type MysqlCluster struct {
 	*apiv1alpha1.MysqlCluster
@@ -71,6 +76,37 @@ func (c *MysqlCluster) Validate() error {
 	if utils.StringInArray(c.Spec.MysqlOpts.User, []string{"root", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser}) {
 		return fmt.Errorf("spec.mysqlOpts.user cannot be root|%s|%s|%s", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser)
 	}
+	if c.Spec.Standby != nil && c.Spec.Standby.Enabled {
+		if len(c.Spec.Standby.ClusterName) == 0 && len(c.Spec.Standby.Host) == 0 {
+			return fmt.Errorf("spec.standby.clusterName or spec.standby.host must be set when standby is enabled")
+		}
+		if c.Spec.Standby.ClusterName == c.Name {
+			return fmt.Errorf("spec.standby.clusterName cannot be the cluster itself")
+		}
+	}
+	if c.Spec.BinlogArchive != nil &&
+		len(c.Spec.BinlogArchive.S3SecretName) == 0 && len(c.Spec.BinlogArchive.NFSServerAddress) == 0 {
+		return fmt.Errorf("spec.binlogArchive.s3SecretName or spec.binlogArchive.nfsServerAddress must be set")
+	}
+	if c.Spec.RestoreTarget != nil {
+		if len(c.Spec.RestoreFrom) == 0 {
+			return fmt.Errorf("spec.restoreFrom must be set when spec.restoreTarget is set")
+		}
+		if (len(c.Spec.RestoreTarget.Time) == 0) == (len(c.Spec.RestoreTarget.GTID) == 0) {
+			return fmt.Errorf("one of spec.restoreTarget.time and spec.restoreTarget.gtid must be set")
+		}
+		if len(c.Spec.RestoreTarget.Time) != 0 {
+			if _, err := time.Parse("2006-01-02 15:04:05", c.Spec.RestoreTarget.Time); err != nil {
+				return fmt.Errorf("spec.restoreTarget.time is invalid: %s", err)
+			}
+		}
+		if len(c.Spec.RestoreTarget.GTID) != 0 && !gtidSetRegexp.MatchString(c.Spec.RestoreTarget.GTID) {
+			return fmt.Errorf("spec.restoreTarget.gtid is invalid: %s", c.Spec.RestoreTarget.GTID)
+		}
+		if len(c.Spec.NFSServerAddress) == 0 && len(c.GetRestoreBinlogSecretName()) == 0 {
+			return fmt.Errorf("spec.restoreTarget.s3SecretName or spec.binlogArchive.s3SecretName must be set to replay the binlogs from S3")
+		}
+	}
 	// MySQL8 nerver support TokuDB
 	// https://www.percona.com/blog/2021/05/21/tokudb-support-changes-and-future-removal-from-percona-server-for-mysql-8-0/
 	if strings.Contains(c.Spec.MysqlOpts.Image, "8.0") && c.Spec.MysqlOpts.InitTokuDB {
@@ -248,7 +284,8 @@ func (c *MysqlCluster) EnsureVolumes() []corev1.Volume {
 		},
 	)
 	// add the nfs volumn mount
-	if len(c.Spec.NFSServerAddress) != 0 {
+	switch {
+	case len(c.Spec.NFSServerAddress) != 0:
 		ip, path := utils.ParseIPAndPath(c.Spec.NFSServerAddress)
 		volumes = append(volumes, corev1.Volume{
 			Name: utils.XtrabackupPV,
@@ -259,15 +296,56 @@ func (c *MysqlCluster) EnsureVolumes() []corev1.Volume {
 				},
 			},
 		})
+	// add the pvc or the host path where cluster restore from
+	case len(c.Spec.RestoreClaimName) != 0:
+		volumes = append(volumes, corev1.Volume{
+			Name: utils.XtrabackupPV,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: c.Spec.RestoreClaimName,
+				},
+			},
+		})
+	case len(c.Spec.RestoreHostPath) != 0:
+		volumes = append(volumes, corev1.Volume{
+			Name: utils.XtrabackupPV,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: c.Spec.RestoreHostPath,
+				},
+			},
+		})
+	}
+	// add the nfs volume for binlog archive
+	if c.Spec.BinlogArchive != nil && len(c.Spec.BinlogArchive.NFSServerAddress) != 0 {
+		ip, path := utils.ParseIPAndPath(c.Spec.BinlogArchive.NFSServerAddress)
+		volumes = append(volumes, corev1.Volume{
+			Name: utils.BinlogArchivePV,
+			VolumeSource: corev1.VolumeSource{
+				NFS: &corev1.NFSVolumeSource{
+					Server: ip,
+					Path:   path,
+				},
+			},
+		})
 	}
 	// Add the ssl secret mounts.
-	if len(c.Spec.TlsSecretName) != 0 {
+	if len(c.GetTLSSecretName()) != 0 {
+		tlsSecret := &corev1.SecretVolumeSource{
+			SecretName: c.GetTLSSecretName(),
+		}
+		// The private key of the CA generated by the operator is not mounted.
+		if len(c.Spec.TlsSecretName) == 0 {
+			tlsSecret.Items = []corev1.KeyToPath{
+				{Key: utils.TLSCAKey, Path: utils.TLSCAKey},
+				{Key: utils.TLSCertKey, Path: utils.TLSCertKey},
+				{Key: utils.TLSPKey, Path: utils.TLSPKey},
+			}
+		}
 		volumes = append(volumes, corev1.Volume{
 			Name: utils.TlsVolumeName + "-sidecar",
 			VolumeSource: corev1.VolumeSource{
-				Secret: &corev1.SecretVolumeSource{
-					SecretName: c.Spec.TlsSecretName,
-				},
+				Secret: tlsSecret,
 			},
 		}, corev1.Volume{
 			Name: utils.TlsVolumeName,
@@ -277,6 +355,26 @@ func (c *MysqlCluster) EnsureVolumes() []corev1.Volume {
 		})
 	}
 
+	// Add the certificates of the remote source backup server, they are only required
+	// to clone MySQL 5.7.
+	if len(c.Spec.RemoteSourceSecretName) != 0 {
+		optional := true
+		volumes = append(volumes, corev1.Volume{
+			Name: utils.RemoteSourceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: c.Spec.RemoteSourceSecretName,
+					Items: []corev1.KeyToPath{
+						{Key: utils.TLSCAKey, Path: utils.TLSCAKey},
+						{Key: utils.TLSCertKey, Path: utils.TLSCertKey},
+						{Key: utils.TLSPKey, Path: utils.TLSPKey},
+					},
+					Optional: &optional,
+				},
+			},
+		})
+	}
+
 	// Add mysql checker volume
 	volumes = append(volumes, corev1.Volume{
 		Name: utils.MySQLcheckerVolumeName,
@@ -338,6 +436,8 @@ func (c *MysqlCluster) GetNameForResource(name utils.ResourceName) string {
 		return fmt.Sprintf("%s-secret", c.Name)
 	case utils.XenonMetaData:
 		return fmt.Sprintf("%s-xenon", c.Name)
+	case utils.TLSSecret:
+		return fmt.Sprintf("%s-tls", c.Name)
 	case utils.ConfigMap:
 		if template := c.Spec.MysqlOpts.MysqlConfTemplate; template != "" {
 			return template
@@ -352,6 +452,18 @@ func (c *MysqlCluster) GetNameForResource(name utils.ResourceName) string {
 	}
 }
 
+// GetTLSSecretName returns the name of the secret that contains the certificates, it is
+// empty if TLS is disabled.
+func (c *MysqlCluster) GetTLSSecretName() string {
+	if len(c.Spec.TlsSecretName) != 0 {
+		return c.Spec.TlsSecretName
+	}
+	if c.Spec.AutoTLS {
+		return c.GetNameForResource(utils.TLSSecret)
+	}
+	return ""
+}
+
 // EnsureMysqlConf set the mysql default configs.
 func (c *MysqlCluster) EnsureMysqlConf() {
 	if len(c.Spec.MysqlOpts.MysqlConf) == 0 {
@@ -470,6 +582,68 @@ func IsClusterKind(kind string) bool {
 	return false
 }
 
+// IsStandby returns true if the cluster replicates from another source as a standby.
+// The cluster bootstrapped from a remote mysql is a standby of it until the remote source
+// is removed, unless the standby is set.
+func (c *MysqlCluster) IsStandby() bool {
+	if c.Spec.Standby != nil {
+		return c.Spec.Standby.Enabled
+	}
+	return len(c.Spec.RemoteSourceSecretName) != 0
+}
+
+// GetStandbySource returns the address of the source that the standby cluster follows.
+// The leader service of ClusterName is preferred, otherwise Host and Port are used. The
+// remote source is read from its secret by the caller.
+func (c *MysqlCluster) GetStandbySource() (string, int32) {
+	if len(c.Spec.Standby.ClusterName) != 0 {
+		return fmt.Sprintf("%s-leader.%s", c.Spec.Standby.ClusterName, c.Namespace), utils.MysqlPort
+	}
+	if c.Spec.Standby.Port != nil {
+		return c.Spec.Standby.Host, *c.Spec.Standby.Port
+	}
+	return c.Spec.Standby.Host, utils.MysqlPort
+}
+
+// GetRestoreBinlogSecretName returns the secret of the S3 storage where the binlogs to
+// replay were archived, it falls back to the secret of the binlog archive.
+func (c *MysqlCluster) GetRestoreBinlogSecretName() string {
+	if len(c.Spec.RestoreTarget.S3SecretName) != 0 {
+		return c.Spec.RestoreTarget.S3SecretName
+	}
+	if c.Spec.BinlogArchive != nil {
+		return c.Spec.BinlogArchive.S3SecretName
+	}
+	return ""
+}
+
+// GetReplicationMode returns the replication mode in effect and the number of the acks the
+// leader waits for. The cluster without followers is async, and the acks are limited to the
+// number of the followers.
+func (c *MysqlCluster) GetReplicationMode() (apiv1alpha1.ReplicationMode, int32) {
+	if c.Spec.ReplicationMode == apiv1alpha1.ReplicationModeAsync ||
+		c.Spec.Replicas == nil || *c.Spec.Replicas <= 1 {
+		return apiv1alpha1.ReplicationModeAsync, 0
+	}
+	acks := c.Spec.SemiSyncOpts.WaitForSlaveCount
+	if acks < 1 {
+		acks = 1
+	}
+	if acks > *c.Spec.Replicas-1 {
+		acks = *c.Spec.Replicas - 1
+	}
+	return apiv1alpha1.ReplicationModeSemiSync, acks
+}
+
+// GetSemiSyncTimeout returns the rpl_semi_sync_master_timeout in milliseconds. The leader
+// never degrades to async if the degrade timeout is not set.
+func (c *MysqlCluster) GetSemiSyncTimeout() string {
+	if c.Spec.SemiSyncOpts.DegradeTimeout == nil {
+		return utils.SemiSyncNeverTimeout
+	}
+	return strconv.FormatInt(c.Spec.SemiSyncOpts.DegradeTimeout.Milliseconds(), 10)
+}
+
 // GetClusterKey returns the MysqlUser's MySQLCluster key.
 func (c *MysqlCluster) GetClusterKey() client.ObjectKey {
 	return client.ObjectKey{
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
@@ -373,6 +374,33 @@ func TestEnsureVolumes(t *testing.T) {
 		}
 		assert.Equal(t, volume, testCase.EnsureVolumes())
 	}
+	// bootstrapped from a remote mysql
+	{
+		testMysql := mysqlCluster
+		testMysql.Spec.Persistence.Enabled = true
+		testMysql.Spec.RemoteSourceSecretName = "remote-secret"
+		testCase := MysqlCluster{
+			MysqlCluster: &testMysql, log: logf.Log.WithName("mysqlcluster"),
+		}
+		optional := true
+		want := make([]corev1.Volume, len(volume)-1)
+		copy(want, volume)
+		want = append(want, corev1.Volume{
+			Name: utils.RemoteSourceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "remote-secret",
+					Items: []corev1.KeyToPath{
+						{Key: utils.TLSCAKey, Path: utils.TLSCAKey},
+						{Key: utils.TLSCertKey, Path: utils.TLSCertKey},
+						{Key: utils.TLSPKey, Path: utils.TLSPKey},
+					},
+					Optional: &optional,
+				},
+			},
+		}, volume[len(volume)-1])
+		assert.Equal(t, want, testCase.EnsureVolumes())
+	}
 }
 
 func TestEnsureVolumeClaimTemplates(t *testing.T) {
@@ -509,6 +537,113 @@ func TestGetNameForResource(t *testing.T) {
 	}
 }
 
+func TestGetStandbySource(t *testing.T) {
+	// standby is nil.
+	{
+		assert.Equal(t, false, testCluster.IsStandby())
+	}
+	// follow the leader of another cluster.
+	{
+		testMysqlCluster := mysqlCluster
+		testMysqlCluster.Namespace = "default"
+		testMysqlCluster.Spec.Standby = &mysqlv1alpha1.MySQLStandbySpec{
+			Enabled:     true,
+			ClusterName: "source",
+		}
+		testCase := MysqlCluster{
+			MysqlCluster: &testMysqlCluster,
+			log:          logf.Log.WithName("mysqlcluster"),
+		}
+		host, port := testCase.GetStandbySource()
+		assert.Equal(t, true, testCase.IsStandby())
+		assert.Equal(t, "source-leader.default", host)
+		assert.Equal(t, int32(utils.MysqlPort), port)
+	}
+	// follow an external host.
+	{
+		var sourcePort int32 = 3307
+		testMysqlCluster := mysqlCluster
+		testMysqlCluster.Spec.Standby = &mysqlv1alpha1.MySQLStandbySpec{
+			Enabled: false,
+			Host:    "192.168.0.2",
+			Port:    &sourcePort,
+		}
+		testCase := MysqlCluster{
+			MysqlCluster: &testMysqlCluster,
+			log:          logf.Log.WithName("mysqlcluster"),
+		}
+		host, port := testCase.GetStandbySource()
+		assert.Equal(t, false, testCase.IsStandby())
+		assert.Equal(t, "192.168.0.2", host)
+		assert.Equal(t, sourcePort, port)
+	}
+	// bootstrapped from a remote mysql.
+	{
+		testMysqlCluster := mysqlCluster
+		testMysqlCluster.Spec.RemoteSourceSecretName = "remote-secret"
+		testCase := New(&testMysqlCluster)
+		assert.Equal(t, true, testCase.IsStandby())
+		// the standby spec wins.
+		testMysqlCluster.Spec.Standby = &mysqlv1alpha1.MySQLStandbySpec{Enabled: false}
+		assert.Equal(t, false, testCase.IsStandby())
+	}
+}
+
+func TestGetRestoreBinlogSecretName(t *testing.T) {
+	testMysqlCluster := mysqlCluster
+	testMysqlCluster.Spec.RestoreFrom = "backup"
+	testMysqlCluster.Spec.RestoreTarget = &mysqlv1alpha1.RestoreTarget{
+		ClusterName: "source",
+		GTID:        "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+	}
+	testCase := New(&testMysqlCluster)
+	assert.Equal(t, "", testCase.GetRestoreBinlogSecretName())
+	assert.Error(t, testCase.Validate())
+	// the secret of the binlog archive is the default.
+	testMysqlCluster.Spec.BinlogArchive = &mysqlv1alpha1.BinlogArchiveOpts{S3SecretName: "archive-secret"}
+	assert.Equal(t, "archive-secret", testCase.GetRestoreBinlogSecretName())
+	assert.NoError(t, testCase.Validate())
+	testMysqlCluster.Spec.RestoreTarget.S3SecretName = "binlog-secret"
+	assert.Equal(t, "binlog-secret", testCase.GetRestoreBinlogSecretName())
+}
+
+func TestGetReplicationMode(t *testing.T) {
+	newCluster := func(replicas int32, mode mysqlv1alpha1.ReplicationMode, acks int32) *MysqlCluster {
+		testMysqlCluster := mysqlCluster
+		testMysqlCluster.Spec.Replicas = &replicas
+		testMysqlCluster.Spec.ReplicationMode = mode
+		testMysqlCluster.Spec.SemiSyncOpts = mysqlv1alpha1.SemiSyncOpts{WaitForSlaveCount: acks}
+		return New(&testMysqlCluster)
+	}
+	testCases := []struct {
+		cluster *MysqlCluster
+		mode    mysqlv1alpha1.ReplicationMode
+		acks    int32
+	}{
+		{newCluster(3, mysqlv1alpha1.ReplicationModeSemiSync, 1), mysqlv1alpha1.ReplicationModeSemiSync, 1},
+		{newCluster(3, "", 0), mysqlv1alpha1.ReplicationModeSemiSync, 1},
+		{newCluster(5, mysqlv1alpha1.ReplicationModeSemiSync, 2), mysqlv1alpha1.ReplicationModeSemiSync, 2},
+		// the acks are limited to the number of the followers.
+		{newCluster(3, mysqlv1alpha1.ReplicationModeSemiSync, 3), mysqlv1alpha1.ReplicationModeSemiSync, 2},
+		// the cluster without followers is async.
+		{newCluster(1, mysqlv1alpha1.ReplicationModeSemiSync, 1), mysqlv1alpha1.ReplicationModeAsync, 0},
+		{newCluster(3, mysqlv1alpha1.ReplicationModeAsync, 1), mysqlv1alpha1.ReplicationModeAsync, 0},
+	}
+	for _, tc := range testCases {
+		mode, acks := tc.cluster.GetReplicationMode()
+		assert.Equal(t, tc.mode, mode)
+		assert.Equal(t, tc.acks, acks)
+	}
+}
+
+func TestGetSemiSyncTimeout(t *testing.T) {
+	assert.Equal(t, utils.SemiSyncNeverTimeout, testCluster.GetSemiSyncTimeout())
+
+	testMysqlCluster := mysqlCluster
+	testMysqlCluster.Spec.SemiSyncOpts.DegradeTimeout = &metav1.Duration{Duration: 1500 * time.Millisecond}
+	assert.Equal(t, "1500", New(&testMysqlCluster).GetSemiSyncTimeout())
+}
+
 func TestEnsureMysqlConf(t *testing.T) {
 	var (
 		gb                     int64 = 1 << 30
@@ -0,0 +1,267 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+var (
+	// variableNameRegexp matches the valid name of the global variable.
+	variableNameRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+	// sizeValueRegexp matches the value with the size suffix, such as 16M.
+	sizeValueRegexp = regexp.MustCompile(`^([0-9]+)([kKmMgG]?)$`)
+)
+
+// isDynamicConfig returns true if the config can be applied online by SET GLOBAL, the
+// static configs are applied by restarting the nodes.
+func isDynamicConfig(version, key string) bool {
+	if _, ok := mysqlStaticConfigs[barskey(key)]; ok {
+		return false
+	}
+	if _, ok := mysqlStaticConfigs[underscorekey(key)]; ok {
+		return false
+	}
+	return apiv1alpha1.IsDynamicMysqlVariable(version, key)
+}
+
+// getMysqlMajorVersion returns the major version of mysql of the cluster.
+func getMysqlMajorVersion(c *mysqlcluster.MysqlCluster) string {
+	return apiv1alpha1.GetMysqlMajorVersion(c.Spec.MysqlVersion, c.Spec.MysqlOpts.Image)
+}
+
+// GetConfigRev returns the revision of the static configs in the configmap, the pods are
+// rolling updated when it changes. The dynamic configs are skipped, they are applied online
+// by the StatusSyncer. It returns the resourceVersion if the configs cannot be parsed.
+func (s *mysqlCMSyncer) GetConfigRev() string {
+	if rev, ok := s.cm.Annotations[utils.AnnotationConfigRev]; ok {
+		return rev
+	}
+	return s.cm.ResourceVersion
+}
+
+// syncConfigRev records the revision of the static configs in the annotations of the configmap,
+// the revision is the hash of the static configs. The configmap synced by the older operator
+// keeps its resourceVersion, which was the revision, until the static configs change, so that
+// upgrading the operator does not restart the pods. prev is the data before the sync.
+func (s *mysqlCMSyncer) syncConfigRev(prev map[string]string) {
+	version := getMysqlMajorVersion(s.MysqlCluster)
+	if s.cm.Annotations == nil {
+		s.cm.Annotations = map[string]string{}
+	}
+	if _, ok := s.cm.Annotations[utils.AnnotationStaticConfigsHash]; !ok && len(s.cm.ResourceVersion) != 0 {
+		if hash, err := staticConfigRev(version, prev); err == nil {
+			s.cm.Annotations[utils.AnnotationConfigRev] = s.cm.ResourceVersion
+			s.cm.Annotations[utils.AnnotationStaticConfigsHash] = hash
+		}
+	}
+
+	hash, err := staticConfigRev(version, s.cm.Data)
+	if err != nil {
+		s.log.Error(err, "failed to get the revision of the static configs")
+		delete(s.cm.Annotations, utils.AnnotationConfigRev)
+		delete(s.cm.Annotations, utils.AnnotationStaticConfigsHash)
+		return
+	}
+	if s.cm.Annotations[utils.AnnotationStaticConfigsHash] != hash {
+		s.cm.Annotations[utils.AnnotationConfigRev] = hash
+		s.cm.Annotations[utils.AnnotationStaticConfigsHash] = hash
+	}
+}
+
+// staticConfigRev returns the hash of the configs without the dynamic configs and the
+// semi-sync configs.
+func staticConfigRev(version string, data map[string]string) (string, error) {
+	files := []string{}
+	for file := range data {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "[%s]\n", file)
+		if file != "my.cnf" && file != utils.PluginConfigs {
+			fmt.Fprintln(hash, data[file])
+			continue
+		}
+		cfg, err := ini.LoadSources(iniLoadOptions, []byte(data[file]))
+		if err != nil {
+			return "", fmt.Errorf("failed to load %s, err: %s", file, err)
+		}
+		for _, sec := range cfg.Sections() {
+			for _, key := range sec.Keys() {
+				if sec.Name() == "mysqld" && (isDynamicConfig(version, key.Name()) || isSemiSyncConfig(key.Name())) {
+					continue
+				}
+				for _, value := range key.ValueWithShadows() {
+					fmt.Fprintf(hash, "%s.%s=%s\n", sec.Name(), key.Name(), value)
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:16], nil
+}
+
+// getDynamicConfigs returns the dynamic configs in the configmap, the keys are the names of the variables.
+func getDynamicConfigs(version string, data map[string]string) (map[string]string, error) {
+	configs := map[string]string{}
+	for _, file := range []string{"my.cnf", utils.PluginConfigs} {
+		if _, ok := data[file]; !ok {
+			continue
+		}
+		cfg, err := ini.LoadSources(iniLoadOptions, []byte(data[file]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s, err: %s", file, err)
+		}
+		for _, key := range cfg.Section("mysqld").Keys() {
+			if isDynamicConfig(version, key.Name()) {
+				configs[underscorekey(key.Name())] = key.Value()
+			}
+		}
+	}
+	return configs, nil
+}
+
+// parseConfigValue converts the value in my.cnf to the value of SET GLOBAL, the size
+// suffix is converted to bytes.
+func parseConfigValue(value string) interface{} {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	if m := sizeValueRegexp.FindStringSubmatch(value); m != nil {
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return value
+		}
+		switch strings.ToUpper(m[2]) {
+		case "K":
+			n <<= 10
+		case "M":
+			n <<= 20
+		case "G":
+			n <<= 30
+		}
+		return n
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// configValueEqual checks whether the current value of the variable equals the expected value.
+func configValueEqual(expected interface{}, current string) bool {
+	switch v := expected.(type) {
+	case int64:
+		f, err := strconv.ParseFloat(current, 64)
+		return err == nil && f == float64(v)
+	case float64:
+		f, err := strconv.ParseFloat(current, 64)
+		return err == nil && f == v
+	}
+	normalize := func(s string) string {
+		switch s = strings.ToUpper(s); s {
+		case "ON", "TRUE":
+			return "1"
+		case "OFF", "FALSE":
+			return "0"
+		}
+		return s
+	}
+	return normalize(fmt.Sprintf("%v", expected)) == normalize(current)
+}
+
+// alignBufferPoolSize rounds the innodb_buffer_pool_size up to the multiple of
+// innodb_buffer_pool_chunk_size * innodb_buffer_pool_instances as mysqld does, so that it
+// equals the current value once applied.
+func alignBufferPoolSize(sqlRunner internal.SQLRunner, size int64) (int64, error) {
+	var chunkSize, instances int64
+	query := internal.NewQuery("SELECT @@global.innodb_buffer_pool_chunk_size, @@global.innodb_buffer_pool_instances")
+	if err := sqlRunner.QueryRow(query, &chunkSize, &instances); err != nil {
+		return 0, err
+	}
+	unit := chunkSize * instances
+	if unit <= 0 {
+		return size, nil
+	}
+	return (size + unit - 1) / unit * unit, nil
+}
+
+// applyDynamicConfigs applies the dynamic configs of the configmap on the node by SET GLOBAL,
+// and checks whether the node needs to restart to apply the static configs.
+func (s *StatusSyncer) applyDynamicConfigs(sqlRunner internal.SQLRunner, pod *corev1.Pod) *apiv1alpha1.NodeConfigStatus {
+	status := &apiv1alpha1.NodeConfigStatus{
+		RestartRequired: len(s.configRev) != 0 && pod.Annotations["config_rev"] != s.configRev,
+	}
+	configs := s.dynamicConfigs
+	names := []string{}
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !variableNameRegexp.MatchString(name) {
+			status.Pending = append(status.Pending, name)
+			continue
+		}
+		value := parseConfigValue(configs[name])
+		if size, ok := value.(int64); ok && name == "innodb_buffer_pool_size" {
+			aligned, err := alignBufferPoolSize(sqlRunner, size)
+			if err != nil {
+				s.log.V(1).Info("failed to align the buffer pool size", "pod", pod.Name, "error", err)
+				status.Pending = append(status.Pending, name)
+				continue
+			}
+			value = aligned
+		}
+		var current sql.NullString
+		if err := sqlRunner.QueryRow(internal.NewQuery(fmt.Sprintf("SELECT @@global.%s", name)), &current); err != nil {
+			s.log.V(1).Info("failed to get the global variable", "pod", pod.Name, "name", name, "error", err)
+			status.Pending = append(status.Pending, name)
+			continue
+		}
+		if !configValueEqual(value, current.String) {
+			if err := internal.SetGlobalVariable(sqlRunner, name, value); err != nil {
+				s.log.V(1).Info("failed to set the global variable", "pod", pod.Name, "name", name, "error", err)
+				status.Pending = append(status.Pending, name)
+				continue
+			}
+			s.log.Info("set the global variable", "pod", pod.Name, "name", name, "value", value)
+		}
+		status.Applied = append(status.Applied, name)
+	}
+
+	status.State = apiv1alpha1.NodeConfigApplied
+	if status.RestartRequired || len(status.Pending) != 0 {
+		status.State = apiv1alpha1.NodeConfigPending
+	}
+	return status
+}
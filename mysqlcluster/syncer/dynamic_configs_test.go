@@ -0,0 +1,167 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestStaticConfigRev(t *testing.T) {
+	data := map[string]string{
+		"my.cnf":     "[mysqld]\nmax_connections = 1024\nback_log = 2048\n",
+		"plugin.cnf": "[mysqld]\naudit_log_rotations = 6\n",
+	}
+	rev, err := staticConfigRev("5.7", data)
+	assert.NoError(t, err)
+
+	// dynamic configs changed.
+	{
+		data := map[string]string{
+			"my.cnf":     "[mysqld]\nmax_connections = 2048\nback_log = 2048\nmax-allowed-packet = 16M\n",
+			"plugin.cnf": "[mysqld]\naudit_log_rotations = 10\n",
+		}
+		got, err := staticConfigRev("5.7", data)
+		assert.NoError(t, err)
+		assert.Equal(t, rev, got)
+	}
+	// static configs changed.
+	{
+		data := map[string]string{
+			"my.cnf":     "[mysqld]\nmax_connections = 1024\nback_log = 4096\n",
+			"plugin.cnf": "[mysqld]\naudit_log_rotations = 6\n",
+		}
+		got, err := staticConfigRev("5.7", data)
+		assert.NoError(t, err)
+		assert.NotEqual(t, rev, got)
+	}
+	// semi-sync configs changed.
+	{
+		data := map[string]string{
+			"my.cnf":     "[mysqld]\nmax_connections = 1024\nback_log = 2048\n",
+			"plugin.cnf": "[mysqld]\naudit_log_rotations = 6\nrpl_semi_sync_master_wait_for_slave_count = 2\n",
+		}
+		got, err := staticConfigRev("5.7", data)
+		assert.NoError(t, err)
+		assert.Equal(t, rev, got)
+	}
+	// unknown configs are static.
+	{
+		data := map[string]string{
+			"my.cnf":     "[mysqld]\nmax_connections = 1024\nback_log = 2048\ninnodb_buffer_pool_instances = 8\n",
+			"plugin.cnf": "[mysqld]\naudit_log_rotations = 6\n",
+		}
+		got, err := staticConfigRev("5.7", data)
+		assert.NoError(t, err)
+		assert.NotEqual(t, rev, got)
+	}
+}
+
+func TestConfigValue(t *testing.T) {
+	assert.Equal(t, int64(16777216), parseConfigValue("16M"))
+	assert.Equal(t, int64(3), parseConfigValue("3"))
+	assert.Equal(t, 0.5, parseConfigValue("0.5"))
+	assert.Equal(t, "READ-COMMITTED", parseConfigValue(`"READ-COMMITTED"`))
+
+	assert.Equal(t, true, configValueEqual(int64(3), "3.000000"))
+	assert.Equal(t, true, configValueEqual("ON", "1"))
+	assert.Equal(t, true, configValueEqual("off", "OFF"))
+	assert.Equal(t, false, configValueEqual(int64(1024), "2048"))
+	assert.Equal(t, false, configValueEqual("READ-COMMITTED", "REPEATABLE-READ"))
+}
+
+func TestSyncConfigRev(t *testing.T) {
+	data := map[string]string{
+		"my.cnf": "[mysqld]\nmax_connections = 1024\nback_log = 2048\n",
+	}
+	s := &mysqlCMSyncer{
+		MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{}),
+		cm: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{ResourceVersion: "100"},
+			Data:       map[string]string{"my.cnf": data["my.cnf"]},
+		},
+		log: logf.Log.WithName("test"),
+	}
+
+	// the configmap synced by the older operator keeps the resourceVersion.
+	s.syncConfigRev(data)
+	assert.Equal(t, "100", s.GetConfigRev())
+
+	// the dynamic configs changed.
+	s.cm.ResourceVersion = "101"
+	s.cm.Data["my.cnf"] = "[mysqld]\nmax_connections = 2048\nback_log = 2048\n"
+	s.syncConfigRev(data)
+	assert.Equal(t, "100", s.GetConfigRev())
+
+	// the static configs changed.
+	s.cm.Data["my.cnf"] = "[mysqld]\nmax_connections = 2048\nback_log = 4096\n"
+	s.syncConfigRev(data)
+	rev, err := staticConfigRev("5.7", s.cm.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, rev, s.GetConfigRev())
+
+	// the static configs changed with the upgrade.
+	s.cm.Annotations = nil
+	s.syncConfigRev(data)
+	assert.Equal(t, rev, s.GetConfigRev())
+	assert.Equal(t, rev, s.cm.Annotations[utils.AnnotationStaticConfigsHash])
+}
+
+func TestApplyDynamicConfigs(t *testing.T) {
+	s := &StatusSyncer{
+		MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{}),
+		dynamicConfigs: map[string]string{
+			"innodb_buffer_pool_size": "1717986918",
+			"max_connections":         "1024",
+		},
+		log: logf.Log.WithName("test"),
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-0"}}
+	newServer := func(bufferPoolSize int64) *sqltest.Server {
+		server := &sqltest.Server{}
+		server.SetResult("SELECT @@global.innodb_buffer_pool_size;", &sqltest.Result{Rows: [][]driver.Value{{bufferPoolSize}}})
+		server.SetResult("SELECT @@global.innodb_buffer_pool_chunk_size, @@global.innodb_buffer_pool_instances;",
+			&sqltest.Result{Rows: [][]driver.Value{{int64(134217728), int64(2)}}})
+		server.SetResult("SELECT @@global.max_connections;", &sqltest.Result{Rows: [][]driver.Value{{int64(1024)}}})
+		return server
+	}
+
+	// the size is rounded up to the multiple of 256M.
+	server := newServer(1073741824)
+	status := s.applyDynamicConfigs(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), pod)
+	assert.Equal(t, apiv1alpha1.NodeConfigApplied, status.State)
+	assert.Contains(t, server.Stmts(), "SET GLOBAL innodb_buffer_pool_size = 1879048192;")
+
+	// the size has been applied.
+	server = newServer(1879048192)
+	status = s.applyDynamicConfigs(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), pod)
+	assert.Equal(t, []string{"innodb_buffer_pool_size", "max_connections"}, status.Applied)
+	for _, stmt := range server.Stmts() {
+		assert.NotContains(t, stmt, "SET GLOBAL")
+	}
+}
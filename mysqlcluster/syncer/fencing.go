@@ -0,0 +1,142 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// minFencingMembers is the least members to fence the stale leaders.
+const minFencingMembers = 3
+
+// getFencedNodes returns the pods that must not be the leader. The leader is the node that
+// claims to be the leader and is followed by the majority of the members. If there is such
+// a leader, the other pods that claim to be or are labeled the leader are fenced. Otherwise
+// they are fenced only if more than one node claims to be the leader. Nothing is fenced with
+// less than three members, which have no majority once a node is lost.
+func getFencedNodes(pods []corev1.Pod, nodes []apiv1alpha1.NodeStatus, members int) map[string]bool {
+	fenced := map[string]bool{}
+	if members < minFencingMembers {
+		return fenced
+	}
+	votes := map[string]int{}
+	claimants := []string{}
+	for _, node := range nodes {
+		if node.RaftStatus.Role == string(utils.Leader) {
+			claimants = append(claimants, getPodNameOfHost(node.Name))
+		}
+		votes[getPodNameOfHost(node.RaftStatus.Leader)]++
+	}
+
+	leader := ""
+	for _, claimant := range claimants {
+		if votes[claimant] > members/2 {
+			leader = claimant
+		}
+	}
+	if leader == "" && len(claimants) < 2 {
+		return fenced
+	}
+	for _, claimant := range claimants {
+		if claimant != leader {
+			fenced[claimant] = true
+		}
+	}
+	for _, pod := range pods {
+		if pod.Labels["role"] == string(utils.Leader) && pod.Name != leader {
+			fenced[pod.Name] = true
+		}
+	}
+	return fenced
+}
+
+// getPodNameOfHost returns the pod name of the host, such as sample-mysql-0 of
+// sample-mysql-0.sample-mysql.default:8801.
+func getPodNameOfHost(host string) string {
+	return strings.Split(host, ".")[0]
+}
+
+// fenceLeader removes the stale leader from the leader endpoints, the role label has been
+// removed by updatePodLabel. An event is recorded if anything was done.
+func (s *StatusSyncer) fenceLeader(ctx context.Context, pod *corev1.Pod, labelRemoved bool) error {
+	endpointsRemoved, err := s.removeFromLeaderEndpoints(ctx, pod)
+	if err != nil {
+		return err
+	}
+	if labelRemoved || endpointsRemoved {
+		s.recorder.Eventf(s.Unwrap(), corev1.EventTypeWarning, "Fenced",
+			"fenced the stale leader %s, role label removed: %t, removed from the leader endpoints: %t",
+			pod.Name, labelRemoved, endpointsRemoved)
+	}
+	return nil
+}
+
+// removeFromLeaderEndpoints removes the pod from the endpoints of the leader service, returns
+// false if the pod is not in them.
+func (s *StatusSyncer) removeFromLeaderEndpoints(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	endpoints := &corev1.Endpoints{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: s.GetNameForResource(utils.LeaderService), Namespace: s.Namespace}, endpoints); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	oldEndpoints := endpoints.DeepCopy()
+
+	removed := false
+	subsets := []corev1.EndpointSubset{}
+	for _, subset := range endpoints.Subsets {
+		var ok bool
+		subset.Addresses, ok = removePodAddress(subset.Addresses, pod)
+		removed = removed || ok
+		subset.NotReadyAddresses, ok = removePodAddress(subset.NotReadyAddresses, pod)
+		removed = removed || ok
+		// A subset without addresses is invalid.
+		if len(subset.Addresses) != 0 || len(subset.NotReadyAddresses) != 0 {
+			subsets = append(subsets, subset)
+		}
+	}
+	if !removed {
+		return false, nil
+	}
+	endpoints.Subsets = subsets
+	if err := s.cli.Patch(ctx, endpoints, client.MergeFrom(oldEndpoints)); err != nil {
+		return false, fmt.Errorf("failed to patch the leader endpoints: %s", err)
+	}
+	return true, nil
+}
+
+// removePodAddress returns the addresses without the pod's, and whether there was one.
+func removePodAddress(addresses []corev1.EndpointAddress, pod *corev1.Pod) ([]corev1.EndpointAddress, bool) {
+	removed := false
+	result := []corev1.EndpointAddress{}
+	for _, address := range addresses {
+		if (address.TargetRef != nil && address.TargetRef.Name == pod.Name) ||
+			(pod.Status.PodIP != "" && address.IP == pod.Status.PodIP) {
+			removed = true
+			continue
+		}
+		result = append(result, address)
+	}
+	return result, removed
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func newFencingPod(name, role string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"role": role},
+		},
+	}
+}
+
+func newFencingNode(name, role, leader string) apiv1alpha1.NodeStatus {
+	host := func(pod string) string {
+		if pod == "" {
+			return "UNKNOWN"
+		}
+		return pod + ".sample-mysql.default:8801"
+	}
+	return apiv1alpha1.NodeStatus{
+		Name: name + ".sample-mysql.default",
+		RaftStatus: apiv1alpha1.RaftStatus{
+			Role:   role,
+			Leader: host(leader),
+		},
+	}
+}
+
+func TestGetFencedNodes(t *testing.T) {
+	pods := []corev1.Pod{
+		newFencingPod("sample-mysql-0", "LEADER"),
+		newFencingPod("sample-mysql-1", "FOLLOWER"),
+		newFencingPod("sample-mysql-2", "FOLLOWER"),
+	}
+
+	// Healthy.
+	nodes := []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "LEADER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-1", "FOLLOWER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-2", "FOLLOWER", "sample-mysql-0"),
+	}
+	assert.Empty(t, getFencedNodes(pods, nodes, 3))
+
+	// The old leader is isolated and still claims to be the leader.
+	nodes = []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "LEADER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-1", "LEADER", "sample-mysql-1"),
+		newFencingNode("sample-mysql-2", "FOLLOWER", "sample-mysql-1"),
+	}
+	assert.Equal(t, map[string]bool{"sample-mysql-0": true}, getFencedNodes(pods, nodes, 3))
+
+	// The old leader is unreachable but still labeled the leader.
+	nodes = []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "UNKNOW", ""),
+		newFencingNode("sample-mysql-1", "LEADER", "sample-mysql-1"),
+		newFencingNode("sample-mysql-2", "FOLLOWER", "sample-mysql-1"),
+	}
+	assert.Equal(t, map[string]bool{"sample-mysql-0": true}, getFencedNodes(pods, nodes, 3))
+
+	// The only leader without the majority is left to xenon.
+	nodes = []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "LEADER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-1", "UNKNOW", ""),
+		newFencingNode("sample-mysql-2", "UNKNOW", ""),
+	}
+	assert.Empty(t, getFencedNodes(pods, nodes, 3))
+
+	// No leader has the majority, all the claimants are fenced.
+	nodes = []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "LEADER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-1", "LEADER", "sample-mysql-1"),
+		newFencingNode("sample-mysql-2", "UNKNOW", ""),
+	}
+	assert.Equal(t, map[string]bool{"sample-mysql-0": true, "sample-mysql-1": true}, getFencedNodes(pods, nodes, 3))
+
+	// Two members have no majority once a node is lost, nothing is fenced.
+	nodes = []apiv1alpha1.NodeStatus{
+		newFencingNode("sample-mysql-0", "LEADER", "sample-mysql-0"),
+		newFencingNode("sample-mysql-1", "LEADER", "sample-mysql-1"),
+	}
+	assert.Empty(t, getFencedNodes(pods[:2], nodes, 2))
+}
+
+func TestRemovePodAddress(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-0"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	addresses := []corev1.EndpointAddress{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2", TargetRef: &corev1.ObjectReference{Name: "sample-mysql-0"}},
+		{IP: "10.0.0.3", TargetRef: &corev1.ObjectReference{Name: "sample-mysql-1"}},
+	}
+	result, removed := removePodAddress(addresses, pod)
+	assert.True(t, removed)
+	assert.Equal(t, []corev1.EndpointAddress{addresses[2]}, result)
+
+	result, removed = removePodAddress(result, pod)
+	assert.False(t, removed)
+	assert.Equal(t, []corev1.EndpointAddress{addresses[2]}, result)
+}
@@ -19,6 +19,7 @@ package syncer
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -128,6 +129,7 @@ func (s *mysqlCMSyncer) createOrUpdate(ctx context.Context) (controllerutil.Oper
 		if err = s.generateTemplate(ctx); err != nil {
 			return resultNone, err
 		}
+		s.syncConfigRev(nil)
 
 		if err = s.cli.Create(ctx, s.cm); err != nil {
 			return resultNone, err
@@ -136,10 +138,19 @@ func (s *mysqlCMSyncer) createOrUpdate(ctx context.Context) (controllerutil.Oper
 		}
 	}
 
+	prev := make(map[string]string, len(s.cm.Data))
+	for k, v := range s.cm.Data {
+		prev[k] = v
+	}
 	if err := s.appendConf(); err != nil {
 		return resultNone, err
 	}
 
+	if err := s.syncSemiSyncConf(); err != nil {
+		return resultNone, err
+	}
+	s.syncConfigRev(prev)
+
 	if err := s.setControllerReference(); err != nil {
 		return resultNone, err
 	}
@@ -171,17 +182,158 @@ func (s *mysqlCMSyncer) generateTemplate(ctx context.Context) error {
 	return fmt.Errorf("MysqlConfTemplate is empty")
 }
 
-// Notice: The parameters will not be removed from the cm when its removed from the mysqlConf/pluginConf.
+// appendConf applies the mysqlConf/pluginConf to the configmap. The keys from the spec are
+// recorded in the annotation with their original values, so that they are restored when
+// they are removed from the spec. The configmap without the annotation was synced by the
+// older operator, its managed keys are adopted from the difference with the defaults.
 func (s *mysqlCMSyncer) appendConf() error {
-	if err := s.createOrReplaceIniKey("my.cnf", s.Spec.MysqlOpts.MysqlConf); err != nil {
+	defaults, err := s.buildDefaultConf()
+	if err != nil {
 		return err
 	}
-	if err := s.createOrReplaceIniKey("plugin.cnf", s.Spec.MysqlOpts.PluginConf); err != nil {
+
+	managed := map[string]map[string]*string{}
+	if data, ok := s.cm.Annotations[utils.AnnotationManagedConfigs]; ok {
+		if err := json.Unmarshal([]byte(data), &managed); err != nil {
+			s.log.Error(err, "failed to parse the managed configs, ignore it")
+			managed = map[string]map[string]*string{}
+		}
+	} else if managed, err = adoptManagedConfigs(s.cm.Data, defaults); err != nil {
+		return err
+	}
+
+	for _, file := range []struct {
+		key   string
+		patch map[string]string
+	}{
+		{"my.cnf", s.Spec.MysqlOpts.MysqlConf},
+		{utils.PluginConfigs, s.Spec.MysqlOpts.PluginConf},
+	} {
+		keys, err := s.createOrReplaceIniKey(file.key, file.patch, managed[file.key], defaults[file.key])
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			delete(managed, file.key)
+		} else {
+			managed[file.key] = keys
+		}
+	}
+
+	// The empty annotation is kept, so that the managed keys are adopted only once.
+	data, err := json.Marshal(managed)
+	if err != nil {
 		return err
 	}
+	if s.cm.Annotations == nil {
+		s.cm.Annotations = map[string]string{}
+	}
+	s.cm.Annotations[utils.AnnotationManagedConfigs] = string(data)
 	return nil
 }
 
+// adoptManagedConfigs returns the managed keys of the configs without the annotation. The keys
+// different from the defaults were set by the mysqlConf/pluginConf, they are recorded with the
+// default values, nil if not in the defaults. The semi-sync configs are skipped as they are
+// synced by syncSemiSyncConf, and nothing is adopted from the template, which has no defaults.
+func adoptManagedConfigs(data, defaults map[string]string) (map[string]map[string]*string, error) {
+	managed := map[string]map[string]*string{}
+	for _, file := range []string{"my.cnf", utils.PluginConfigs} {
+		if len(data[file]) == 0 || len(defaults[file]) == 0 {
+			continue
+		}
+		cfg, err := ini.LoadSources(iniLoadOptions, []byte(data[file]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s, err: %s", file, err.Error())
+		}
+		defaultCfg, err := ini.LoadSources(iniLoadOptions, []byte(defaults[file]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the default %s, err: %s", file, err.Error())
+		}
+		defaultSec := defaultCfg.Section("mysqld")
+
+		keys := map[string]*string{}
+		for _, key := range cfg.Section("mysqld").Keys() {
+			k := key.Name()
+			if isSemiSyncConfig(k) {
+				continue
+			}
+			if isRepeatableConfig(k) {
+				added := []string{}
+				for _, v := range key.ValueWithShadows() {
+					if !iniValuesContain(defaultSec, k, v) {
+						added = append(added, v)
+					}
+				}
+				if len(added) != 0 {
+					value := strings.Join(added, ";")
+					keys[k] = &value
+				}
+				continue
+			}
+			if value := getIniValue(defaultSec, k); value == nil || *value != key.Value() {
+				keys[k] = value
+			}
+		}
+		if len(keys) != 0 {
+			managed[file] = keys
+		}
+	}
+	return managed, nil
+}
+
+// iniValuesContain returns true if the value is one of the values of the repeatable key.
+func iniValuesContain(sec *ini.Section, k, value string) bool {
+	for _, name := range []string{barskey(k), underscorekey(k)} {
+		if sec.HasKey(name) && utils.StringInArray(value, sec.Key(name).ValueWithShadows()) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncSemiSyncConf makes the semi-sync configs in the plugin configs follow the replication
+// mode, so that the restarted nodes start with the mode in effect.
+func (s *mysqlCMSyncer) syncSemiSyncConf() error {
+	data, ok := s.cm.Data[utils.PluginConfigs]
+	if !ok {
+		return nil
+	}
+	cfg, err := ini.LoadSources(iniLoadOptions, []byte(data))
+	if err != nil {
+		return fmt.Errorf("failed to load %s, err: %s", utils.PluginConfigs, err.Error())
+	}
+	addKVConfigsToSection(cfg.Section("mysqld"), semiSyncConfigs(s.MysqlCluster))
+	if data, err = writeConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to write configs: %s", err)
+	}
+	s.cm.Data[utils.PluginConfigs] = data
+	return nil
+}
+
+// buildDefaultConf returns the configs generated by the operator without the mysqlConf/pluginConf,
+// it is empty if the configmap is the template specified by the user.
+func (s *mysqlCMSyncer) buildDefaultConf() (map[string]string, error) {
+	if s.Spec.MysqlOpts.MysqlConfTemplate != "" {
+		return map[string]string{}, nil
+	}
+	c := mysqlcluster.New(s.Unwrap().DeepCopy())
+	c.Spec.MysqlOpts.MysqlConf = nil
+	c.Spec.MysqlOpts.PluginConf = nil
+	data, err := buildMysqlConf(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mysql configs: %s", err)
+	}
+	dataPlugin, err := buildMysqlPluginConf(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mysql plugin configs: %s", err)
+	}
+	return map[string]string{
+		"my.cnf":            data,
+		utils.PluginConfigs: dataPlugin,
+	}, nil
+}
+
 func (s *mysqlCMSyncer) setControllerReference() error {
 	if s.MysqlCluster == nil {
 		return fmt.Errorf("owner is nil")
@@ -200,45 +352,163 @@ func (s *mysqlCMSyncer) setControllerReference() error {
 	return nil
 }
 
-func (s *mysqlCMSyncer) createOrReplaceIniKey(key string, patch map[string]string) error {
-	if len(patch) == 0 {
-		return nil
+// createOrReplaceIniKey applies the patch to the section mysqld of the file in the configmap.
+// The managed is the keys applied last time with their original values, the keys removed
+// from the patch are restored to the original values. The original values are got from the
+// defaults, or the file if the defaults is empty. For the repeatable keys, the values
+// separated by ';' are added as the repeated lines, and the managed keeps the added values.
+// It returns the managed keys after applied.
+func (s *mysqlCMSyncer) createOrReplaceIniKey(key string, patch map[string]string,
+	managed map[string]*string, defaults string) (map[string]*string, error) {
+	f, ok := s.cm.Data[key]
+	if !ok {
+		return managed, nil
 	}
-	if f, ok := s.cm.Data[key]; ok {
-		if iniFile, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true, AllowBooleanKeys: true}, []byte(f)); err != nil {
-			return fmt.Errorf("failed to load %s, err: %s", key, err.Error())
-		} else {
-			sec := iniFile.Section("mysqld")
-			keys := []string{}
-			for k := range patch {
-				keys = append(keys, k)
+	iniFile, err := ini.LoadSources(iniLoadOptions, []byte(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s, err: %s", key, err.Error())
+	}
+	sec := iniFile.Section("mysqld")
+	origin := sec
+	if len(defaults) != 0 {
+		defaultFile, err := ini.LoadSources(iniLoadOptions, []byte(defaults))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the default %s, err: %s", key, err.Error())
+		}
+		origin = defaultFile.Section("mysqld")
+	}
+
+	result := map[string]*string{}
+	// Restore the keys removed from the patch.
+	for k, value := range managed {
+		if _, ok := patch[k]; ok {
+			result[k] = value
+			continue
+		}
+		if isRepeatableConfig(k) {
+			removeIniValues(sec, k, value)
+			continue
+		}
+		deleteIniKey(sec, k)
+		if value != nil {
+			if _, err := sec.NewKey(k, *value); err != nil {
+				return nil, fmt.Errorf("failed to restore key to config section: %s", err)
 			}
-			sort.Sort(StringsConnectedByBar(keys))
-
-			for _, k := range keys {
-				// TODO: repeatable key, like plugin-load-add
-				// replace
-				if sec.HasKey(barskey(k)) {
-					sec.Key(barskey(k)).SetValue(patch[k])
-				} else if sec.HasKey(underscorekey(k)) {
-					sec.Key(underscorekey(k)).SetValue(patch[k])
-				} else { // Not in sec.
-					// Add it to sec
-					if _, err := sec.NewKey(k, patch[k]); err != nil {
-						return fmt.Errorf("failed to add key to config section: %s", err)
-					}
-				}
+		}
+	}
+
+	keys := []string{}
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Sort(StringsConnectedByBar(keys))
+
+	for _, k := range keys {
+		if isRepeatableConfig(k) {
+			removeIniValues(sec, k, result[k])
+			value := patch[k]
+			result[k] = &value
+			if err := addIniValues(sec, k, value); err != nil {
+				return nil, err
 			}
-			data, err := writeConfigs(iniFile)
-			if err != nil {
-				return fmt.Errorf("failed to write configs: %s", err)
+			continue
+		}
+
+		if _, ok := result[k]; !ok {
+			result[k] = getIniValue(origin, k)
+		}
+		// replace
+		if sec.HasKey(barskey(k)) {
+			sec.Key(barskey(k)).SetValue(patch[k])
+		} else if sec.HasKey(underscorekey(k)) {
+			sec.Key(underscorekey(k)).SetValue(patch[k])
+		} else { // Not in sec.
+			// Add it to sec
+			if _, err := sec.NewKey(k, patch[k]); err != nil {
+				return nil, fmt.Errorf("failed to add key to config section: %s", err)
 			}
-			s.cm.Data[key] = data
+		}
+	}
+	data, err := writeConfigs(iniFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write configs: %s", err)
+	}
+	s.cm.Data[key] = data
+	return result, nil
+}
+
+// iniLoadOptions is the options to load the configs, the repeatable keys are loaded as shadows.
+var iniLoadOptions = ini.LoadOptions{IgnoreInlineComment: true, AllowBooleanKeys: true, AllowShadows: true}
+
+// isRepeatableConfig returns true if the key can be repeated in my.cnf.
+func isRepeatableConfig(k string) bool {
+	return utils.StringInArray(barskey(k), mysqlRepeatableConfigs)
+}
+
+// getIniValue returns the value of the key in the section, nil if the key does not exist.
+func getIniValue(sec *ini.Section, k string) *string {
+	for _, name := range []string{barskey(k), underscorekey(k)} {
+		if sec.HasKey(name) {
+			value := sec.Key(name).Value()
+			return &value
 		}
 	}
 	return nil
 }
 
+// deleteIniKey deletes the key in the section, both the barskey and the underscorekey.
+func deleteIniKey(sec *ini.Section, k string) {
+	sec.DeleteKey(barskey(k))
+	sec.DeleteKey(underscorekey(k))
+}
+
+// addIniValues adds the values separated by ';' of the repeatable key to the section.
+func addIniValues(sec *ini.Section, k, value string) error {
+	for _, v := range strings.Split(value, ";") {
+		if v = strings.TrimSpace(v); len(v) == 0 {
+			continue
+		}
+		var err error
+		if sec.HasKey(k) {
+			err = sec.Key(k).AddShadow(v)
+		} else {
+			_, err = sec.NewKey(k, v)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add key to config section: %s", err)
+		}
+	}
+	return nil
+}
+
+// removeIniValues removes the values separated by ';' of the repeatable key from the section,
+// the other values of the key are kept.
+func removeIniValues(sec *ini.Section, k string, value *string) {
+	if value == nil {
+		return
+	}
+	removed := map[string]bool{}
+	for _, v := range strings.Split(*value, ";") {
+		removed[strings.TrimSpace(v)] = true
+	}
+	for _, name := range []string{barskey(k), underscorekey(k)} {
+		if !sec.HasKey(name) {
+			continue
+		}
+		values := sec.Key(name).ValueWithShadows()
+		sec.DeleteKey(name)
+		for _, v := range values {
+			if removed[v] {
+				// Only remove once, the same value may be added by others.
+				delete(removed, v)
+				continue
+			}
+			// The values of the key are added back in order.
+			_ = addIniValues(sec, name, v)
+		}
+	}
+}
+
 // buildMysqlConf build the mysql config.
 func buildMysqlConf(c *mysqlcluster.MysqlCluster) (string, error) {
 	var log = logf.Log.WithName("mysqlcluster.syncer.buildMysqlConf")
@@ -270,7 +540,7 @@ func buildMysqlConf(c *mysqlcluster.MysqlCluster) (string, error) {
 			log.Error(err, "failed to add boolean key to config section", "key", key)
 		}
 	}
-	if len(c.Spec.TlsSecretName) != 0 {
+	if len(c.GetTLSSecretName()) != 0 {
 		addKVConfigsToSection(sec, mysqlSSLConfigs)
 	}
 	addKVConfigsToSection(sec, c.Spec.MysqlOpts.MysqlConf)
@@ -288,8 +558,9 @@ func buildMysqlPluginConf(c *mysqlcluster.MysqlCluster) (string, error) {
 	cfg := ini.Empty(ini.LoadOptions{IgnoreInlineComment: true})
 	sec := cfg.Section("mysqld")
 
-	addKVConfigsToSection(sec, pluginConfigs)
-	addKVConfigsToSection(sec, c.Spec.MysqlOpts.PluginConf)
+	// The semi-sync configs follow the replication mode, they override the pluginConf as
+	// syncSemiSyncConf does.
+	addKVConfigsToSection(sec, pluginConfigs, c.Spec.MysqlOpts.PluginConf, semiSyncConfigs(c))
 	data, err := writeConfigs(cfg)
 	if err != nil {
 		return "", err
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ini/ini"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestMysqlCMSyncerAppendConf(t *testing.T) {
+	cluster := mysqlcluster.New(&mysqlv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample",
+			Namespace: "default",
+		},
+		Spec: mysqlv1alpha1.MysqlClusterSpec{
+			MysqlOpts: mysqlv1alpha1.MysqlOpts{
+				MysqlConfTemplate: "sample-template",
+				MysqlConf: mysqlv1alpha1.MysqlConf{
+					"max_connections": "2048",
+					"wait_timeout":    "60",
+					"plugin-load-add": "b.so;c.so",
+				},
+			},
+		},
+	})
+	s := &mysqlCMSyncer{
+		MysqlCluster: cluster,
+		cm: &corev1.ConfigMap{
+			Data: map[string]string{
+				"my.cnf": "[mysqld]\nmax_connections = 1024\nplugin-load-add = a.so\n",
+			},
+		},
+		log: logf.Log.WithName("test"),
+	}
+	assert.NoError(t, s.appendConf())
+	assert.Equal(t, "[mysqld]\nmax_connections = 2048\nplugin-load-add = a.so\nplugin-load-add = b.so\nplugin-load-add = c.so\nwait_timeout    = 60\n\n",
+		s.cm.Data["my.cnf"])
+
+	// the keys removed from the spec are restored.
+	cluster.Spec.MysqlOpts.MysqlConf = mysqlv1alpha1.MysqlConf{
+		"plugin-load-add": "c.so",
+	}
+	assert.NoError(t, s.appendConf())
+	assert.Equal(t, "[mysqld]\nmax_connections = 1024\nplugin-load-add = a.so\nplugin-load-add = c.so\n\n",
+		s.cm.Data["my.cnf"])
+
+	cluster.Spec.MysqlOpts.MysqlConf = nil
+	assert.NoError(t, s.appendConf())
+	assert.Equal(t, "[mysqld]\nmax_connections = 1024\nplugin-load-add = a.so\n\n", s.cm.Data["my.cnf"])
+	assert.Equal(t, "{}", s.cm.Annotations[utils.AnnotationManagedConfigs])
+}
+
+func TestMysqlCMSyncerAdoptManagedConfigs(t *testing.T) {
+	cluster := mysqlcluster.New(&mysqlv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample",
+			Namespace: "default",
+		},
+		Spec: mysqlv1alpha1.MysqlClusterSpec{
+			MysqlOpts: mysqlv1alpha1.MysqlOpts{
+				MysqlConf: mysqlv1alpha1.MysqlConf{
+					"max_connections": "2048",
+					"wait_timeout":    "60",
+					"event_scheduler": "ON",
+				},
+			},
+		},
+	})
+	// the configmap synced by the older operator.
+	data, err := buildMysqlConf(cluster)
+	assert.NoError(t, err)
+	s := &mysqlCMSyncer{
+		MysqlCluster: cluster,
+		cm: &corev1.ConfigMap{
+			Data: map[string]string{"my.cnf": data},
+		},
+		log: logf.Log.WithName("test"),
+	}
+	getConf := func() map[string]string {
+		cfg, err := ini.LoadSources(iniLoadOptions, []byte(s.cm.Data["my.cnf"]))
+		assert.NoError(t, err)
+		return cfg.Section("mysqld").KeysHash()
+	}
+
+	// the keys removed from the spec with the upgrade are restored.
+	delete(cluster.Spec.MysqlOpts.MysqlConf, "wait_timeout")
+	delete(cluster.Spec.MysqlOpts.MysqlConf, "event_scheduler")
+	assert.NoError(t, s.appendConf())
+	conf := getConf()
+	assert.Equal(t, "2048", conf["max_connections"])
+	assert.Equal(t, "3600", conf["wait_timeout"])
+	assert.Equal(t, "OFF", conf["event_scheduler"])
+
+	// the key kept in the spec is restored after the upgrade.
+	delete(cluster.Spec.MysqlOpts.MysqlConf, "max_connections")
+	assert.NoError(t, s.appendConf())
+	assert.Equal(t, "1024", getConf()["max_connections"])
+}
+
+func TestMysqlCMSyncerSyncSemiSyncConf(t *testing.T) {
+	replicas := int32(3)
+	cluster := mysqlcluster.New(&mysqlv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample",
+			Namespace: "default",
+		},
+		Spec: mysqlv1alpha1.MysqlClusterSpec{
+			Replicas:        &replicas,
+			ReplicationMode: mysqlv1alpha1.ReplicationModeSemiSync,
+			SemiSyncOpts: mysqlv1alpha1.SemiSyncOpts{
+				WaitForSlaveCount: 2,
+				DegradeTimeout:    &metav1.Duration{Duration: 10 * time.Second},
+			},
+		},
+	})
+	s := &mysqlCMSyncer{
+		MysqlCluster: cluster,
+		cm: &corev1.ConfigMap{
+			Data: map[string]string{
+				"plugin.cnf": "[mysqld]\naudit_log_rotations = 6\nrpl_semi_sync_slave_enabled = OFF\n",
+			},
+		},
+		log: logf.Log.WithName("test"),
+	}
+	getConf := func() map[string]string {
+		cfg, err := ini.Load([]byte(s.cm.Data["plugin.cnf"]))
+		assert.NoError(t, err)
+		return cfg.Section("mysqld").KeysHash()
+	}
+
+	assert.NoError(t, s.syncSemiSyncConf())
+	assert.Equal(t, map[string]string{
+		"audit_log_rotations":                       "6",
+		"rpl_semi_sync_master_enabled":              "OFF",
+		"rpl_semi_sync_slave_enabled":               "ON",
+		"rpl_semi_sync_master_wait_no_slave":        "ON",
+		"rpl_semi_sync_master_timeout":              "10000",
+		"rpl_semi_sync_master_wait_for_slave_count": "2",
+	}, getConf())
+
+	// switch to async.
+	cluster.Spec.ReplicationMode = mysqlv1alpha1.ReplicationModeAsync
+	assert.NoError(t, s.syncSemiSyncConf())
+	conf := getConf()
+	assert.Equal(t, "OFF", conf["rpl_semi_sync_slave_enabled"])
+	assert.Equal(t, "1", conf["rpl_semi_sync_master_wait_for_slave_count"])
+
+	// no plugin configs in the template.
+	s.cm.Data = map[string]string{}
+	assert.NoError(t, s.syncSemiSyncConf())
+	assert.Empty(t, s.cm.Data)
+}
+
+func TestBuildMysqlPluginConfSemiSync(t *testing.T) {
+	replicas := int32(3)
+	// The semi-sync configs in the pluginConf of the clusters created before the webhook
+	// rejected them are overridden by the replication mode, as syncSemiSyncConf does.
+	cluster := mysqlcluster.New(&mysqlv1alpha1.MysqlCluster{
+		Spec: mysqlv1alpha1.MysqlClusterSpec{
+			Replicas:        &replicas,
+			ReplicationMode: mysqlv1alpha1.ReplicationModeSemiSync,
+			MysqlOpts: mysqlv1alpha1.MysqlOpts{
+				PluginConf: mysqlv1alpha1.MysqlConf{
+					"audit_log_rotations":          "6",
+					"rpl_semi_sync_slave_enabled":  "OFF",
+					"rpl_semi_sync_master_timeout": "1000",
+				},
+			},
+		},
+	})
+	data, err := buildMysqlPluginConf(cluster)
+	assert.NoError(t, err)
+	cfg, err := ini.Load([]byte(data))
+	assert.NoError(t, err)
+	conf := cfg.Section("mysqld").KeysHash()
+	assert.Equal(t, "6", conf["audit_log_rotations"])
+	assert.Equal(t, "ON", conf["rpl_semi_sync_slave_enabled"])
+	assert.Equal(t, cluster.GetSemiSyncTimeout(), conf["rpl_semi_sync_master_timeout"])
+}
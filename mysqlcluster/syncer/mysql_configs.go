@@ -47,11 +47,6 @@ var mysqlSysConfigs = map[string]string{
 var pluginConfigs = map[string]string{
 	"plugin-load": "\"semisync_master.so;semisync_slave.so;audit_log.so;connection_control.so\"",
 
-	"rpl_semi_sync_master_enabled":       "OFF",
-	"rpl_semi_sync_slave_enabled":        "ON",
-	"rpl_semi_sync_master_wait_no_slave": "ON",
-	"rpl_semi_sync_master_timeout":       "1000000000000000000",
-
 	"audit_log_file":             "/var/log/mysql/mysql-audit.log",
 	"audit_log_exclude_accounts": "\"root@localhost,root@127.0.0.1," + utils.ReplicationUser + "@%," + utils.MetricsUser + "@%\"",
 	"audit_log_buffer_size":      "16M",
@@ -156,6 +151,19 @@ var mysqlBooleanConfigs = []string{
 	"!includedir /etc/mysql/conf.d",
 }
 
+// mysqlRepeatableConfigs is the list of the mysql configs which can be repeated in my.cnf.
+var mysqlRepeatableConfigs = []string{
+	"plugin-load-add",
+	"binlog-do-db",
+	"binlog-ignore-db",
+	"replicate-do-db",
+	"replicate-ignore-db",
+	"replicate-do-table",
+	"replicate-ignore-table",
+	"replicate-wild-do-table",
+	"replicate-wild-ignore-table",
+}
+
 // mysqlSSLConfigs is the ist of the mysql ssl configs.
 var mysqlSSLConfigs = map[string]string{
 	"ssl_ca":   "/etc/mysql-ssl/ca.crt",
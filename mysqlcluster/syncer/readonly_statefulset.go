@@ -320,10 +320,28 @@ func putMySQLReadOnly(s *StatefulSetSyncer, host string) error {
 		}
 		if isReplicating == corev1.ConditionFalse {
 			// chang master
+			masterSSL := 0
+			if len(s.GetTLSSecretName()) != 0 {
+				masterSSL = 1
+			}
 			changeSql := fmt.Sprintf(`stop slave;CHANGE MASTER TO MASTER_HOST='%s', MASTER_PORT=%d, MASTER_USER='%s', MASTER_PASSWORD='%s',
-MASTER_AUTO_POSITION=1; start slave;`, buildMasterName(s), 3306, "root", cfg.Password)
+MASTER_AUTO_POSITION=1, MASTER_SSL=%d, MASTER_DELAY=%d; start slave;`, buildMasterName(s), 3306, "root", cfg.Password, masterSSL, s.Spec.ReadOnlys.DelaySeconds)
 			sqlRunner.QueryExec(internal.NewQuery(changeSql))
 		}
+		// 4. delay the replication
+		if isReplicating == corev1.ConditionTrue {
+			delay, err := internal.GetSlaveSQLDelay(sqlRunner)
+			if err != nil {
+				return err
+			}
+			if delay != int(s.Spec.ReadOnlys.DelaySeconds) {
+				s.log.Info("change the readonly replication delay", "host", host, "delay", s.Spec.ReadOnlys.DelaySeconds)
+				delaySql := fmt.Sprintf("STOP SLAVE SQL_THREAD;CHANGE MASTER TO MASTER_DELAY=%d;START SLAVE SQL_THREAD;", s.Spec.ReadOnlys.DelaySeconds)
+				if err := sqlRunner.QueryExec(internal.NewQuery(delaySql)); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	return errOut
 }
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+)
+
+func TestPutMySQLReadOnlyDelay(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, apiv1alpha1.AddToScheme(scheme))
+	replicas := int32(3)
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			Replicas:  &replicas,
+			ReadOnlys: &apiv1alpha1.ReadOnlyType{Num: 1, DelaySeconds: 3600},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, secret).Build()
+
+	// newServer returns the read-only node replicating with the SQL_Delay, or not replicating
+	// if the delay is negative.
+	newServer := func(delay int) *sqltest.Server {
+		server := &sqltest.Server{}
+		for _, name := range []string{"read_only", "super_read_only"} {
+			server.SetResult("select @@global."+name+";", &sqltest.Result{Rows: [][]driver.Value{{int64(1)}}})
+		}
+		server.SetResult("select @@global.rpl_semi_sync_slave_enabled;", &sqltest.Result{Rows: [][]driver.Value{{int64(0)}}})
+		server.SetResult("select @@global.long_query_time;", &sqltest.Result{Rows: [][]driver.Value{{float64(3)}}})
+		if delay >= 0 {
+			server.SetResult("show slave status;", &sqltest.Result{
+				Columns: []string{"Slave_IO_State", "Slave_IO_Running", "Slave_SQL_Running", "Seconds_Behind_Master", "SQL_Delay"},
+				Rows:    [][]driver.Value{{"Waiting for master to send event", "Yes", "Yes", int64(0), int64(delay)}},
+			})
+		}
+		return server
+	}
+	putReadOnly := func(server *sqltest.Server) {
+		s := &StatefulSetSyncer{
+			MysqlCluster:     mysqlcluster.New(cluster),
+			cli:              cli,
+			SQLRunnerFactory: internal.NewSQLRunnerFactoryFromDB(sqltest.NewDB(t, server)),
+			log:              logf.Log.WithName("test"),
+		}
+		assert.NoError(t, putMySQLReadOnly(s, "sample-mysql-ro-0.sample-mysql-ro.default"))
+	}
+	delayStmt := "STOP SLAVE SQL_THREAD;CHANGE MASTER TO MASTER_DELAY=3600;START SLAVE SQL_THREAD;"
+
+	// The delay is changed on the replicating node.
+	server := newServer(0)
+	putReadOnly(server)
+	assert.Contains(t, server.Stmts(), delayStmt)
+
+	// The delay is in effect.
+	server = newServer(3600)
+	putReadOnly(server)
+	assert.NotContains(t, server.Stmts(), delayStmt)
+
+	// The delay is set with the master.
+	server = newServer(-1)
+	putReadOnly(server)
+	assert.NotContains(t, server.Stmts(), delayStmt)
+	changed := false
+	for _, stmt := range server.Stmts() {
+		changed = changed || (strings.Contains(stmt, "CHANGE MASTER TO MASTER_HOST='sample-follower'") &&
+			strings.Contains(stmt, "MASTER_DELAY=3600"))
+	}
+	assert.True(t, changed)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+)
+
+// semiSyncConfigPrefix is the prefix of the semi-sync configs. They follow the replication
+// mode, are applied online by the StatusSyncer and never restart the nodes.
+const semiSyncConfigPrefix = "rpl_semi_sync_"
+
+// isSemiSyncConfig returns true if the key is a semi-sync config.
+func isSemiSyncConfig(key string) bool {
+	return strings.HasPrefix(underscorekey(key), semiSyncConfigPrefix)
+}
+
+// semiSyncConfigs returns the semi-sync configs of the replication mode. The master is
+// disabled in the configs, it is enabled on the leader by xenon when the node becomes the
+// leader, and by the StatusSyncer when the mode is changed online.
+func semiSyncConfigs(c *mysqlcluster.MysqlCluster) map[string]string {
+	mode, acks := c.GetReplicationMode()
+	slaveEnabled := "ON"
+	if mode == apiv1alpha1.ReplicationModeAsync {
+		slaveEnabled = "OFF"
+		acks = 1
+	}
+	return map[string]string{
+		"rpl_semi_sync_master_enabled":              "OFF",
+		"rpl_semi_sync_slave_enabled":               slaveEnabled,
+		"rpl_semi_sync_master_wait_no_slave":        "ON",
+		"rpl_semi_sync_master_timeout":              c.GetSemiSyncTimeout(),
+		"rpl_semi_sync_master_wait_for_slave_count": strconv.Itoa(int(acks)),
+	}
+}
+
+// applyReplicationMode applies the semi-sync configs on the node online. The slave is
+// enabled or disabled on all the nodes, the io thread is restarted to take effect. The
+// master is enabled on the leader in semi-sync and disabled in async, it is left to xenon
+// on the followers. It returns whether the leader has degraded to async after the timeout.
+func (s *StatusSyncer) applyReplicationMode(sqlRunner internal.SQLRunner, isLeader bool) (bool, error) {
+	mode, _ := s.GetReplicationMode()
+	configs := semiSyncConfigs(s.MysqlCluster)
+	if !isLeader {
+		delete(configs, "rpl_semi_sync_master_enabled")
+	} else if mode == apiv1alpha1.ReplicationModeSemiSync {
+		configs["rpl_semi_sync_master_enabled"] = "ON"
+	}
+	names := []string{}
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	slaveChanged := false
+	for _, name := range names {
+		value := parseConfigValue(configs[name])
+		var current string
+		if err := internal.GetGlobalVariable(sqlRunner, name, &current); err != nil {
+			return false, fmt.Errorf("failed to get %s: %s", name, err)
+		}
+		if configValueEqual(value, current) {
+			continue
+		}
+		if err := internal.SetGlobalVariable(sqlRunner, name, value); err != nil {
+			return false, fmt.Errorf("failed to set %s: %s", name, err)
+		}
+		s.log.Info("set the semi-sync variable", "name", name, "value", value)
+		slaveChanged = slaveChanged || name == "rpl_semi_sync_slave_enabled"
+	}
+
+	if !isLeader {
+		// The slave reports the acks only after the io thread is restarted.
+		if slaveChanged {
+			if err := restartSlaveIOThread(sqlRunner); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+	if mode == apiv1alpha1.ReplicationModeAsync {
+		return false, nil
+	}
+	// The master is enabled above, so the status is off only if the leader has timed out
+	// waiting for the acks.
+	var name, status string
+	if err := sqlRunner.QueryRow(internal.NewQuery("SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_master_status'"), &name, &status); err != nil {
+		return false, fmt.Errorf("failed to get the semi-sync master status: %s", err)
+	}
+	return !configValueEqual("ON", status), nil
+}
+
+// restartSlaveIOThread restarts the io thread if the node is replicating.
+func restartSlaveIOThread(sqlRunner internal.SQLRunner) error {
+	masterHost, err := internal.GetMasterHost(sqlRunner)
+	if err != nil {
+		return err
+	}
+	if len(masterHost) == 0 {
+		return nil
+	}
+	if err := sqlRunner.QueryExec(internal.NewQuery("STOP SLAVE IO_THREAD;START SLAVE IO_THREAD;")); err != nil {
+		return fmt.Errorf("failed to restart the slave io thread: %s", err)
+	}
+	return nil
+}
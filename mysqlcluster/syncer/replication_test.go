@@ -0,0 +1,215 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// newSemiSyncServer returns the server with the semi-sync variables and the master status.
+func newSemiSyncServer(variables map[string]string, masterStatus string) *sqltest.Server {
+	server := &sqltest.Server{}
+	for name, value := range variables {
+		server.SetResult("select @@global."+name+";", &sqltest.Result{Rows: [][]driver.Value{{value}}})
+	}
+	server.SetResult("SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_master_status';",
+		&sqltest.Result{Rows: [][]driver.Value{{"Rpl_semi_sync_master_status", masterStatus}}})
+	return server
+}
+
+func semiSyncSetStmts(server *sqltest.Server) []string {
+	stmts := []string{}
+	for _, stmt := range server.Stmts() {
+		if strings.HasPrefix(stmt, "SET GLOBAL") {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func TestApplyReplicationMode(t *testing.T) {
+	replicas := int32(3)
+	s := &StatusSyncer{
+		MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+			Spec: apiv1alpha1.MysqlClusterSpec{
+				Replicas:        &replicas,
+				ReplicationMode: apiv1alpha1.ReplicationModeSemiSync,
+			},
+		}),
+		log: logf.Log.WithName("test"),
+	}
+	semiSync := map[string]string{
+		"rpl_semi_sync_master_enabled":              "ON",
+		"rpl_semi_sync_slave_enabled":               "ON",
+		"rpl_semi_sync_master_wait_no_slave":        "ON",
+		"rpl_semi_sync_master_timeout":              utils.SemiSyncNeverTimeout,
+		"rpl_semi_sync_master_wait_for_slave_count": "1",
+	}
+	async := map[string]string{}
+	for name, value := range semiSync {
+		async[name] = value
+	}
+	async["rpl_semi_sync_master_enabled"] = "OFF"
+	async["rpl_semi_sync_slave_enabled"] = "OFF"
+
+	// The master of the leader is enabled when the cluster is switched to semi-sync online.
+	server := newSemiSyncServer(async, "ON")
+	degraded, err := s.applyReplicationMode(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), true)
+	assert.NoError(t, err)
+	assert.False(t, degraded)
+	assert.Equal(t, []string{
+		"SET GLOBAL rpl_semi_sync_master_enabled = ON;",
+		"SET GLOBAL rpl_semi_sync_slave_enabled = ON;",
+	}, semiSyncSetStmts(server))
+
+	// The leader timed out waiting for the acks.
+	server = newSemiSyncServer(semiSync, "OFF")
+	degraded, err = s.applyReplicationMode(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), true)
+	assert.NoError(t, err)
+	assert.True(t, degraded)
+	assert.Empty(t, semiSyncSetStmts(server))
+
+	// The master of the follower is left to xenon, the io thread is restarted for the slave.
+	server = newSemiSyncServer(async, "OFF")
+	server.SetResult("show slave status;", &sqltest.Result{Columns: []string{"Master_Host"},
+		Rows: [][]driver.Value{{"sample-mysql-0.sample-mysql.default"}}})
+	degraded, err = s.applyReplicationMode(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), false)
+	assert.NoError(t, err)
+	assert.False(t, degraded)
+	assert.Equal(t, []string{"SET GLOBAL rpl_semi_sync_slave_enabled = ON;"}, semiSyncSetStmts(server))
+	assert.Contains(t, server.Stmts(), "STOP SLAVE IO_THREAD;START SLAVE IO_THREAD;")
+
+	// The master of the leader is disabled in async, and it never degrades.
+	s.Spec.ReplicationMode = apiv1alpha1.ReplicationModeAsync
+	server = newSemiSyncServer(semiSync, "OFF")
+	degraded, err = s.applyReplicationMode(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), true)
+	assert.NoError(t, err)
+	assert.False(t, degraded)
+	assert.Equal(t, []string{
+		"SET GLOBAL rpl_semi_sync_master_enabled = OFF;",
+		"SET GLOBAL rpl_semi_sync_slave_enabled = OFF;",
+	}, semiSyncSetStmts(server))
+	assert.NotContains(t, server.Stmts(), "SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_master_status';")
+
+	// A single node is async.
+	s.Spec.ReplicationMode = apiv1alpha1.ReplicationModeSemiSync
+	replicas = 1
+	server = newSemiSyncServer(semiSync, "ON")
+	_, err = s.applyReplicationMode(internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server)), true)
+	assert.NoError(t, err)
+	assert.Contains(t, semiSyncSetStmts(server), "SET GLOBAL rpl_semi_sync_master_enabled = OFF;")
+}
+
+// fakeSemiChecker records the pods whose semi-sync check of xenon was synced.
+type fakeSemiChecker struct {
+	enabled []string
+	closed  []string
+}
+
+func (c *fakeSemiChecker) EnableXenonSemiCheck(namespace, podName string) error {
+	c.enabled = append(c.enabled, podName)
+	return nil
+}
+
+func (c *fakeSemiChecker) CloseXenonSemiCheck(namespace, podName string) error {
+	c.closed = append(c.closed, podName)
+	return nil
+}
+
+func TestSyncXenonSemiCheck(t *testing.T) {
+	ctx := context.TODO()
+	replicas := int32(3)
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			Replicas:        &replicas,
+			ReplicationMode: apiv1alpha1.ReplicationModeSemiSync,
+		},
+		Status: apiv1alpha1.MysqlClusterStatus{State: apiv1alpha1.ClusterReadyState},
+	})
+	newPod := func(name string, ready bool, extraLabels map[string]string) *corev1.Pod {
+		labels := cluster.GetLabels()
+		for k, v := range extraLabels {
+			labels[k] = v
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: utils.ContainerXenonName, Ready: ready}},
+			},
+		}
+	}
+	cli := fake.NewClientBuilder().WithObjects(
+		newPod("sample-mysql-0", true, nil),
+		newPod("sample-mysql-1", true, nil),
+		// xenon is not ready.
+		newPod("sample-mysql-2", false, nil),
+		// the read-only pods have no xenon.
+		newPod("sample-mysql-ro-0", true, map[string]string{"readonly": "true"}),
+	).Build()
+	checker := &fakeSemiChecker{}
+	s := &StatefulSetSyncer{
+		MysqlCluster: cluster,
+		cli:          cli,
+		semiChecker:  checker,
+		log:          logf.Log.WithName("test"),
+	}
+	getState := func(name string) string {
+		pod := &corev1.Pod{}
+		assert.NoError(t, cli.Get(ctx, client.ObjectKey{Name: name, Namespace: "default"}, pod))
+		return pod.Annotations[utils.AnnotationXenonSemiCheck]
+	}
+
+	assert.NoError(t, s.SyncXenonSemiCheck(ctx))
+	assert.Equal(t, []string{"sample-mysql-0", "sample-mysql-1"}, checker.enabled)
+	assert.Equal(t, "enabled/0", getState("sample-mysql-0"))
+	assert.Equal(t, "", getState("sample-mysql-2"))
+
+	// Synced already.
+	checker.enabled = nil
+	assert.NoError(t, s.SyncXenonSemiCheck(ctx))
+	assert.Empty(t, checker.enabled)
+
+	// Switched to async.
+	cluster.Spec.ReplicationMode = apiv1alpha1.ReplicationModeAsync
+	assert.NoError(t, s.SyncXenonSemiCheck(ctx))
+	assert.Equal(t, []string{"sample-mysql-0", "sample-mysql-1"}, checker.closed)
+	assert.Equal(t, "disabled/0", getState("sample-mysql-1"))
+
+	// Nothing is synced until the cluster is ready.
+	checker.closed = nil
+	cluster.Spec.ReplicationMode = apiv1alpha1.ReplicationModeSemiSync
+	cluster.Status.State = apiv1alpha1.ClusterUpdateState
+	assert.NoError(t, s.SyncXenonSemiCheck(ctx))
+	assert.Empty(t, checker.enabled)
+}
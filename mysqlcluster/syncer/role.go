@@ -47,10 +47,25 @@ func NewRoleSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Inter
 				Resources: []string{"pods"},
 			},
 			{
-				Verbs:     []string{"get", "update", "patch"},
+				Verbs:     []string{"get", "patch"},
+				APIGroups: []string{""},
+				Resources: []string{"endpoints"},
+			},
+			{
+				Verbs:     []string{"create"},
+				APIGroups: []string{""},
+				Resources: []string{"events"},
+			},
+			{
+				Verbs:     []string{"get", "list", "update", "patch"},
 				APIGroups: []string{"batch"},
 				Resources: []string{"jobs"},
 			},
+			{
+				Verbs:     []string{"get", "list"},
+				APIGroups: []string{"mysql.radondb.com"},
+				Resources: []string{"backups"},
+			},
 			{
 				Verbs:     []string{"create"},
 				APIGroups: []string{""},
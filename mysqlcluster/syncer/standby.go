@@ -0,0 +1,204 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// reconcileStandby keeps the leader replicating from the standby source and the
+// whole xenon group super read only. Once the standby is disabled or removed, the
+// leader stops replicating from the recorded source and becomes a writable primary.
+func (s *StatusSyncer) reconcileStandby(ctx context.Context) error {
+	if s.IsStandby() {
+		sourceHost, sourcePort, err := s.getStandbySource(ctx)
+		if err != nil {
+			return err
+		}
+		s.Status.StandbySource = fmt.Sprintf("%s:%d", sourceHost, sourcePort)
+	} else if len(s.Status.StandbySource) == 0 {
+		return nil
+	}
+
+	for _, node := range s.Status.Nodes {
+		if strings.HasPrefix(node.Name, s.GetNameForResource(utils.ReadOnlyHeadlessSVC)) {
+			continue
+		}
+		isLeader := node.RaftStatus.Role == string(utils.Leader)
+		// The followers are always read only, nothing to do after promotion.
+		if !isLeader && !s.IsStandby() {
+			continue
+		}
+		if err := s.syncStandbyNode(ctx, node.Name, isLeader); err != nil {
+			return fmt.Errorf("failed to sync standby on node %s: %s", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// syncStandbyNode makes the node follow the standby spec.
+func (s *StatusSyncer) syncStandbyNode(ctx context.Context, host string, isLeader bool) error {
+	var sqlRunner internal.SQLRunner
+	closeCh := make(chan func())
+
+	var closeConn func()
+	errCh := make(chan error)
+
+	cfg, errOut := internal.NewConfigFromClusterKey(
+		s.cli, s.MysqlCluster.GetClusterKey(), utils.RootUser, host)
+	go func(sqlRunner *internal.SQLRunner, errCh chan error, closeCh chan func()) {
+		var err error
+		*sqlRunner, closeConn, err = s.SQLRunnerFactory(cfg, errOut)
+		if err != nil {
+			s.log.V(1).Info("failed to get sql runner", "node", host, "error", err)
+			errCh <- err
+			return
+		}
+		if closeConn != nil {
+			closeCh <- closeConn
+			return
+		}
+		errCh <- nil
+	}(&sqlRunner, errCh, closeCh)
+
+	select {
+	case errOut = <-errCh:
+		return errOut
+	case closeConn := <-closeCh:
+		defer closeConn()
+	case <-time.After(time.Second * 5):
+	}
+	if sqlRunner == nil {
+		return fmt.Errorf("failed to connect to %s", host)
+	}
+	return s.applyStandby(ctx, sqlRunner, host, isLeader)
+}
+
+// applyStandby applies the standby spec on the node. The statements are executed only
+// if the node does not match the spec, so that it does not fight xenon.
+func (s *StatusSyncer) applyStandby(ctx context.Context, sqlRunner internal.SQLRunner, host string, isLeader bool) error {
+	masterHost, masterPort, err := internal.GetMasterAddress(sqlRunner)
+	if err != nil {
+		return err
+	}
+	masterAddr := fmt.Sprintf("%s:%d", masterHost, masterPort)
+
+	if !s.IsStandby() {
+		// Promote the leader only if it still follows the recorded source, otherwise
+		// xenon has already made a new leader writable.
+		if len(masterHost) != 0 && masterAddr == s.Status.StandbySource {
+			s.log.Info("promote the standby leader to primary", "node", host, "source", masterAddr)
+			if err := sqlRunner.QueryExec(internal.NewQuery(
+				"STOP SLAVE;RESET SLAVE ALL;SET GLOBAL super_read_only=off;SET GLOBAL read_only=off;")); err != nil {
+				return err
+			}
+		}
+		s.Status.StandbySource = ""
+		return nil
+	}
+
+	// 1. the whole xenon group must be super read only.
+	if status, err := internal.CheckSuperReadOnly(sqlRunner); err != nil {
+		return err
+	} else if status != corev1.ConditionTrue {
+		if err := sqlRunner.QueryExec(internal.NewQuery("SET GLOBAL super_read_only=on")); err != nil {
+			return err
+		}
+	}
+	if !isLeader {
+		return nil
+	}
+
+	// 2. the leader replicates from the source, it is changed only if the source differs.
+	if masterAddr == s.Status.StandbySource {
+		return nil
+	}
+	user, password, err := s.getStandbyReplicationUser(ctx)
+	if err != nil {
+		return err
+	}
+	sourceHost, sourcePort, err := s.getStandbySource(ctx)
+	if err != nil {
+		return err
+	}
+	s.log.Info("change the standby leader master", "node", host, "source", s.Status.StandbySource)
+	return sqlRunner.QueryExec(internal.NewQuery(`STOP SLAVE;CHANGE MASTER TO MASTER_HOST=?, MASTER_PORT=?, MASTER_USER=?, MASTER_PASSWORD=?,
+MASTER_AUTO_POSITION=1;START SLAVE;`, sourceHost, sourcePort, user, password))
+}
+
+// getStandbySource returns the address of the source, the remote source is read from
+// its secret.
+func (s *StatusSyncer) getStandbySource(ctx context.Context) (string, int32, error) {
+	if s.Spec.Standby != nil {
+		host, port := s.GetStandbySource()
+		return host, port, nil
+	}
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.Spec.RemoteSourceSecretName, Namespace: s.Namespace}, secret); err != nil {
+		return "", 0, err
+	}
+	host, ok := secret.Data["host"]
+	if !ok {
+		return "", 0, fmt.Errorf("host cannot be empty in secret %s", s.Spec.RemoteSourceSecretName)
+	}
+	port := int64(utils.MysqlPort)
+	if data, ok := secret.Data["port"]; ok {
+		var err error
+		if port, err = strconv.ParseInt(string(data), 10, 32); err != nil {
+			return "", 0, fmt.Errorf("invalid port in secret %s: %s", s.Spec.RemoteSourceSecretName, err)
+		}
+	}
+	return string(host), int32(port), nil
+}
+
+// getStandbyReplicationUser returns the replication account used to connect to the source.
+// If the source is a MysqlCluster, use its replication user. If the cluster bootstraps from
+// a remote mysql, use the user of the remote source. Otherwise use the local one.
+func (s *StatusSyncer) getStandbyReplicationUser(ctx context.Context) (string, string, error) {
+	secretName := s.GetNameForResource(utils.Secret)
+	userKey, passwordKey := "replication-user", "replication-password"
+	if s.Spec.Standby != nil && len(s.Spec.Standby.ClusterName) != 0 {
+		secretName = fmt.Sprintf("%s-secret", s.Spec.Standby.ClusterName)
+	} else if len(s.Spec.RemoteSourceSecretName) != 0 {
+		secretName = s.Spec.RemoteSourceSecretName
+		userKey, passwordKey = "user", "password"
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: secretName, Namespace: s.Namespace}, secret); err != nil {
+		return "", "", err
+	}
+	user, ok := secret.Data[userKey]
+	if !ok {
+		return "", "", fmt.Errorf("%s cannot be empty in secret %s", userKey, secretName)
+	}
+	password, ok := secret.Data[passwordKey]
+	if !ok {
+		return "", "", fmt.Errorf("%s cannot be empty in secret %s", passwordKey, secretName)
+	}
+	return string(user), string(password), nil
+}
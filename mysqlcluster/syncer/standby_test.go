@@ -0,0 +1,153 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/internal/sqltest"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+)
+
+const (
+	showSlaveStatus     = "show slave status;"
+	selectSuperReadOnly = "select @@global.super_read_only;"
+	changeStandbyMaster = "STOP SLAVE;CHANGE MASTER TO MASTER_HOST=source-leader.default, MASTER_PORT=3306, " +
+		"MASTER_USER=radondb_repl, MASTER_PASSWORD=repl,\nMASTER_AUTO_POSITION=1;START SLAVE;"
+	promoteStandby = "STOP SLAVE;RESET SLAVE ALL;SET GLOBAL super_read_only=off;SET GLOBAL read_only=off;"
+)
+
+func newStandbyStatusSyncer(standby *apiv1alpha1.MySQLStandbySpec) *StatusSyncer {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec:       apiv1alpha1.MysqlClusterSpec{Standby: standby},
+	}
+	cli := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"replication-user":     []byte("radondb_repl"),
+			"replication-password": []byte("repl"),
+		},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"host":     []byte("192.168.0.10"),
+			"port":     []byte("3307"),
+			"user":     []byte("migrate"),
+			"password": []byte("pwd"),
+		},
+	}).Build()
+	return &StatusSyncer{
+		MysqlCluster: mysqlcluster.New(cluster),
+		cli:          cli,
+		log:          logf.Log.WithName("test"),
+	}
+}
+
+func slaveStatus(host string, port int64) *sqltest.Result {
+	return &sqltest.Result{
+		Columns: []string{"Master_Host", "Master_Port"},
+		Rows:    [][]driver.Value{{host, port}},
+	}
+}
+
+func TestApplyStandby(t *testing.T) {
+	ctx := context.TODO()
+	standby := &apiv1alpha1.MySQLStandbySpec{Enabled: true, ClusterName: "source"}
+	superReadOnly := &sqltest.Result{Rows: [][]driver.Value{{int64(1)}}}
+
+	// The leader is pointed to the source once.
+	s := newStandbyStatusSyncer(standby)
+	assert.NoError(t, s.reconcileStandby(ctx))
+	assert.Equal(t, "source-leader.default:3306", s.Status.StandbySource)
+	server := &sqltest.Server{}
+	server.SetResult(selectSuperReadOnly, superReadOnly)
+	sqlRunner := internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus, selectSuperReadOnly, changeStandbyMaster}, server.Stmts())
+
+	// Nothing is changed if the leader already follows the source.
+	server = &sqltest.Server{}
+	server.SetResult(showSlaveStatus, slaveStatus("source-leader.default", 3306))
+	server.SetResult(selectSuperReadOnly, superReadOnly)
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus, selectSuperReadOnly}, server.Stmts())
+
+	// A follower is only kept super read only.
+	server = &sqltest.Server{}
+	server.SetResult(selectSuperReadOnly, &sqltest.Result{Rows: [][]driver.Value{{int64(0)}}})
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-1", false))
+	assert.Equal(t, []string{showSlaveStatus, selectSuperReadOnly, "SET GLOBAL super_read_only=on;"}, server.Stmts())
+
+	// Removing the standby block promotes the leader following the recorded source.
+	s.Spec.Standby = nil
+	server = &sqltest.Server{}
+	server.SetResult(showSlaveStatus, slaveStatus("source-leader.default", 3306))
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus, promoteStandby}, server.Stmts())
+	assert.Equal(t, "", s.Status.StandbySource)
+	// The promoted cluster is left alone.
+	assert.NoError(t, s.reconcileStandby(ctx))
+
+	// A leader that does not follow the source any more is not touched.
+	s = newStandbyStatusSyncer(&apiv1alpha1.MySQLStandbySpec{Enabled: false, ClusterName: "source"})
+	s.Status.StandbySource = "source-leader.default:3306"
+	server = &sqltest.Server{}
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus}, server.Stmts())
+	assert.Equal(t, "", s.Status.StandbySource)
+}
+
+func TestApplyStandbyRemoteSource(t *testing.T) {
+	ctx := context.TODO()
+	s := newStandbyStatusSyncer(nil)
+	s.Spec.RemoteSourceSecretName = "remote-secret"
+
+	// The cluster bootstrapped from the remote mysql keeps replicating from it.
+	assert.NoError(t, s.reconcileStandby(ctx))
+	assert.Equal(t, "192.168.0.10:3307", s.Status.StandbySource)
+	server := &sqltest.Server{}
+	server.SetResult(selectSuperReadOnly, &sqltest.Result{Rows: [][]driver.Value{{int64(1)}}})
+	sqlRunner := internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus, selectSuperReadOnly,
+		"STOP SLAVE;CHANGE MASTER TO MASTER_HOST=192.168.0.10, MASTER_PORT=3307, " +
+			"MASTER_USER=migrate, MASTER_PASSWORD=pwd,\nMASTER_AUTO_POSITION=1;START SLAVE;"}, server.Stmts())
+
+	// Removing the remote source promotes the cluster.
+	s.Spec.RemoteSourceSecretName = ""
+	server = &sqltest.Server{}
+	server.SetResult(showSlaveStatus, slaveStatus("192.168.0.10", 3307))
+	sqlRunner = internal.NewSQLRunnerFromDB(sqltest.NewDB(t, server))
+	assert.NoError(t, s.applyStandby(ctx, sqlRunner, "sample-mysql-0", true))
+	assert.Equal(t, []string{showSlaveStatus, promoteStandby}, server.Stmts())
+	assert.Equal(t, "", s.Status.StandbySource)
+}
@@ -66,16 +66,21 @@ type StatefulSetSyncer struct {
 	// Secret resourceVersion.
 	sctRev string
 
+	// TLS secret resourceVersion, empty if the certificates are not generated by the operator.
+	tlsRev string
+
 	// Mysql query runner.
 	internal.SQLRunnerFactory
 	// XenonExecutor is used to execute Xenon HTTP instructions.
 	internal.XenonExecutor
+	// semiChecker syncs the semi-sync check of xenon, a PodExecutor is created if it is nil.
+	semiChecker internal.XenonSemiChecker
 	// logger
 	log logr.Logger
 }
 
 // NewStatefulSetSyncer returns a pointer to StatefulSetSyncer.
-func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev, sctRev string, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatefulSetSyncer {
+func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev, sctRev, tlsRev string, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatefulSetSyncer {
 	return &StatefulSetSyncer{
 		MysqlCluster: c,
 		cli:          cli,
@@ -91,6 +96,7 @@ func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev
 		},
 		cmRev:            cmRev,
 		sctRev:           sctRev,
+		tlsRev:           tlsRev,
 		SQLRunnerFactory: sqlRunnerFactory,
 		XenonExecutor:    xenonExecutor,
 		log:              logf.Log.WithName("StatefulSetSyncer"),
@@ -153,8 +159,8 @@ func (s *StatefulSetSyncer) Sync(ctx context.Context) (syncer.SyncResult, error)
 	default:
 		// readonly node processing.
 		s.SfsReadOnly(ctx)
-		if err = s.SingleLeaderAction(); err != nil {
-			s.log.Error(err, "single Leader operation", "key", key, "kind", kind)
+		if err = s.SyncXenonSemiCheck(ctx); err != nil {
+			s.log.Error(err, "failed to sync the xenon's semicheck", "key", key, "kind", kind)
 		}
 		result.SetEventData("Normal", basicEventReason(s.Name, err),
 			fmt.Sprintf("%s %s %s successfully", kind, key, result.Operation))
@@ -395,6 +401,10 @@ func (s *StatefulSetSyncer) mutate() error {
 	}
 	s.sfs.Spec.Template.ObjectMeta.Annotations["config_rev"] = s.cmRev
 	s.sfs.Spec.Template.ObjectMeta.Annotations["secret_rev"] = s.sctRev
+	// Roll the pods to load the renewed certificates.
+	if len(s.tlsRev) != 0 {
+		s.sfs.Spec.Template.ObjectMeta.Annotations["tls_rev"] = s.tlsRev
+	}
 
 	err := mergo.Merge(&s.sfs.Spec.Template.Spec, s.ensurePodSpec(), mergo.WithTransformers(transformers.PodSpec))
 	if err != nil {
@@ -432,6 +442,9 @@ func (s *StatefulSetSyncer) ensurePodSpec() corev1.PodSpec {
 	xenon := container.EnsureContainer(utils.ContainerXenonName, s.MysqlCluster)
 	backup := container.EnsureContainer(utils.ContainerBackupName, s.MysqlCluster)
 	containers := []corev1.Container{mysql, xenon, backup}
+	if s.Spec.BinlogArchive != nil {
+		containers = append(containers, container.EnsureContainer(utils.ContainerBinlogName, s.MysqlCluster))
+	}
 	if s.Spec.MetricsOpts.Enabled {
 		containers = append(containers, container.EnsureContainer(utils.ContainerMetricsName, s.MysqlCluster))
 	}
@@ -627,33 +640,76 @@ func (s *StatefulSetSyncer) podsAllUpdated(ctx context.Context) bool {
 	return len(podlist.Items) == 0
 }
 
-// Execute Comm
-func (s *StatefulSetSyncer) SingleLeaderAction() error {
-	if *s.Spec.Replicas != 1 {
-		return nil
-	}
+// SyncXenonSemiCheck keeps the semi-sync check of xenon following the replication mode, it
+// is disabled in async. The check is reset when xenon restarts, so the state applied is
+// recorded in the pod annotation with the restart count of xenon.
+func (s *StatefulSetSyncer) SyncXenonSemiCheck(ctx context.Context) error {
 	if s.Status.State != apiv1alpha1.ClusterReadyState {
 		return nil
 	}
-	// 1. close the xenon's SemiCheck.
-	executor, err := internal.NewPodExecutor()
+	mode, _ := s.GetReplicationMode()
+	enabled := mode == apiv1alpha1.ReplicationModeSemiSync
+
+	labelSelector := s.GetLabels().AsSelector()
+	r, err := labels.NewRequirement("readonly", selection.DoesNotExist, []string{})
 	if err != nil {
 		return err
 	}
-	podName := fmt.Sprintf("%s-mysql-0", s.Name)
-	err = executor.CloseXenonSemiCheck(s.Namespace, podName)
-	s.log.Info("close the xenon's semicheck", "pod", podName)
-	if err != nil {
+	labelSelector = labelSelector.Add(*r)
+	podlist := corev1.PodList{}
+	if err := s.cli.List(ctx, &podlist, &client.ListOptions{
+		Namespace:     s.Namespace,
+		LabelSelector: labelSelector,
+	}); err != nil {
 		return err
 	}
-	// 2. close mysql semi
 
-	// SET GLOBAL rpl_semi_sync_master_enabled=OFF
-	err = executor.SetGlobalSysVar(s.Namespace, podName, "SET GLOBAL rpl_semi_sync_master_enabled=off")
-	s.log.Info("close the mysql's rpl_semi_sync_master_enabled", "pod", podName)
-	if err != nil {
-		return err
-	}
+	for i := range podlist.Items {
+		pod := &podlist.Items[i]
+		state, ok := getXenonSemiCheckState(pod, enabled)
+		if !ok || pod.Annotations[utils.AnnotationXenonSemiCheck] == state {
+			continue
+		}
+		if s.semiChecker == nil {
+			executor, err := internal.NewPodExecutor()
+			if err != nil {
+				return err
+			}
+			s.semiChecker = executor
+		}
+		if enabled {
+			err = s.semiChecker.EnableXenonSemiCheck(s.Namespace, pod.Name)
+		} else {
+			err = s.semiChecker.CloseXenonSemiCheck(s.Namespace, pod.Name)
+		}
+		if err != nil {
+			return err
+		}
+		s.log.Info("sync the xenon's semicheck", "pod", pod.Name, "enabled", enabled)
 
+		oldPod := pod.DeepCopy()
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[utils.AnnotationXenonSemiCheck] = state
+		if err := s.cli.Patch(ctx, pod, client.MergeFrom(oldPod)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// getXenonSemiCheckState returns the state of the semi-sync check with the restart count
+// of xenon, such as enabled/0. It returns false if xenon is not ready.
+func getXenonSemiCheckState(pod *corev1.Pod, enabled bool) (string, bool) {
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == utils.ContainerXenonName {
+			return fmt.Sprintf("%s/%d", state, status.RestartCount), status.Ready
+		}
+	}
+	return "", false
+}
@@ -19,6 +19,7 @@ package syncer
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -145,3 +146,25 @@ func TestStatefulSetSyncer_sfsUpdated(t *testing.T) {
 		})
 	}
 }
+
+func TestGetXenonSemiCheckState(t *testing.T) {
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: "mysql", Ready: true},
+				{Name: "xenon", Ready: true, RestartCount: 2},
+			},
+		},
+	}
+	state, ok := getXenonSemiCheckState(pod, true)
+	assert.True(t, ok)
+	assert.Equal(t, "enabled/2", state)
+	state, ok = getXenonSemiCheckState(pod, false)
+	assert.True(t, ok)
+	assert.Equal(t, "disabled/2", state)
+
+	// xenon is not ready.
+	pod.Status.ContainerStatuses[1].Ready = false
+	_, ok = getXenonSemiCheckState(pod, true)
+	assert.False(t, ok)
+}
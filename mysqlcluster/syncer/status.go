@@ -24,12 +24,14 @@ import (
 	"time"
 
 	"github.com/presslabs/controller-util/pkg/syncer"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -57,17 +59,24 @@ type StatusSyncer struct {
 	internal.SQLRunnerFactory
 	// XenonExecutor is used to execute Xenon HTTP instructions.
 	internal.XenonExecutor
+	// The revision of the static configs in the statefulset template.
+	configRev string
+	// The dynamic configs in the mysql configmap.
+	dynamicConfigs map[string]string
+	// Recorder records the fencing events.
+	recorder record.EventRecorder
 	// Logger
 	log logr.Logger
 }
 
 // NewStatusSyncer returns a pointer to StatusSyncer.
-func NewStatusSyncer(c *mysqlcluster.MysqlCluster, cli client.Client, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatusSyncer {
+func NewStatusSyncer(c *mysqlcluster.MysqlCluster, cli client.Client, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor, recorder record.EventRecorder) *StatusSyncer {
 	return &StatusSyncer{
 		MysqlCluster:     c,
 		cli:              cli,
 		SQLRunnerFactory: sqlRunnerFactory,
 		XenonExecutor:    xenonExecutor,
+		recorder:         recorder,
 		log:              logf.Log.WithName("syncer.StatusSyncer"),
 	}
 }
@@ -89,6 +98,16 @@ func (s *StatusSyncer) GetOwner() runtime.Object { return s.MysqlCluster }
 // Sync persists data into the external store.
 func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 	clusterCondition := s.updateClusterStatus()
+	sfs := &appsv1.StatefulSet{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: s.GetNameForResource(utils.StatefulSet), Namespace: s.Namespace}, sfs); err == nil {
+		s.configRev = sfs.Spec.Template.Annotations["config_rev"]
+	}
+	cm := &corev1.ConfigMap{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: s.GetNameForResource(utils.ConfigMap), Namespace: s.Namespace}, cm); err == nil {
+		if s.dynamicConfigs, err = getDynamicConfigs(getMysqlMajorVersion(s.MysqlCluster), cm.Data); err != nil {
+			s.log.Error(err, "failed to get the dynamic configs")
+		}
+	}
 	labelSelector := s.GetLabels().AsSelector()
 	// Find the pods that revision is old.
 	r, err := labels.NewRequirement("readonly", selection.DoesNotExist, []string{})
@@ -171,7 +190,13 @@ func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 		s.log.Error(err, "ReadOnly pod fail", "namespace", s.Namespace)
 	}
 	// Update all nodes' status.
-	return syncer.SyncResult{}, s.updateNodeStatus(ctx, s.cli, list.Items)
+	if err := s.updateNodeStatus(ctx, s.cli, list.Items); err != nil {
+		return syncer.SyncResult{}, err
+	}
+	if err := s.reconcileStandby(ctx); err != nil {
+		s.log.Error(err, "failed to reconcile standby", "namespace", s.Namespace)
+	}
+	return syncer.SyncResult{}, nil
 }
 
 // updateClusterStatus update the cluster status and returns condition.
@@ -270,10 +295,13 @@ func (s *StatusSyncer) AutoRebuild(ctx context.Context, pod *corev1.Pod, items [
 // updateNodeStatus update the node status.
 func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client, pods []corev1.Pod) error {
 	closeCh := make(chan func())
-	for _, pod := range pods {
+	indexes := make([]int, len(pods))
+	degraded := false
+	for i, pod := range pods {
 		podName := pod.Name
 		host := fmt.Sprintf("%s.%s.%s", podName, s.GetNameForResource(utils.HeadlessSVC), s.Namespace)
 		index := s.getNodeStatusIndex(host)
+		indexes[i] = index
 		node := &s.Status.Nodes[index]
 		node.Message = ""
 
@@ -321,6 +349,15 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 				s.log.V(1).Info("failed to check read only", "node", node.Name, "error", err)
 				node.Message = err.Error()
 			}
+
+			node.Config = s.applyDynamicConfigs(sqlRunner, &pod)
+
+			isLeader := node.RaftStatus.Role == string(utils.Leader)
+			if leaderDegraded, err := s.applyReplicationMode(sqlRunner, isLeader); err != nil {
+				s.log.V(1).Info("failed to apply the replication mode", "node", node.Name, "error", err)
+			} else if isLeader {
+				degraded = leaderDegraded
+			}
 			// move it to mysql readiness
 			// if !utils.ExistUpdateFile() &&
 			// 	node.RaftStatus.Role == string(utils.Leader) &&
@@ -337,9 +374,33 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 		s.updateNodeCondition(node, int(apiv1alpha1.IndexReplicating), isReplicating)
 		// update apiv1alpha1.NodeConditionReadOnly.
 		s.updateNodeCondition(node, int(apiv1alpha1.IndexReadOnly), isReadOnly)
+	}
+
+	mode, acks := s.GetReplicationMode()
+	s.Status.Replication = &apiv1alpha1.ReplicationStatus{
+		Mode:              mode,
+		WaitForSlaveCount: acks,
+		Degraded:          degraded,
+	}
 
-		if err = s.updatePodLabel(ctx, &pod, node); err != nil {
+	// The labels are updated after all the nodes, the stale leaders can only be found with
+	// the raft status of all the nodes.
+	nodes := make([]apiv1alpha1.NodeStatus, len(pods))
+	for i, index := range indexes {
+		nodes[i] = s.Status.Nodes[index]
+	}
+	fenced := getFencedNodes(pods, nodes, int(*s.Spec.Replicas))
+	for i := range pods {
+		pod := &pods[i]
+		isLeaderLabeled := pod.Labels["role"] == string(utils.Leader)
+		if err := s.updatePodLabel(ctx, pod, &s.Status.Nodes[indexes[i]], fenced[pod.Name]); err != nil {
 			s.log.V(1).Info("failed to update labels", "pod", pod.Name, "error", err)
+			continue
+		}
+		if fenced[pod.Name] {
+			if err := s.fenceLeader(ctx, pod, isLeaderLabeled); err != nil {
+				s.log.Error(err, "failed to fence the stale leader", "pod", pod.Name)
+			}
 		}
 	}
 
@@ -482,7 +543,7 @@ func (s *StatusSyncer) addNodesInXenon(host string, toAdd []string) error {
 }
 
 // updatePodLabel update the pod lables.
-func (s *StatusSyncer) updatePodLabel(ctx context.Context, pod *corev1.Pod, node *apiv1alpha1.NodeStatus) error {
+func (s *StatusSyncer) updatePodLabel(ctx context.Context, pod *corev1.Pod, node *apiv1alpha1.NodeStatus, fenced bool) error {
 	oldPod := pod.DeepCopy()
 	healthy := "no"
 	isPodLabelsUpdated := false
@@ -495,6 +556,12 @@ func (s *StatusSyncer) updatePodLabel(ctx context.Context, pod *corev1.Pod, node
 			node.Conditions[apiv1alpha1.IndexReplicating].Status == corev1.ConditionFalse &&
 			node.Conditions[apiv1alpha1.IndexReadOnly].Status == corev1.ConditionFalse {
 			healthy = "yes"
+		} else if s.IsStandby() &&
+			node.Conditions[apiv1alpha1.IndexLeader].Status == corev1.ConditionTrue &&
+			node.Conditions[apiv1alpha1.IndexReplicating].Status == corev1.ConditionTrue &&
+			node.Conditions[apiv1alpha1.IndexReadOnly].Status == corev1.ConditionTrue {
+			// The standby leader replicates from the source and keeps read only.
+			healthy = "yes"
 		}
 	}
 	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
@@ -510,12 +577,23 @@ func (s *StatusSyncer) updatePodLabel(ctx context.Context, pod *corev1.Pod, node
 		}
 	}
 
+	role := node.RaftStatus.Role
+	// The stale leader has no role label, so that no service selects it.
+	if fenced {
+		healthy = "no"
+		role = ""
+	}
+
 	if pod.Labels["healthy"] != healthy {
 		pod.Labels["healthy"] = healthy
 		isPodLabelsUpdated = true
 	}
-	if pod.Labels["role"] != node.RaftStatus.Role {
-		pod.Labels["role"] = node.RaftStatus.Role
+	if pod.Labels["role"] != role {
+		if role == "" {
+			delete(pod.Labels, "role")
+		} else {
+			pod.Labels["role"] = role
+		}
 		isPodLabelsUpdated = true
 	}
 	if isPodLabelsUpdated {
@@ -619,6 +697,8 @@ func (s *StatusSyncer) RoCheckStatus(ctx context.Context, cli client.Client, pod
 			if _, isReplicating, err = internal.CheckSlaveStatus(sqlRunner); err != nil {
 				node.Message = err.Error()
 			}
+			// 4. apply the dynamic configs
+			node.Config = s.applyDynamicConfigs(sqlRunner, &pod)
 		}
 		//update node Rostatus
 		node.RoStatus = &apiv1alpha1.RoStatus{
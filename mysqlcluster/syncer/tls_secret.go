@@ -0,0 +1,112 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"encoding/pem"
+	"fmt"
+
+	"github.com/presslabs/controller-util/pkg/syncer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// NewTLSSecretSyncer returns the syncer of the secret which contains the CA and the
+// certificate generated by the operator. The certificate is renewed before expiry or
+// when the SANs change, the statefulset rolls the pods when the secret is updated.
+func NewTLSSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Interface {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.GetNameForResource(utils.TLSSecret),
+			Namespace: c.Namespace,
+			Labels:    c.GetLabels(),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	return syncer.NewObjectSyncer("TLSSecret", c.Unwrap(), secret, cli, func() error {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+
+		// The first certificate of ca.crt is the CA which signs the certificate.
+		caRenewed := false
+		if utils.CertNeedsRenewal(secret.Data[utils.TLSCAKey], nil, utils.CertRenewBefore) {
+			caCert, caKey, err := utils.NewCA(fmt.Sprintf("%s-ca", c.Name), utils.CAValidity)
+			if err != nil {
+				return fmt.Errorf("failed to generate the CA: %s", err)
+			}
+			// Keep trusting the old CA until all the pods use the new certificate.
+			bundle := caCert
+			if old := secret.Data[utils.TLSCAKey]; !utils.CertNeedsRenewal(old, nil, 0) {
+				_, rest := pem.Decode(old)
+				bundle = append(bundle, old[:len(old)-len(rest)]...)
+			}
+			secret.Data[utils.TLSCAKey] = bundle
+			secret.Data[utils.TLSCAPKey] = caKey
+			caRenewed = true
+		}
+
+		names := getTLSNames(c)
+		if caRenewed || utils.CertNeedsRenewal(secret.Data[utils.TLSCertKey], names, utils.CertRenewBefore) {
+			cert, key, err := utils.NewSignedCert(secret.Data[utils.TLSCAKey], secret.Data[utils.TLSCAPKey],
+				c.Name, names, utils.CertValidity)
+			if err != nil {
+				return fmt.Errorf("failed to generate the certificate: %s", err)
+			}
+			secret.Data[utils.TLSCertKey] = cert
+			secret.Data[utils.TLSPKey] = key
+		}
+		return nil
+	})
+}
+
+// getTLSNames returns the SANs of the certificate, contains the services and the pods. The
+// pods are covered by the wildcards of the headless services, so that the certificate is
+// not reissued when the cluster scales.
+func getTLSNames(c *mysqlcluster.MysqlCluster) []string {
+	names := []string{"localhost", "127.0.0.1"}
+	addName := func(name string) {
+		names = append(names, name,
+			fmt.Sprintf("%s.%s", name, c.Namespace),
+			fmt.Sprintf("%s.%s.svc", name, c.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", name, c.Namespace),
+		)
+	}
+	for _, svc := range []utils.ResourceName{utils.LeaderService, utils.FollowerService, utils.HeadlessSVC,
+		utils.ReadOnlyHeadlessSVC, utils.ReadOnlySvc} {
+		addName(c.GetNameForResource(svc))
+	}
+
+	for _, svc := range []utils.ResourceName{utils.HeadlessSVC, utils.ReadOnlyHeadlessSVC} {
+		headless := c.GetNameForResource(svc)
+		names = append(names,
+			fmt.Sprintf("*.%s.%s", headless, c.Namespace),
+			fmt.Sprintf("*.%s.%s.svc", headless, c.Namespace),
+			fmt.Sprintf("*.%s.%s.svc.cluster.local", headless, c.Namespace),
+		)
+	}
+	return names
+}
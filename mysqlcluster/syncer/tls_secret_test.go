@@ -0,0 +1,65 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestGetTLSNames(t *testing.T) {
+	replicas := int32(3)
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			Replicas:  &replicas,
+			ReadOnlys: &apiv1alpha1.ReadOnlyType{Num: 1},
+		},
+	}
+	names := getTLSNames(mysqlcluster.New(cluster))
+
+	// The names do not change when the cluster scales.
+	scaled := cluster.DeepCopy()
+	replicas = 5
+	scaled.Spec.Replicas = &replicas
+	scaled.Spec.ReadOnlys.Num = 3
+	assert.Equal(t, names, getTLSNames(mysqlcluster.New(scaled)))
+
+	caCert, caKey, err := utils.NewCA("sample-ca", utils.CAValidity)
+	assert.NoError(t, err)
+	certPEM, _, err := utils.NewSignedCert(caCert, caKey, "sample", names, utils.CertValidity)
+	assert.NoError(t, err)
+	cert, err := utils.ParseCert(certPEM)
+	assert.NoError(t, err)
+	for _, host := range []string{
+		"sample-leader",
+		"sample-mysql-4.sample-mysql.default",
+		"sample-mysql-4.sample-mysql.default.svc",
+		"sample-mysql-4.sample-mysql.default.svc.cluster.local",
+		"sample-ro-2.sample-ro.default.svc",
+		"127.0.0.1",
+	} {
+		assert.NoError(t, cert.VerifyHostname(host), host)
+	}
+	assert.Error(t, cert.VerifyHostname("sample-mysql-4.other.default.svc"))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqldatabase
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// UpdateStatusCondition sets the condition to a status.
+// for example Ready condition to True, or False.
+func (d *MysqlDatabase) UpdateStatusCondition(
+	condType apiv1alpha1.MysqlDatabaseConditionType,
+	status corev1.ConditionStatus, reason, message string,
+) (
+	cond *apiv1alpha1.MySQLDatabaseCondition, changed bool,
+) {
+	t := metav1.NewTime(time.Now())
+
+	existingCondition, exists := d.ConditionExists(condType)
+	if !exists {
+		newCondition := apiv1alpha1.MySQLDatabaseCondition{
+			Type:               condType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: t,
+			LastUpdateTime:     t,
+		}
+		d.Status.Conditions = append(d.Status.Conditions, newCondition)
+
+		return &newCondition, true
+	}
+
+	if status != existingCondition.Status {
+		existingCondition.LastTransitionTime = t
+		changed = true
+	}
+
+	if message != existingCondition.Message || reason != existingCondition.Reason {
+		existingCondition.LastUpdateTime = t
+		changed = true
+	}
+
+	existingCondition.Status = status
+	existingCondition.Message = message
+	existingCondition.Reason = reason
+
+	return existingCondition, changed
+}
+
+// ConditionExists returns a condition and whether it exists.
+func (d *MysqlDatabase) ConditionExists(
+	ct apiv1alpha1.MysqlDatabaseConditionType,
+) (
+	*apiv1alpha1.MySQLDatabaseCondition, bool,
+) {
+	for i := range d.Status.Conditions {
+		cond := &d.Status.Conditions[i]
+		if cond.Type == ct {
+			return cond, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlrole
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+const (
+	// ProvisionFailedReason is the condition reason when MysqlRole provisioning
+	// has failed.
+	ProvisionFailedReason = "ProvisionFailed"
+
+	// ProvisionSucceededReason the reason used when provision was successful.
+	ProvisionSucceededReason = "ProvisionSucceeded"
+
+	// ExpandedReason the reason used when the role is not supported by MySQL, and the
+	// privileges are granted to the users directly.
+	ExpandedReason = "Expanded"
+)
+
+// MysqlRole is a type wrapper over MysqlRole that contains the Business logic.
+type MysqlRole struct {
+	*apiv1alpha1.MysqlRole
+}
+
+// New returns a wraper object over MysqlRole.
+func New(mysqlRole *apiv1alpha1.MysqlRole) *MysqlRole {
+	return &MysqlRole{
+		MysqlRole: mysqlRole,
+	}
+}
+
+// Unwrap returns the api MysqlRole object.
+func (r *MysqlRole) Unwrap() *apiv1alpha1.MysqlRole {
+	return r.MysqlRole
+}
+
+// GetClusterKey returns the MysqlRole's MySQLCluster key.
+func (r *MysqlRole) GetClusterKey() client.ObjectKey {
+	ns := r.Spec.RoleOwner.NameSpace
+	if ns == "" {
+		ns = r.Namespace
+	}
+
+	return client.ObjectKey{
+		Name:      r.Spec.RoleOwner.ClusterName,
+		Namespace: ns,
+	}
+}
+
+// GetKey return the role key. Usually used for logging or for runtime.Client.Get as key.
+func (r *MysqlRole) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: r.Namespace,
+		Name:      r.Name,
+	}
+}
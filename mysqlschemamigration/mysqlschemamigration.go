@@ -0,0 +1,73 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlschemamigration
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+const (
+	// MigrationFailedReason is the condition reason when a migration has failed.
+	MigrationFailedReason = "MigrationFailed"
+
+	// MigratingReason the reason used when a migration is running by the online tool.
+	MigratingReason = "Migrating"
+
+	// MigrationSucceededReason the reason used when all the migrations are applied.
+	MigrationSucceededReason = "MigrationSucceeded"
+)
+
+// MysqlSchemaMigration is a type wrapper over MysqlSchemaMigration that contains the Business logic.
+type MysqlSchemaMigration struct {
+	*apiv1alpha1.MysqlSchemaMigration
+}
+
+// New returns a wraper object over MysqlSchemaMigration.
+func New(mysqlSchemaMigration *apiv1alpha1.MysqlSchemaMigration) *MysqlSchemaMigration {
+	return &MysqlSchemaMigration{
+		MysqlSchemaMigration: mysqlSchemaMigration,
+	}
+}
+
+// Unwrap returns the api MysqlSchemaMigration object.
+func (m *MysqlSchemaMigration) Unwrap() *apiv1alpha1.MysqlSchemaMigration {
+	return m.MysqlSchemaMigration
+}
+
+// GetClusterKey returns the MysqlSchemaMigration's MySQLCluster key.
+func (m *MysqlSchemaMigration) GetClusterKey() client.ObjectKey {
+	ns := m.Spec.MigrationOwner.NameSpace
+	if ns == "" {
+		ns = m.Namespace
+	}
+
+	return client.ObjectKey{
+		Name:      m.Spec.MigrationOwner.ClusterName,
+		Namespace: ns,
+	}
+}
+
+// GetKey return the migration key. Usually used for logging or for runtime.Client.Get as key.
+func (m *MysqlSchemaMigration) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: m.Namespace,
+		Name:      m.Name,
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlswitchover
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// MysqlSwitchover is a type wrapper over MysqlSwitchover that contains the Business logic.
+type MysqlSwitchover struct {
+	*apiv1alpha1.MysqlSwitchover
+}
+
+// New returns a wraper object over MysqlSwitchover.
+func New(mysqlSwitchover *apiv1alpha1.MysqlSwitchover) *MysqlSwitchover {
+	return &MysqlSwitchover{
+		MysqlSwitchover: mysqlSwitchover,
+	}
+}
+
+// Unwrap returns the api MysqlSwitchover object.
+func (s *MysqlSwitchover) Unwrap() *apiv1alpha1.MysqlSwitchover {
+	return s.MysqlSwitchover
+}
+
+// GetClusterKey returns the MysqlSwitchover's MySQLCluster key.
+func (s *MysqlSwitchover) GetClusterKey() client.ObjectKey {
+	return client.ObjectKey{
+		Name:      s.Spec.ClusterName,
+		Namespace: s.Namespace,
+	}
+}
+
+// GetKey return the switchover key. Usually used for logging or for runtime.Client.Get as key.
+func (s *MysqlSwitchover) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: s.Namespace,
+		Name:      s.Name,
+	}
+}
+
+// IsFinished returns whether the switchover has finished.
+func (s *MysqlSwitchover) IsFinished() bool {
+	switch s.Status.Phase {
+	case apiv1alpha1.SwitchoverSucceeded, apiv1alpha1.SwitchoverFailed, apiv1alpha1.SwitchoverRolledBack:
+		return true
+	}
+	return false
+}
+
+// IsTimedOut returns whether the switchover is still running after the timeout.
+func (s *MysqlSwitchover) IsTimedOut(now time.Time) bool {
+	return s.Status.StartTime != nil && now.After(s.Status.StartTime.Add(s.Spec.Timeout.Duration))
+}
+
+// SetPhase sets the phase and the message of the switchover, the completion time is set
+// if the switchover finished.
+func (s *MysqlSwitchover) SetPhase(phase apiv1alpha1.SwitchoverPhase, message string) {
+	s.Status.Phase = phase
+	s.Status.Message = message
+	if s.IsFinished() && s.Status.CompletionTime == nil {
+		now := metav1.Now()
+		s.Status.CompletionTime = &now
+	}
+}
@@ -20,14 +20,19 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"strconv"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -52,6 +57,23 @@ type BackupClientConfig struct {
 	XtrabackupTargetDir string `json:"xtrabackup_target_dir"`
 	// BackupType is a backup type for xtrabackup. s3 or disk
 	BackupType BkType `json:"backup_type"`
+	// HostName is the host where the backup is taken.
+	HostName string `json:"host_name"`
+	// Incremental takes an incremental backup based on the last backup.
+	Incremental bool `json:"incremental"`
+	// IncrementalLSN is the to_lsn of the parent backup.
+	IncrementalLSN string `json:"incremental_lsn"`
+	// BackupChain is the backups which the incremental backup is based on,
+	// from the full backup to the parent.
+	BackupChain []string `json:"backup_chain"`
+	// Compression is the compression algorithm of the backup, zstd or qpress.
+	Compression string `json:"compression"`
+	// Encrypt asks the backup server to encrypt the backup with its own key.
+	Encrypt bool `json:"encrypt"`
+	// EncryptKey decrypts the backup in the verification, it is never sent to the server.
+	EncryptKey string `json:"-"`
+	// The credentials of azure or gcs.
+	CloudStorageConfig
 }
 
 type BkType string
@@ -61,8 +83,20 @@ const (
 	S3 BkType = "s3"
 	// BackupTypeDisk is a backup type for xtrabackup. disk
 	NFS BkType = "nfs"
+	// PVC stores the backups in the PersistentVolumeClaim mounted at /backup.
+	PVC BkType = "pvc"
+	// Local stores the backups in the directory of the host mounted at /backup.
+	Local BkType = "local"
+	// Azure stores the backups in azure blob storage.
+	Azure BkType = "azure"
+	// GCS stores the backups in google cloud storage.
+	GCS BkType = "gcs"
 )
 
+// backupChainFile is saved in every backup, it lists the backups needed to restore,
+// from the full backup to this one.
+const backupChainFile = "backup_chain"
+
 // NewReqBackupConfig returns the configuration file needed for backup job call /backup.
 // The configuration file is obtained from the environment variables.
 func NewReqBackupConfig() *BackupClientConfig {
@@ -80,9 +114,25 @@ func NewReqBackupConfig() *BackupClientConfig {
 		XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
 		XCloudS3Bucket:    getEnvValue("S3_BUCKET"),
 		BackupType:        BkType(getEnvValue("BACKUP_TYPE")),
+		Incremental:       getEnvValue("BACKUP_INCREMENTAL") == "true",
+		Compression:       getEnvValue("BACKUP_COMPRESSION"),
+		Encrypt:           getEnvValue("BACKUP_ENCRYPT") == "true",
+		EncryptKey:        getEnvValue("BACKUP_ENCRYPT_KEY"),
+
+		CloudStorageConfig: newCloudStorageConfig(),
 	}
 }
 
+// storageBackend returns the backend of the backup type.
+func (cfg *BackupClientConfig) storageBackend() (StorageBackend, error) {
+	return newStorageBackend(cfg.BackupType, s3Credentials{
+		endpoint:  cfg.XCloudS3EndPoint,
+		accessKey: cfg.XCloudS3AccessKey,
+		secretKey: cfg.XCloudS3SecretKey,
+		bucket:    cfg.XCloudS3Bucket,
+	}, cfg.CloudStorageConfig)
+}
+
 // Build xbcloud arguments
 func (cfg *BackupClientConfig) XCloudArgs(backupName string) []string {
 	xcloudArgs := []string{
@@ -114,6 +164,9 @@ func (cfg *BackupClientConfig) XtrabackupArgs() []string {
 		fmt.Sprintf("--password=%s", cfg.RootPassword),
 		fmt.Sprintf("--target-dir=%s", tmpdir),
 	}
+	if len(cfg.IncrementalLSN) != 0 {
+		xtrabackupArgs = append(xtrabackupArgs, fmt.Sprintf("--incremental-lsn=%s", cfg.IncrementalLSN))
+	}
 
 	return append(xtrabackupArgs, cfg.XtrabackupExtraArgs...)
 }
@@ -122,7 +175,7 @@ func (cfg *BackupClientConfig) XBackupName() (string, string) {
 	return utils.BuildBackupName(cfg.ClusterName)
 }
 
-func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, BackupType string, BackupSize int64) error {
+func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, BackupType string, BackupSize int64, toLSN string) error {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return err
@@ -144,6 +197,9 @@ func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, B
 	job.Annotations[utils.JobAnonationDate] = DateTime
 	job.Annotations[utils.JobAnonationType] = BackupType
 	job.Annotations[utils.JobAnonationSize] = strconv.FormatInt(BackupSize, 10)
+	job.Annotations[utils.JobAnonationHost] = cfg.HostName
+	job.Annotations[utils.JobAnonationToLSN] = toLSN
+	job.Annotations[utils.JobAnonationChain] = strings.Join(append(cfg.BackupChain, backname), ",")
 	_, err = clientset.BatchV1().Jobs(cfg.NameSpace).Update(context.TODO(), job, metav1.UpdateOptions{})
 	if err != nil {
 		return err
@@ -151,74 +207,227 @@ func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, B
 	return nil
 }
 
-func RunTakeS3BackupCommand(cfg *BackupClientConfig) (string, string, int64, error) {
+func RunTakeS3BackupCommand(cfg *BackupClientConfig) (string, string, string, int64, error) {
+	backend, err := cfg.storageBackend()
+	if err != nil {
+		return "", "", "", 0, err
+	}
+
+	// Keep a copy of the xtrabackup_checkpoints to get the to_lsn.
+	lsnDir, err := ioutil.TempDir("", "backup-lsn")
+	if err != nil {
+		return "", "", "", 0, err
+	}
+	defer os.RemoveAll(lsnDir)
+
+	// Compress and encrypt the backup before it leaves the host.
+	streamArgs, cleanup, err := xtrabackupStreamArgs(cfg.Compression, cfg.EncryptKey)
+	if err != nil {
+		return "", "", "", 0, err
+	}
+	defer cleanup()
+
 	// cfg->XtrabackupArgs()
-	xtrabackup := exec.Command(xtrabackupCommand, cfg.XtrabackupArgs()...)
+	args := append(cfg.XtrabackupArgs(), streamArgs...)
+	xtrabackup := exec.Command(xtrabackupCommand, append(args, "--extra-lsndir="+lsnDir)...)
 
-	var err error
 	backupName, DateTime := cfg.XBackupName()
-	xcloud := exec.Command(xcloudCommand, cfg.XCloudArgs(backupName)...)
-	log.Info("xargs ", "xargs", strings.Join(cfg.XCloudArgs(backupName), " "))
+	log.Info("upload the backup", "backup", backupName, "type", cfg.BackupType)
 
-	// Create a pipe between xtrabackup and xcloud
+	// Create a pipe between xtrabackup and the storage
 	r, w := io.Pipe()
 	defer r.Close()
-	xcloud.Stdin = r
 
 	// Start xtrabackup command with stdout directed to the pipe
 	xtrabackupReader, err := xtrabackup.StdoutPipe()
 	if err != nil {
 		log.Error(err, "failed to create stdout pipe for xtrabackup")
-		return "", "", 0, err
+		return "", "", "", 0, err
 	}
 
-	// set xtrabackup and xcloud stderr to os.Stderr
+	// set xtrabackup stderr to os.Stderr
 	xtrabackup.Stderr = os.Stderr
-	xcloud.Stderr = os.Stderr
 
-	// Start xtrabackup and xcloud in separate goroutines
 	if err := xtrabackup.Start(); err != nil {
 		log.Error(err, "failed to start xtrabackup command")
-		return "", "", 0, err
-	}
-	if err := xcloud.Start(); err != nil {
-		log.Error(err, "fail start xcloud ")
-		return "", "", 0, err
+		return "", "", "", 0, err
 	}
 
 	// Use io.Copy to write xtrabackup output to the pipe while tracking the number of bytes written
 	var n int64
 	go func() {
-		n, err = io.Copy(w, xtrabackupReader)
-		if err != nil {
+		var err error
+		if n, err = io.Copy(w, xtrabackupReader); err != nil {
 			log.Error(err, "failed to write xtrabackup output to pipe")
+		} else if err = xtrabackup.Wait(); err != nil {
+			log.Error(err, "xtrabackup failed")
+		} else if err = writeBackupChain(w, lsnDir, append(cfg.BackupChain, backupName)); err != nil {
+			log.Error(err, "failed to write backup chain to pipe")
 		}
-		w.Close()
+		// The upload fails if the stream is incomplete.
+		w.CloseWithError(err)
 	}()
 
-	// Wait for xtrabackup and xcloud to finish
 	// pipe command fail one, whole things fail
-	errorChannel := make(chan error, 2)
-	go func() {
-		errorChannel <- xcloud.Wait()
-	}()
-	go func() {
-		errorChannel <- xtrabackup.Wait()
-	}()
-
-	for i := 0; i < 2; i++ {
-		if err = <-errorChannel; err != nil {
-			// If xtrabackup or xcloud failed, stop the pipe and kill the other command
-			log.Error(err, "xtrabackup or xcloud failed closing the pipe...")
-			xtrabackup.Process.Kill()
-			xcloud.Process.Kill()
-			return "", "", 0, err
-		}
+	if err = backend.Upload(backupName, r); err != nil {
+		log.Error(err, "xtrabackup or upload failed closing the pipe...")
+		xtrabackup.Process.Kill()
+		return "", "", "", 0, err
 	}
 
 	// Log backup size and upload speed
 	backupSizeMB := float64(n) / (1024 * 1024)
 	log.Info(fmt.Sprintf("Backup size: %.2f MB", backupSizeMB))
 
-	return backupName, DateTime, n, nil
+	toLSN, err := GetXtrabackupToLSN(lsnDir)
+	if err != nil {
+		log.Error(err, "failed to get the to_lsn of backup")
+	}
+	return backupName, DateTime, toLSN, n, nil
+}
+
+// writeBackupChain streams the backup chain file to the writer in xbstream format,
+// xbstream can extract the concatenated streams, so it is appended to the backup.
+func writeBackupChain(w io.Writer, dir string, chain []string) error {
+	if err := ioutil.WriteFile(path.Join(dir, backupChainFile), []byte(strings.Join(chain, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	xbstream := exec.Command("xbstream", "-c", "-C", dir, backupChainFile)
+	xbstream.Stdout = w
+	xbstream.Stderr = os.Stderr
+	return xbstream.Run()
+}
+
+// readBackupChain returns the backups needed to restore the backup in the directory,
+// from the full backup to this one. It returns nil if there is no backup chain file.
+func readBackupChain(dir string) []string {
+	content, err := ioutil.ReadFile(path.Join(dir, backupChainFile))
+	if err != nil {
+		return nil
+	}
+	var chain []string
+	for _, name := range strings.Split(string(content), "\n") {
+		if name = strings.TrimSpace(name); len(name) != 0 {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// setParentBackup finds the last successful backup of the cluster taken on the same host,
+// the incremental backup is based on it. A full backup is taken if there is none. The parent
+// is found in the records of the Backups, the jobs may be deleted by the history limit.
+func setParentBackup(cfg *BackupClientConfig) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	list, err := client.Resource(v1beta1.GroupVersion.WithResource("backups")).Namespace(cfg.NameSpace).
+		List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	backups := &v1beta1.BackupList{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.UnstructuredContent(), backups); err != nil {
+		return err
+	}
+	parent := getParentBackup(backups.Items, cfg.ClusterName, string(cfg.BackupType))
+	if parent == nil {
+		log.Info("no backup found, take a full backup")
+		return nil
+	}
+	// The LSN is different between the hosts.
+	if parent.BackupHost != cfg.HostName {
+		log.Info("the last backup is taken on another host, take a full backup",
+			"backup", parent.Name, "host", parent.BackupHost)
+		return nil
+	}
+
+	cfg.IncrementalLSN = parent.ToLSN
+	cfg.BackupChain = parent.BackupChain
+	if len(cfg.BackupChain) == 0 {
+		cfg.BackupChain = []string{parent.Name}
+	}
+	log.Info("take an incremental backup", "parent", parent.Name, "lsn", cfg.IncrementalLSN)
+	return nil
+}
+
+// getParentBackup returns the last succeeded backup of the cluster in the storage. The backups
+// pruned by the retention policy are not in the records.
+func getParentBackup(backups []v1beta1.Backup, clusterName, backupType string) *v1beta1.BackupRecord {
+	var parent *v1beta1.BackupRecord
+	for i := range backups {
+		if backups[i].Spec.ClusterName != clusterName {
+			continue
+		}
+		for j := range backups[i].Status.Backups {
+			record := &backups[i].Status.Backups[j]
+			if len(record.ToLSN) == 0 || record.CompletionTime == nil ||
+				!strings.EqualFold(record.BackupType, backupType) {
+				continue
+			}
+			if parent == nil || parent.CompletionTime.Before(record.CompletionTime) {
+				parent = record
+			}
+		}
+	}
+	return parent
+}
+
+// RunPruneBackups deletes the backups from the storage.
+func RunPruneBackups(cfg *BackupClientConfig, backups []string) error {
+	backend, err := cfg.storageBackend()
+	if err != nil {
+		return err
+	}
+	for _, name := range backups {
+		if len(name) == 0 || name == "." || name == ".." || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid backup name: %q", name)
+		}
+		log.Info("prune backup", "backup", name, "type", cfg.BackupType)
+		if err := backend.Delete(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunVerifyRestore restores the backup to the data directory of the verification pod, then
+// the mysql container starts on it and runs the checks. The backups in the volume are kept unprepared.
+func RunVerifyRestore(cfg *BackupClientConfig, name string) error {
+	if len(name) == 0 || name == "." || name == ".." || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid backup name: %q", name)
+	}
+	// xtrabackup prepare needs the my.cnf of the cluster.
+	if err := os.MkdirAll(extraConfPath, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %s", extraConfPath, err)
+	}
+	if err := copyFile(path.Join(mysqlCMPath, "my.cnf"), path.Join(mysqlConfigPath, "my.cnf")); err != nil {
+		return fmt.Errorf("failed to copy my.cnf: %s", err)
+	}
+	restoreCfg := &Config{
+		XRestoreFrom:      name,
+		XCloudS3EndPoint:  cfg.XCloudS3EndPoint,
+		XCloudS3AccessKey: cfg.XCloudS3AccessKey,
+		XCloudS3SecretKey: cfg.XCloudS3SecretKey,
+		XCloudS3Bucket:    cfg.XCloudS3Bucket,
+		EncryptKey:        cfg.EncryptKey,
+		RestoreStorage:    cfg.BackupType,
+
+		CloudStorageConfig: cfg.CloudStorageConfig,
+	}
+	log.Info("restore the backup for verification", "backup", name, "type", cfg.BackupType)
+	if isCloudStorage(cfg.BackupType) {
+		return restoreCfg.executeCloudRestore()
+	}
+	chain := readBackupChain(path.Join(utils.XtrabckupLocal, name))
+	if len(chain) == 0 {
+		chain = []string{name}
+	}
+	return restoreCfg.executeNFSCopyRestore(chain)
 }
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+func TestGetParentBackup(t *testing.T) {
+	now := time.Now()
+	at := func(hours int) *metav1.Time {
+		t := metav1.NewTime(now.Add(time.Duration(hours) * time.Hour))
+		return &t
+	}
+	newBackup := func(cluster string, records ...v1beta1.BackupRecord) v1beta1.Backup {
+		return v1beta1.Backup{
+			Spec:   v1beta1.BackupSpec{ClusterName: cluster},
+			Status: v1beta1.BackupStatus{Backups: records},
+		}
+	}
+	backups := []v1beta1.Backup{
+		newBackup("sample",
+			v1beta1.BackupRecord{Name: "full", CompletionTime: at(-3), BackupType: "S3", BackupHost: "sample-mysql-1", ToLSN: "100"},
+			v1beta1.BackupRecord{Name: "incr", CompletionTime: at(-2), BackupType: "S3", BackupHost: "sample-mysql-1", ToLSN: "200",
+				BackupChain: []string{"full", "incr"}},
+		),
+		// The manual backup taken after the scheduled ones.
+		newBackup("sample",
+			v1beta1.BackupRecord{Name: "manual", CompletionTime: at(-1), BackupType: "NFS", BackupHost: "sample-mysql-0", ToLSN: "300"},
+			// Recorded by the old version without the LSN.
+			v1beta1.BackupRecord{Name: "old", CompletionTime: at(0), BackupType: "S3"},
+		),
+		newBackup("other",
+			v1beta1.BackupRecord{Name: "other", CompletionTime: at(1), BackupType: "S3", BackupHost: "other-mysql-0", ToLSN: "400"},
+		),
+	}
+
+	parent := getParentBackup(backups, "sample", "s3")
+	assert.Equal(t, "incr", parent.Name)
+	assert.Equal(t, []string{"full", "incr"}, parent.BackupChain)
+	assert.Equal(t, "manual", getParentBackup(backups, "sample", "nfs").Name)
+	assert.Nil(t, getParentBackup(backups, "sample", "azure"))
+	assert.Nil(t, getParentBackup(nil, "sample", "s3"))
+}
+
+func TestEncryptKeyNotSent(t *testing.T) {
+	cfg := &BackupClientConfig{Encrypt: true, EncryptKey: "0123456789abcdef"}
+	body, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), cfg.EncryptKey)
+
+	var req BackupClientConfig
+	assert.NoError(t, json.Unmarshal(body, &req))
+	assert.True(t, req.Encrypt)
+	assert.Empty(t, req.EncryptKey)
+
+	// The server encrypts with its own key.
+	srv := &server{cfg: &Config{EncryptKey: "server-key-0123456"}}
+	key, err := srv.encryptKey(req.Encrypt)
+	assert.NoError(t, err)
+	assert.Equal(t, "server-key-0123456", key)
+	key, err = srv.encryptKey(false)
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+	_, err = (&server{cfg: &Config{}}).encryptKey(true)
+	assert.Error(t, err)
+}
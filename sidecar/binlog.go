@@ -0,0 +1,733 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// binlogIndexFile is the binlog index of mysql, see log-bin in my.cnf.
+	binlogIndexFile = "mysql-bin.index"
+	// binlogArchiveIndex is the index of the archived binlogs in S3.
+	binlogArchiveIndex = "index"
+	// binlogReplayPath is where the archived binlogs are downloaded to before replay.
+	binlogReplayPath = utils.DataVolumeMountPath + "/pitr-binlogs"
+
+	// binlogMagic is the magic number at the beginning of the binlog.
+	binlogMagic = "\xfebin"
+	// binlogEventHeaderLen is the length of the common header of the events.
+	binlogEventHeaderLen = 19
+	// The types of the binlog events, see libbinlogevents/include/binlog_event.h.
+	formatDescriptionEvent = 15
+	gtidLogEvent           = 33
+	previousGTIDsLogEvent  = 35
+)
+
+// BinlogConfig of the binlog-archiver.
+type BinlogConfig struct {
+	// The hostname of the pod.
+	HostName string
+	// The namespace where the pod is in.
+	NameSpace string
+	// The name of the cluster whose binlogs are archived.
+	ClusterName string
+	// Interval to check the closed binlogs.
+	Interval time.Duration
+	// ArchiveType is where the binlogs archived to, s3 or nfs.
+	ArchiveType BkType
+
+	XCloudS3EndPoint  string
+	XCloudS3AccessKey string
+	XCloudS3SecretKey string
+	XCloudS3Bucket    string
+
+	// The user to flush the binary logs.
+	OperatorUser     string
+	OperatorPassword string
+
+	// The binlogs have been archived, and the gtids executed before them.
+	archived map[string]string
+	// Whether the index in S3 need to be updated.
+	indexDirty bool
+}
+
+// NewBinlogConfig returns the configuration needed for binlog-archiver container.
+func NewBinlogConfig() *BinlogConfig {
+	interval, err := strconv.Atoi(getEnvValue("ARCHIVE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 60
+	}
+	return &BinlogConfig{
+		HostName:          getEnvValue("POD_HOSTNAME"),
+		NameSpace:         getEnvValue("NAMESPACE"),
+		ClusterName:       getEnvValue("CLUSTER_NAME"),
+		Interval:          time.Duration(interval) * time.Second,
+		ArchiveType:       BkType(getEnvValue("ARCHIVE_TYPE")),
+		XCloudS3EndPoint:  getEnvValue("S3_ENDPOINT"),
+		XCloudS3AccessKey: getEnvValue("S3_ACCESSKEY"),
+		XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
+		XCloudS3Bucket:    getEnvValue("S3_BUCKET"),
+		OperatorUser:      getEnvValue("OPERATOR_USER"),
+		OperatorPassword:  getEnvValue("OPERATOR_PASSWORD"),
+	}
+}
+
+// RunBinlogArchiver ships the closed binlogs of the leader to S3 or NFS periodically.
+func RunBinlogArchiver(cfg *BinlogConfig, stop <-chan struct{}) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	log.Info("start binlog archiver", "type", cfg.ArchiveType, "interval", cfg.Interval)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := cfg.archiveBinlogs(clientset); err != nil {
+				log.Error(err, "failed to archive binlogs")
+			}
+		}
+	}
+}
+
+// archiveBinlogs ships the closed binlogs which have not been archived, only on the leader.
+// The binlog in use is rotated every interval, so the binlogs not archived are at most an
+// interval behind.
+func (cfg *BinlogConfig) archiveBinlogs(clientset kubernetes.Interface) error {
+	pod, err := clientset.CoreV1().Pods(cfg.NameSpace).Get(context.TODO(), cfg.HostName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pod.Labels["role"] != string(utils.Leader) {
+		return nil
+	}
+
+	if cfg.archived == nil {
+		if cfg.archived, err = cfg.listArchived(); err != nil {
+			if cfg.ArchiveType != S3 {
+				return err
+			}
+			// The index does not exist before the first binlog archived.
+			log.Info("failed to get the archived binlogs index", "error", err)
+			cfg.archived = map[string]string{}
+		}
+	}
+
+	if err := cfg.rotateBinlog(time.Now()); err != nil {
+		log.Error(err, "failed to rotate the binlog")
+	}
+	binlogs, err := getClosedBinlogs(dataPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range binlogs {
+		var name string
+		if name, err = getBinlogArchiveName(cfg.HostName, file); err != nil {
+			break
+		}
+		if _, ok := cfg.archived[name]; ok {
+			continue
+		}
+		log.Info("archive binlog", "binlog", file, "name", name)
+		if err = cfg.putBinlog(file, name); err != nil {
+			break
+		}
+		// The gtids in the index let the restore skip the binlogs in the backup.
+		gtids, _, herr := readBinlogHeader(path.Join(dataPath, file))
+		if herr != nil {
+			log.Info("failed to read the previous gtids", "binlog", file, "error", herr)
+		}
+		cfg.archived[name] = gtids.String()
+		cfg.indexDirty = true
+	}
+
+	// Update the index even if failed, the archived binlogs should be in it.
+	if cfg.ArchiveType == S3 && cfg.indexDirty {
+		if err := cfg.putIndex(); err != nil {
+			return err
+		}
+		cfg.indexDirty = false
+	}
+	return err
+}
+
+// rotateBinlog flushes the binary logs if the binlog in use has transactions and was opened
+// an interval ago.
+func (cfg *BinlogConfig) rotateBinlog(now time.Time) error {
+	binlogs, err := getBinlogs(dataPath)
+	if err != nil || len(binlogs) == 0 {
+		return err
+	}
+	rotate, err := needRotate(path.Join(dataPath, binlogs[len(binlogs)-1]), cfg.Interval, now)
+	if err != nil || !rotate {
+		return err
+	}
+
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.OperatorUser
+	dsn.Passwd = cfg.OperatorPassword
+	dsn.Net = "tcp"
+	dsn.Addr = fmt.Sprintf("127.0.0.1:%d", utils.MysqlPort)
+	dsn.Timeout = 5 * time.Second
+	db, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	log.Info("rotate binlog", "binlog", binlogs[len(binlogs)-1])
+	_, err = db.Exec("FLUSH BINARY LOGS")
+	return err
+}
+
+// needRotate returns true if the binlog has transactions and was opened an interval ago.
+// The idle binlog is not rotated, otherwise the empty binlogs pile up.
+func needRotate(file string, interval time.Duration, now time.Time) (bool, error) {
+	start, err := getBinlogStartTime(file)
+	if err != nil {
+		return false, err
+	}
+	if now.Sub(time.Unix(int64(start), 0)) < interval {
+		return false, nil
+	}
+	_, headerEnd, err := readBinlogHeader(file)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return info.Size() > headerEnd, nil
+}
+
+// listArchived returns the names of the archived binlogs, and the gtids executed before
+// them. The gtids are empty if unknown, such as in the index written by the old versions.
+func (cfg *BinlogConfig) listArchived() (map[string]string, error) {
+	archived := map[string]string{}
+	if cfg.ArchiveType == NFS {
+		dir := path.Join(utils.XtrabckupLocal, getBinlogArchiveDir(cfg.ClusterName))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if strings.HasSuffix(f.Name(), ".tmp") {
+				continue
+			}
+			gtids, _, err := readBinlogHeader(path.Join(dir, f.Name()))
+			if err != nil {
+				log.Info("failed to read the previous gtids", "binlog", f.Name(), "error", err)
+			}
+			archived[f.Name()] = gtids.String()
+		}
+		return archived, nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "binlog-index")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	xcloud := exec.Command(xcloudCommand, cfg.xcloudArgs("get", binlogArchiveIndex)...)
+	xbstream := exec.Command("xbstream", "-x", "-C", tmpDir)
+	if err := runPiped(xcloud, xbstream); err != nil {
+		return nil, err
+	}
+	index, err := os.Open(path.Join(tmpDir, binlogArchiveIndex))
+	if err != nil {
+		return nil, err
+	}
+	defer index.Close()
+	scanner := bufio.NewScanner(index)
+	for scanner.Scan() {
+		// The line is the name and the previous gtids separated by a tab.
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), "\t", 2)
+		if len(fields[0]) == 0 {
+			continue
+		}
+		archived[fields[0]] = ""
+		if len(fields) == 2 {
+			archived[fields[0]] = fields[1]
+		}
+	}
+	return archived, scanner.Err()
+}
+
+// putBinlog archives the binlog file with the name.
+func (cfg *BinlogConfig) putBinlog(file, name string) error {
+	if cfg.ArchiveType == NFS {
+		dst := path.Join(utils.XtrabckupLocal, getBinlogArchiveDir(cfg.ClusterName), name)
+		if err := copyFile(path.Join(dataPath, file), dst+".tmp"); err != nil {
+			return err
+		}
+		return os.Rename(dst+".tmp", dst)
+	}
+
+	xbstream := exec.Command("xbstream", "-c", "-C", dataPath, file)
+	xcloud := exec.Command(xcloudCommand, cfg.xcloudArgs("put", name)...)
+	return runPiped(xbstream, xcloud)
+}
+
+// putIndex rewrites the index of the archived binlogs in S3.
+func (cfg *BinlogConfig) putIndex() error {
+	tmpDir, err := ioutil.TempDir("", "binlog-index")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	lines := make([]string, 0, len(cfg.archived))
+	for name, gtids := range cfg.archived {
+		if len(gtids) != 0 {
+			name += "\t" + gtids
+		}
+		lines = append(lines, name)
+	}
+	sort.Strings(lines)
+	if err := ioutil.WriteFile(path.Join(tmpDir, binlogArchiveIndex), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+
+	// xbcloud cannot overwrite the existing object, delete it at first.
+	if err := exec.Command(xcloudCommand, cfg.xcloudArgs("delete", binlogArchiveIndex)...).Run(); err != nil {
+		log.Info("failed to delete the binlog index", "error", err)
+	}
+	xbstream := exec.Command("xbstream", "-c", "-C", tmpDir, binlogArchiveIndex)
+	xcloud := exec.Command(xcloudCommand, cfg.xcloudArgs("put", binlogArchiveIndex)...)
+	return runPiped(xbstream, xcloud)
+}
+
+// getBinlog downloads the archived binlog to the target directory, returns the local path.
+func (cfg *BinlogConfig) getBinlog(name, targetDir string) (string, error) {
+	dir := path.Join(targetDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	file := name[strings.LastIndex(name, "_")+1:]
+	if cfg.ArchiveType == NFS {
+		src := path.Join(utils.XtrabckupLocal, getBinlogArchiveDir(cfg.ClusterName), name)
+		return path.Join(dir, file), copyFile(src, path.Join(dir, file))
+	}
+
+	xcloud := exec.Command(xcloudCommand, cfg.xcloudArgs("get", name)...)
+	xbstream := exec.Command("xbstream", "-x", "-C", dir)
+	return path.Join(dir, file), runPiped(xcloud, xbstream)
+}
+
+// xcloudArgs build the xbcloud arguments, the objects are under the <cluster>-binlog.
+func (cfg *BinlogConfig) xcloudArgs(action, name string) []string {
+	return []string{
+		action,
+		"--storage=S3",
+		fmt.Sprintf("--s3-endpoint=%s", cfg.XCloudS3EndPoint),
+		fmt.Sprintf("--s3-access-key=%s", cfg.XCloudS3AccessKey),
+		fmt.Sprintf("--s3-secret-key=%s", cfg.XCloudS3SecretKey),
+		fmt.Sprintf("--s3-bucket=%s", cfg.XCloudS3Bucket),
+		path.Join(getBinlogArchiveDir(cfg.ClusterName), name),
+		"--insecure",
+	}
+}
+
+// runPiped runs the src command with stdout piped to the dst command.
+func runPiped(src, dst *exec.Cmd) error {
+	var err error
+	if dst.Stdin, err = src.StdoutPipe(); err != nil {
+		return err
+	}
+	src.Stderr = os.Stderr
+	dst.Stderr = os.Stderr
+	if err := dst.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %s", dst.Path, err)
+	}
+	if err := src.Run(); err != nil {
+		dst.Process.Kill()
+		return fmt.Errorf("failed to run %s: %s", src.Path, err)
+	}
+	if err := dst.Wait(); err != nil {
+		return fmt.Errorf("failed to run %s: %s", dst.Path, err)
+	}
+	return nil
+}
+
+// getBinlogArchiveDir returns the directory in NFS or the prefix in S3 of the archived binlogs.
+func getBinlogArchiveDir(clusterName string) string {
+	return fmt.Sprintf("%s-binlog", clusterName)
+}
+
+// getBinlogArchiveName returns the archived name of the binlog, the format is
+// <start timestamp>_<hostname>_<binlog file>, so that the names can be sorted by time.
+func getBinlogArchiveName(hostName, file string) (string, error) {
+	ts, err := getBinlogStartTime(path.Join(dataPath, file))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%010d_%s_%s", ts, hostName, file), nil
+}
+
+// getBinlogStartTime returns the timestamp of the format description event,
+// it is the first event after the magic number.
+func getBinlogStartTime(file string) (uint32, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	header := make([]byte, 8)
+	if _, err := f.Read(header); err != nil {
+		return 0, err
+	}
+	if string(header[:4]) != binlogMagic {
+		return 0, fmt.Errorf("%s is not a binlog file", file)
+	}
+	return binary.LittleEndian.Uint32(header[4:]), nil
+}
+
+// binlogEvent is an event in the binlog, the body may end with the checksum.
+type binlogEvent struct {
+	offset int64
+	typ    byte
+	body   []byte
+}
+
+// scanBinlogEvents calls fn on the events of the binlog in order until it returns false.
+// The event being written at the end of the binlog in use is ignored.
+func scanBinlogEvents(file string, fn func(ev binlogEvent) bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(binlogMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != binlogMagic {
+		return fmt.Errorf("%s is not a binlog file", file)
+	}
+	offset := int64(len(binlogMagic))
+	header := make([]byte, binlogEventHeaderLen)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.LittleEndian.Uint32(header[9:13]))
+		if size < binlogEventHeaderLen {
+			return fmt.Errorf("invalid event at %d in %s", offset, file)
+		}
+		body := make([]byte, size-binlogEventHeaderLen)
+		if _, err := io.ReadFull(r, body); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if !fn(binlogEvent{offset: offset, typ: header[4], body: body}) {
+			return nil
+		}
+		offset += size
+	}
+}
+
+// readBinlogHeader returns the gtids executed before the binlog, which are in the previous
+// gtids event, and the offset where the first transaction of the binlog starts.
+func readBinlogHeader(file string) (GTIDSet, int64, error) {
+	gtids := GTIDSet{}
+	headerEnd := int64(len(binlogMagic))
+	var perr error
+	err := scanBinlogEvents(file, func(ev binlogEvent) bool {
+		switch ev.typ {
+		case formatDescriptionEvent:
+		case previousGTIDsLogEvent:
+			gtids, perr = parsePreviousGTIDs(ev.body)
+		default:
+			return false
+		}
+		headerEnd = ev.offset + binlogEventHeaderLen + int64(len(ev.body))
+		return perr == nil
+	})
+	if err == nil {
+		err = perr
+	}
+	if err != nil {
+		return GTIDSet{}, 0, err
+	}
+	return gtids, headerEnd, nil
+}
+
+// parsePreviousGTIDs parses the body of the previous gtids event: the number of the sources,
+// and for each source the uuid, the number of the intervals and the intervals [start, end).
+func parsePreviousGTIDs(body []byte) (GTIDSet, error) {
+	gtids := GTIDSet{}
+	if len(body) < 8 {
+		return nil, fmt.Errorf("invalid previous gtids event")
+	}
+	pos := 8
+	for i := binary.LittleEndian.Uint64(body); i > 0; i-- {
+		if len(body) < pos+24 {
+			return nil, fmt.Errorf("invalid previous gtids event")
+		}
+		sid := formatSID(body[pos : pos+16])
+		n := binary.LittleEndian.Uint64(body[pos+16:])
+		pos += 24
+		for ; n > 0; n-- {
+			if len(body) < pos+16 {
+				return nil, fmt.Errorf("invalid previous gtids event")
+			}
+			start := int64(binary.LittleEndian.Uint64(body[pos:]))
+			end := int64(binary.LittleEndian.Uint64(body[pos+8:]))
+			if start < 1 || end <= start {
+				return nil, fmt.Errorf("invalid previous gtids event")
+			}
+			gtids.addInterval(sid, gtidInterval{start, end - 1})
+			pos += 16
+		}
+	}
+	return gtids, nil
+}
+
+// getBinlogs returns the binlogs in the index, the last one is in use.
+func getBinlogs(dir string) ([]string, error) {
+	content, err := ioutil.ReadFile(path.Join(dir, binlogIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	var binlogs []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); len(line) != 0 {
+			binlogs = append(binlogs, path.Base(line))
+		}
+	}
+	return binlogs, nil
+}
+
+// getClosedBinlogs returns the binlogs in the index except the last one which is in use.
+func getClosedBinlogs(dir string) ([]string, error) {
+	binlogs, err := getBinlogs(dir)
+	if err != nil || len(binlogs) == 0 {
+		return nil, err
+	}
+	return binlogs[:len(binlogs)-1], nil
+}
+
+// prepareBinlogReplay downloads the archived binlogs until the restore target, and builds
+// the pitr.sh which replays them in init-mysql container.
+func (cfg *Config) prepareBinlogReplay() error {
+	bcfg := &BinlogConfig{
+		ClusterName:       cfg.RestoreBinlogCluster,
+		ArchiveType:       S3,
+		XCloudS3EndPoint:  cfg.BinlogS3EndPoint,
+		XCloudS3AccessKey: cfg.BinlogS3AccessKey,
+		XCloudS3SecretKey: cfg.BinlogS3SecretKey,
+		XCloudS3Bucket:    cfg.BinlogS3Bucket,
+	}
+	if len(cfg.XRestoreFromNFS) != 0 {
+		bcfg.ArchiveType = NFS
+	}
+	archived, err := bcfg.listArchived()
+	if err != nil {
+		return fmt.Errorf("failed to list the archived binlogs: %s", err)
+	}
+
+	// The gtid executed may be not persisted in 5.7, reset it to the backup.
+	gtidPurged, err := GetXtrabackupGTIDPurged(dataPath)
+	if err != nil {
+		log.Info("failed to get the gtid of backup", "error", err)
+	}
+	backup, err := ParseGTIDSet(gtidPurged)
+	if err != nil {
+		return fmt.Errorf("failed to parse the gtid of backup: %s", err)
+	}
+	var stopTime int64 = math.MaxInt64
+	if len(cfg.RestoreTime) != 0 {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", cfg.RestoreTime, time.Local)
+		if err != nil {
+			return err
+		}
+		stopTime = t.Unix()
+	}
+	var files []string
+	for _, name := range selectBinlogs(archived, backup, stopTime) {
+		file, err := bcfg.getBinlog(name, binlogReplayPath)
+		if err != nil {
+			return fmt.Errorf("failed to get binlog %s: %s", name, err)
+		}
+		files = append(files, file)
+	}
+	var stopPos int64
+	if len(cfg.RestoreGTID) != 0 {
+		target, err := ParseGTIDSet(cfg.RestoreGTID)
+		if err != nil {
+			return err
+		}
+		if files, stopPos, err = findGTIDStop(files, backup, target); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		log.Info("no archived binlogs need to replay")
+		return os.RemoveAll(binlogReplayPath)
+	}
+
+	cmd := exec.Command("chown", "-R", "mysql.mysql", binlogReplayPath)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to chown -R mysql.mysql : %s", err)
+	}
+	log.Info("replay the archived binlogs in init-mysql", "binlogs", files, "stopPosition", stopPos)
+	return ioutil.WriteFile(path.Join(initFilePath, "pitr.sh"), cfg.buildPitrSh(files, gtidPurged, stopPos), 0755)
+}
+
+// selectBinlogs returns the names of the archived binlogs to replay in order. The binlogs
+// started after the stop time are skipped, and so are the binlogs whose transactions are all
+// in the backup, that is the next binlog of the same host starts after the backup.
+func selectBinlogs(archived map[string]string, backup GTIDSet, stopTime int64) []string {
+	names := make([]string, 0, len(archived))
+	for name := range archived {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var selected []string
+	// The previous gtids of the next binlog of the host.
+	nextGTIDs := map[string]string{}
+	for i := len(names) - 1; i >= 0; i-- {
+		// The name is <start timestamp>_<hostname>_<binlog file>.
+		fields := strings.SplitN(names[i], "_", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		next, hasNext := nextGTIDs[fields[1]]
+		nextGTIDs[fields[1]] = archived[names[i]]
+		if ts, err := strconv.ParseInt(fields[0], 10, 64); err != nil || ts > stopTime {
+			continue
+		}
+		if hasNext && len(next) != 0 {
+			if gtids, err := ParseGTIDSet(next); err == nil && backup.Contains(gtids) {
+				continue
+			}
+		}
+		selected = append(selected, names[i])
+	}
+	for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+		selected[i], selected[j] = selected[j], selected[i]
+	}
+	return selected
+}
+
+// findGTIDStop returns the binlogs needed to reach the target gtids, and the position of the
+// first transaction after the target in the last binlog, where the replay stops. The position
+// is 0 if the last binlog is replayed to the end.
+func findGTIDStop(files []string, backup, target GTIDSet) ([]string, int64, error) {
+	executed := GTIDSet{}
+	executed.Union(backup)
+	if executed.Contains(target) {
+		return nil, 0, nil
+	}
+	for i, file := range files {
+		var stopPos int64
+		err := scanBinlogEvents(file, func(ev binlogEvent) bool {
+			// The body of the gtid event: flags(1), uuid(16), gno(8).
+			if ev.typ != gtidLogEvent || len(ev.body) < 25 {
+				return true
+			}
+			if executed.Contains(target) {
+				stopPos = ev.offset
+				return false
+			}
+			executed.Add(formatSID(ev.body[1:17]), int64(binary.LittleEndian.Uint64(ev.body[17:25])))
+			return true
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if stopPos != 0 || executed.Contains(target) {
+			return files[:i+1], stopPos, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("the gtid set %s is not in the archived binlogs", target)
+}
+
+// buildPitrSh build the pitr.sh, it starts a temporary mysqld and replays the binlogs.
+// The transactions which have been executed are skipped by gtid.
+func (cfg *Config) buildPitrSh(files []string, gtidPurged string, stopPos int64) []byte {
+	stopArg := ""
+	if len(cfg.RestoreTime) != 0 {
+		stopArg = fmt.Sprintf("--stop-datetime='%s' ", cfg.RestoreTime)
+	} else if stopPos != 0 {
+		// It applies to the last binlog.
+		stopArg = fmt.Sprintf("--stop-position=%d ", stopPos)
+	}
+	resetSQL := ""
+	if len(gtidPurged) != 0 {
+		resetSQL = fmt.Sprintf("RESET MASTER;SET GLOBAL gtid_purged='%s';", gtidPurged)
+	}
+	str := fmt.Sprintf(`#!/bin/bash
+set -e
+mysqld --user=mysql --skip-networking=0 --bind-address=127.0.0.1 --port=33306 --skip-slave-start --read-only=0 --super-read-only=0 &
+pid=$!
+mysql=( mysql -h127.0.0.1 -P33306 -uroot -p"${MYSQL_ROOT_PASSWORD}" )
+for i in {60..0}; do
+	if "${mysql[@]}" -e 'SELECT 1' >/dev/null 2>&1; then
+		break
+	fi
+	sleep 1
+done
+if [ ! -f %[1]s/.reset ]; then
+	"${mysql[@]}" -e "%[2]s"
+	touch %[1]s/.reset
+fi
+mysqlbinlog %[3]s%[4]s | "${mysql[@]}"
+"${mysql[@]}" -e 'SHUTDOWN'
+wait $pid || true
+rm -rf %[1]s
+rm -f $0
+`, binlogReplayPath, resetSQL, stopArg, strings.Join(files, " "))
+	return utils.StringToBytes(str)
+}
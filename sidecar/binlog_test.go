@@ -0,0 +1,268 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSID  = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	testSID2 = "4a6b2c1d-71ca-11e1-9e33-c80aa9429562"
+	// queryEvent stands for the events of a transaction.
+	queryEvent = 2
+)
+
+// testBinlog builds a binlog file in memory.
+type testBinlog struct {
+	data []byte
+}
+
+func newTestBinlog(ts uint32, previous map[string][][2]int64) *testBinlog {
+	b := &testBinlog{data: []byte(binlogMagic)}
+	b.event(ts, formatDescriptionEvent, make([]byte, 95))
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint64(body, uint64(len(previous)))
+	for sid, intervals := range previous {
+		body = append(body, sidBytes(sid)...)
+		body = appendUint64(body, uint64(len(intervals)))
+		for _, in := range intervals {
+			body = appendUint64(body, uint64(in[0]))
+			body = appendUint64(body, uint64(in[1]))
+		}
+	}
+	b.event(ts, previousGTIDsLogEvent, body)
+	return b
+}
+
+// event appends an event, and returns its offset.
+func (b *testBinlog) event(ts uint32, typ byte, body []byte) int64 {
+	offset := int64(len(b.data))
+	header := make([]byte, binlogEventHeaderLen)
+	binary.LittleEndian.PutUint32(header, ts)
+	header[4] = typ
+	binary.LittleEndian.PutUint32(header[9:], uint32(binlogEventHeaderLen+len(body)))
+	b.data = append(append(b.data, header...), body...)
+	return offset
+}
+
+// transaction appends a transaction, and returns the offset of its gtid event.
+func (b *testBinlog) transaction(sid string, gno int64) int64 {
+	body := append([]byte{1}, sidBytes(sid)...)
+	body = appendUint64(body, uint64(gno))
+	offset := b.event(0, gtidLogEvent, body)
+	b.event(0, queryEvent, []byte("BEGIN"))
+	return offset
+}
+
+func (b *testBinlog) write(t *testing.T, name string) string {
+	file := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(file, b.data, 0644))
+	return file
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	return append(b, buf...)
+}
+
+func sidBytes(sid string) []byte {
+	b, _ := hex.DecodeString(strings.Replace(sid, "-", "", -1))
+	return b
+}
+
+func mustParseGTIDSet(t *testing.T, str string) GTIDSet {
+	set, err := ParseGTIDSet(str)
+	assert.NoError(t, err)
+	return set
+}
+
+func TestGTIDSet(t *testing.T) {
+	set := mustParseGTIDSet(t, testSID2+":1-3,\n"+strings.ToUpper(testSID)+":7:1-5")
+	assert.Equal(t, testSID+":1-5:7,"+testSID2+":1-3", set.String())
+	assert.True(t, set.Contains(mustParseGTIDSet(t, testSID+":2-4:7")))
+	assert.False(t, set.Contains(mustParseGTIDSet(t, testSID+":5-7")))
+	assert.True(t, set.Contains(GTIDSet{}))
+
+	set.Add(testSID, 6)
+	assert.Equal(t, testSID+":1-7,"+testSID2+":1-3", set.String())
+	set.Union(mustParseGTIDSet(t, testSID2+":4-9"))
+	assert.Equal(t, testSID+":1-7,"+testSID2+":1-9", set.String())
+
+	for _, invalid := range []string{testSID, testSID + ":a", testSID + ":5-3", testSID + ":0"} {
+		_, err := ParseGTIDSet(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestReadBinlogHeader(t *testing.T) {
+	b := newTestBinlog(1640995200, map[string][][2]int64{testSID: {{1, 6}, {7, 8}}})
+	headerEnd := int64(len(b.data))
+	b.transaction(testSID, 8)
+	file := b.write(t, "mysql-bin.000002")
+
+	gtids, end, err := readBinlogHeader(file)
+	assert.NoError(t, err)
+	assert.Equal(t, testSID+":1-5:7", gtids.String())
+	assert.Equal(t, headerEnd, end)
+	ts, err := getBinlogStartTime(file)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1640995200), ts)
+
+	_, _, err = readBinlogHeader(newTestBinlog(0, nil).write(t, "mysql-bin.000003"))
+	assert.NoError(t, err)
+	notBinlog := filepath.Join(t.TempDir(), "mysql-bin.index")
+	assert.NoError(t, os.WriteFile(notBinlog, []byte("./mysql-bin.000001\n"), 0644))
+	_, _, err = readBinlogHeader(notBinlog)
+	assert.Error(t, err)
+}
+
+func TestNeedRotate(t *testing.T) {
+	start := time.Unix(1640995200, 0)
+	b := newTestBinlog(uint32(start.Unix()), nil)
+	idle := b.write(t, "mysql-bin.000001")
+	b.transaction(testSID, 1)
+	busy := b.write(t, "mysql-bin.000002")
+
+	testCases := []struct {
+		file   string
+		now    time.Time
+		rotate bool
+	}{
+		{busy, start.Add(time.Minute), true},
+		{busy, start.Add(30 * time.Second), false},
+		// The idle binlog is never rotated.
+		{idle, start.Add(time.Hour), false},
+	}
+	for _, tc := range testCases {
+		rotate, err := needRotate(tc.file, time.Minute, tc.now)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.rotate, rotate)
+	}
+}
+
+func TestSelectBinlogs(t *testing.T) {
+	archived := map[string]string{
+		// sample-mysql-0 was the leader until the failover.
+		"1640995200_sample-mysql-0_mysql-bin.000001": "",
+		"1640995300_sample-mysql-0_mysql-bin.000002": testSID + ":1-10",
+		"1640995400_sample-mysql-0_mysql-bin.000003": testSID + ":1-20",
+		"1640995500_sample-mysql-1_mysql-bin.000004": testSID + ":1-25",
+		"1640995600_sample-mysql-1_mysql-bin.000005": testSID + ":1-25," + testSID2 + ":1-10",
+		// The old index does not have the gtids.
+		"1640995700_sample-mysql-1_mysql-bin.000006": "",
+	}
+	testCases := []struct {
+		backup   string
+		stopTime int64
+		expect   []string
+	}{
+		// The backup is in the second binlog.
+		{
+			testSID + ":1-15",
+			1640995650,
+			[]string{
+				"1640995300_sample-mysql-0_mysql-bin.000002",
+				"1640995400_sample-mysql-0_mysql-bin.000003",
+				"1640995500_sample-mysql-1_mysql-bin.000004",
+				"1640995600_sample-mysql-1_mysql-bin.000005",
+			},
+		},
+		// The last binlog of the old leader is replayed, the new leader has no binlogs before it.
+		{
+			testSID + ":1-25," + testSID2 + ":1-5",
+			1640995800,
+			[]string{
+				"1640995400_sample-mysql-0_mysql-bin.000003",
+				"1640995500_sample-mysql-1_mysql-bin.000004",
+				"1640995600_sample-mysql-1_mysql-bin.000005",
+				"1640995700_sample-mysql-1_mysql-bin.000006",
+			},
+		},
+		// The binlogs are not skipped without the gtids of the backup.
+		{
+			"",
+			1640995300,
+			[]string{
+				"1640995200_sample-mysql-0_mysql-bin.000001",
+				"1640995300_sample-mysql-0_mysql-bin.000002",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, selectBinlogs(archived, mustParseGTIDSet(t, tc.backup), tc.stopTime))
+	}
+}
+
+func TestFindGTIDStop(t *testing.T) {
+	first := newTestBinlog(1640995200, map[string][][2]int64{testSID: {{1, 6}}})
+	first.transaction(testSID, 6)
+	first.transaction(testSID, 7)
+	second := newTestBinlog(1640995300, map[string][][2]int64{testSID: {{1, 8}}})
+	second.transaction(testSID, 8)
+	stop := second.transaction(testSID, 9)
+	second.transaction(testSID, 10)
+	files := []string{first.write(t, "mysql-bin.000001"), second.write(t, "mysql-bin.000002")}
+	backup := mustParseGTIDSet(t, testSID+":1-5")
+
+	testCases := []struct {
+		target  string
+		files   []string
+		stopPos int64
+	}{
+		// Stop before the first transaction after the target.
+		{testSID + ":8", files, stop},
+		// The first binlog is replayed to the end.
+		{testSID + ":6-7", files[:1], 0},
+		{testSID + ":10", files, 0},
+		// The target is in the backup.
+		{testSID + ":1-3", nil, 0},
+	}
+	for _, tc := range testCases {
+		got, stopPos, err := findGTIDStop(files, backup, mustParseGTIDSet(t, tc.target))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.files, got, tc.target)
+		assert.Equal(t, tc.stopPos, stopPos, tc.target)
+	}
+	_, _, err := findGTIDStop(files, backup, mustParseGTIDSet(t, testSID+":11"))
+	assert.Error(t, err)
+}
+
+func TestBuildPitrSh(t *testing.T) {
+	files := []string{"/a/mysql-bin.000001", "/b/mysql-bin.000002"}
+	cfg := &Config{RestoreGTID: testSID + ":8"}
+	script := string(cfg.buildPitrSh(files, testSID+":1-5", 1024))
+	assert.Contains(t, script, "mysqlbinlog --stop-position=1024 /a/mysql-bin.000001 /b/mysql-bin.000002 |")
+	assert.Contains(t, script, "SET GLOBAL gtid_purged='"+testSID+":1-5';")
+	assert.NotContains(t, script, "--include-gtids")
+
+	script = string(cfg.buildPitrSh(files, "", 0))
+	assert.Contains(t, script, "mysqlbinlog /a/mysql-bin.000001 /b/mysql-bin.000002 |")
+
+	cfg = &Config{RestoreTime: "2022-01-01 00:00:00"}
+	script = string(cfg.buildPitrSh(files, "", 0))
+	assert.Contains(t, script, "mysqlbinlog --stop-datetime='2022-01-01 00:00:00' /a/mysql-bin.000001 /b/mysql-bin.000002 |")
+}
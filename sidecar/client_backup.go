@@ -21,9 +21,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
-	"os"
-	"os/exec"
+	"path"
+	"strings"
 
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
@@ -31,6 +32,11 @@ import (
 func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*http.Response, error) {
 
 	log.Info("initialize a backup", "host", host, "endpoint", endpoint)
+	if cfg.Incremental {
+		if err := setParentBackup(cfg); err != nil {
+			return nil, fmt.Errorf("fail to find the parent backup: %s", err)
+		}
+	}
 	reqBody, err := json.Marshal(cfg)
 	if err != nil {
 		log.Error(err, "fail to marshal request body")
@@ -45,8 +51,10 @@ func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*ht
 	// set authentication user and password
 	req.SetBasicAuth(cfg.BackupUser, cfg.BackupPassword)
 
-	client := &http.Client{}
-	client.Transport = transportWithTimeout(serverConnectTimeout)
+	client, err := newBackupClient()
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := client.Do(req)
 	if err != nil || resp.StatusCode != 200 {
@@ -60,31 +68,53 @@ func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*ht
 	var result utils.JsonResult
 	json.NewDecoder(resp.Body).Decode(&result)
 	log.Info("recive json", "json", result)
-	err = setAnnonations(cfg, result.BackupName, result.Date, "S3", result.BackupSize) // set annotation
+	err = setAnnonations(cfg, result.BackupName, result.Date, strings.ToUpper(string(cfg.BackupType)), result.BackupSize, result.ToLSN) // set annotation
 	if err != nil {
 		return nil, fmt.Errorf("fail to set annotation: %s", err)
 	}
 	return resp, nil
 }
 
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func requestNFSBackup(cfg *BackupClientConfig, host string, endpoint string) error {
-	log.Info("initializing a NFS backup", "host", host, "endpoint", endpoint)
+	log.Info("initializing a backup to the volume", "host", host, "endpoint", endpoint, "type", cfg.BackupType)
 
 	backupName, DateTime := cfg.XBackupName()
+	if cfg.Incremental {
+		if err := setParentBackup(cfg); err != nil {
+			return fmt.Errorf("failed to find the parent backup: %w", err)
+		}
+	}
 
 	reqBody, err := json.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", prepareURL(host, endpoint), bytes.NewBuffer(reqBody))
+	url := prepareURL(host, endpoint)
+	if len(cfg.IncrementalLSN) != 0 {
+		url = fmt.Sprintf("%s?incremental-lsn=%s", url, cfg.IncrementalLSN)
+	}
+	req, err := http.NewRequest("GET", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.SetBasicAuth(cfg.BackupUser, cfg.BackupPassword)
 
-	client := &http.Client{
-		Transport: transportWithTimeout(serverConnectTimeout),
+	client, err := newBackupClient()
+	if err != nil {
+		return err
 	}
 
 	resp, err := client.Do(req)
@@ -97,48 +127,30 @@ func requestNFSBackup(cfg *BackupClientConfig, host string, endpoint string) err
 	}
 	defer resp.Body.Close()
 
-	// Create the backup dir
-	// Backupdir is the name of the backup
-	backupPath := fmt.Sprintf("%s/%s", "/backup", backupName)
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
-		return fmt.Errorf("failed to create backup dir: %w", err)
+	backend, err := cfg.storageBackend()
+	if err != nil {
+		return err
 	}
-
-	// Create a pipe for the xbstream command to read from.
-	rc, wc := io.Pipe()
-	cmd := exec.Command("xbstream", "-x", "-C", backupPath)
-	cmd.Stdin = rc
-	cmd.Stderr = os.Stderr
-
-	// Start xbstream command.
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start xbstream cmd: %w", err)
+	// Extract the backup to the storage while tracking the number of bytes read.
+	body := &countingReader{r: resp.Body}
+	if err := backend.Upload(backupName, body); err != nil {
+		return err
 	}
+	n := body.n
 
-	// Write the response body to the pipe.
-	copyErr := make(chan error)
-	var n int64
-	go func() {
-		n, err = io.Copy(wc, resp.Body)
-		wc.Close()
-		copyErr <- err
-	}()
-
-	// Wait for the xbstream command to finish.
-	cmdErr := make(chan error)
-	go func() {
-		cmdErr <- cmd.Wait()
-	}()
-
-	if err := <-copyErr; err != nil {
-		return fmt.Errorf("failed to write to pipe: %w", err)
+	backupPath := path.Join(utils.XtrabckupLocal, backupName)
+	chain := append(cfg.BackupChain, backupName)
+	if err := ioutil.WriteFile(path.Join(backupPath, backupChainFile), []byte(strings.Join(chain, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write backup chain: %w", err)
 	}
-
-	if err := <-cmdErr; err != nil {
-		return fmt.Errorf("xbstream command failed: %w", err)
+	// The xtrabackup_checkpoints is unreadable if the backup is encrypted or compressed.
+	toLSN := resp.Trailer.Get(backupToLSNTrailer)
+	if len(toLSN) == 0 {
+		if toLSN, err = GetXtrabackupToLSN(backupPath); err != nil {
+			log.Error(err, "failed to get the to_lsn of backup")
+		}
 	}
-
-	if err := setAnnonations(cfg, backupName, DateTime, "nfs", n); err != nil {
+	if err := setAnnonations(cfg, backupName, DateTime, string(cfg.BackupType), n, toLSN); err != nil {
 		return fmt.Errorf("failed to set annotation: %w", err)
 	}
 	log.Info("backup completed", "backupName", backupName, "backupSize", n)
@@ -114,6 +114,27 @@ type Config struct {
 
 	// XRestoreFromNFS string
 
+	// The remote mysql where the first pod clones from.
+	RemoteHost string
+	RemotePort int
+	// The backup server of the remote source where MySQL 5.7 downloads the backup from.
+	RemoteBackupPort     int
+	RemoteBackupUser     string
+	RemoteBackupPassword string
+	// Whether clone from the remote mysql in init-mysql container.
+	remoteClone bool
+
+	// The cluster whose archived binlogs are replayed after restore.
+	RestoreBinlogCluster string
+	// Replay the binlogs until the time or gtid.
+	RestoreTime string
+	RestoreGTID string
+	// The S3 storage where the binlogs to replay were archived.
+	BinlogS3EndPoint  string
+	BinlogS3AccessKey string
+	BinlogS3SecretKey string
+	BinlogS3Bucket    string
+
 	// User customized initsql.
 	InitSQL string
 
@@ -124,6 +145,12 @@ type Config struct {
 	XCloudS3AccessKey string
 	XCloudS3SecretKey string
 	XCloudS3Bucket    string
+	// The key to encrypt the backups taken by the backup server and decrypt the restored backup.
+	EncryptKey string
+	// The storage where cluster restore from.
+	RestoreStorage BkType
+	// The credentials of azure or gcs.
+	CloudStorageConfig
 }
 
 // NewInitConfig returns a pointer to Config.
@@ -159,6 +186,23 @@ func NewInitConfig() *Config {
 
 	existMySQLData, _ := checkIfPathExists(fmt.Sprintf("%s/mysql", dataPath))
 
+	remotePort, err := strconv.Atoi(getEnvValue("REMOTE_PORT"))
+	if err != nil {
+		remotePort = utils.MysqlPort
+	}
+	remoteBackupPort, err := strconv.Atoi(getEnvValue("REMOTE_BACKUP_PORT"))
+	if err != nil {
+		remoteBackupPort = serverPort
+	}
+
+	restoreStorage := BkType(getEnvValue("RESTORE_STORAGE"))
+	if len(restoreStorage) == 0 {
+		restoreStorage = S3
+		if len(getEnvValue("RESTORE_FROM_NFS")) != 0 {
+			restoreStorage = NFS
+		}
+	}
+
 	return &Config{
 		HostName:        getEnvValue("POD_HOSTNAME"),
 		NameSpace:       getEnvValue("NAMESPACE"),
@@ -181,6 +225,9 @@ func NewInitConfig() *Config {
 		OperatorUser:     getEnvValue("OPERATOR_USER"),
 		OperatorPassword: getEnvValue("OPERATOR_PASSWORD"),
 
+		BackupUser:     getEnvValue("BACKUP_USER"),
+		BackupPassword: getEnvValue("BACKUP_PASSWORD"),
+
 		InitTokuDB: initTokuDB,
 
 		MySQLVersion: mysqlSemVer,
@@ -195,6 +242,24 @@ func NewInitConfig() *Config {
 		XCloudS3AccessKey: getEnvValue("S3_ACCESSKEY"),
 		XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
 		XCloudS3Bucket:    getEnvValue("S3_BUCKET"),
+		EncryptKey:        getEnvValue("BACKUP_ENCRYPT_KEY"),
+		RestoreStorage:    restoreStorage,
+
+		CloudStorageConfig: newCloudStorageConfig(),
+
+		RemoteHost:           getEnvValue("REMOTE_HOST"),
+		RemotePort:           remotePort,
+		RemoteBackupPort:     remoteBackupPort,
+		RemoteBackupUser:     getEnvValue("REMOTE_BACKUP_USER"),
+		RemoteBackupPassword: getEnvValue("REMOTE_BACKUP_PASSWORD"),
+
+		RestoreBinlogCluster: getEnvValue("RESTORE_BINLOG_CLUSTER"),
+		RestoreTime:          getEnvValue("RESTORE_TIME"),
+		RestoreGTID:          getEnvValue("RESTORE_GTID"),
+		BinlogS3EndPoint:     getEnvValue("BINLOG_S3_ENDPOINT"),
+		BinlogS3AccessKey:    getEnvValue("BINLOG_S3_ACCESSKEY"),
+		BinlogS3SecretKey:    getEnvValue("BINLOG_S3_SECRETKEY"),
+		BinlogS3Bucket:       getEnvValue("BINLOG_S3_BUCKET"),
 
 		ClusterName: getEnvValue("CLUSTER_NAME"),
 		CloneFlag:   false,
@@ -212,6 +277,7 @@ func NewBackupConfig() *Config {
 
 		BackupUser:     getEnvValue("BACKUP_USER"),
 		BackupPassword: getEnvValue("BACKUP_PASSWORD"),
+		EncryptKey:     getEnvValue("BACKUP_ENCRYPT_KEY"),
 
 		// XCloudS3EndPoint:  getEnvValue("S3_ENDPOINT"),
 		// XCloudS3AccessKey: getEnvValue("S3_ACCESSKEY"),
@@ -278,6 +344,16 @@ func (cfg *Config) XBackupName() (string, string) {
 	return utils.BuildBackupName(cfg.ClusterName)
 }
 
+// storageBackend returns the backend of the storage where cluster restore from.
+func (cfg *Config) storageBackend() (StorageBackend, error) {
+	return newStorageBackend(cfg.RestoreStorage, s3Credentials{
+		endpoint:  cfg.XCloudS3EndPoint,
+		accessKey: cfg.XCloudS3AccessKey,
+		secretKey: cfg.XCloudS3SecretKey,
+		bucket:    cfg.XCloudS3Bucket,
+	}, cfg.CloudStorageConfig)
+}
+
 // buildExtraConfig build a ini file for mysql.
 func (cfg *Config) buildExtraConfig(filePath string) (*ini.File, error) {
 	conf := ini.Empty()
@@ -429,6 +505,11 @@ FLUSH PRIVILEGES;
 	if hasInit {
 		sql += "\nRESET SLAVE ALL;\n"
 	}
+	// The replication requires SSL when the certificates are mounted, the option is kept by
+	// the CHANGE MASTER of xenon.
+	if exists, _ := checkIfPathExists(utils.TlsMountPath); exists {
+		sql += "\nCHANGE MASTER TO MASTER_SSL=1;\n"
+	}
 	return utils.StringToBytes(sql)
 }
 
@@ -504,15 +585,12 @@ xtrabackup --defaults-file={{.MyCnfMountPath}} --datadir={{.DataDir}} --copy-bac
 chown -R mysql.mysql {{.DataDir}}
 rm -rf /root/backup
 */
-func (cfg *Config) executeS3Restore(path string) error {
+func (cfg *Config) executeCloudRestore() error {
 	if len(cfg.XRestoreFrom) == 0 {
 		return fmt.Errorf("do not have restore from")
 	}
-	if len(cfg.XCloudS3EndPoint) == 0 ||
-		len(cfg.XCloudS3AccessKey) == 0 ||
-		len(cfg.XCloudS3SecretKey) == 0 ||
-		len(cfg.XCloudS3Bucket) == 0 {
-		return fmt.Errorf("do not have S3 information")
+	if _, err := cfg.storageBackend(); err != nil {
+		return err
 	}
 	// Check has directory, and create it.
 	if _, err := os.Stat(utils.DataVolumeMountPath); os.IsNotExist(err) {
@@ -520,45 +598,13 @@ func (cfg *Config) executeS3Restore(path string) error {
 			return fmt.Errorf("failed to create data directory : %s", err)
 		}
 	}
-	// Execute xbcloud get.
-	args := []string{
-		"get",
-		"--storage=S3",
-		"--s3-endpoint=" + cfg.XCloudS3EndPoint,
-		"--s3-access-key=" + cfg.XCloudS3AccessKey,
-		"--s3-secret-key=" + cfg.XCloudS3SecretKey,
-		"--s3-bucket=" + cfg.XCloudS3Bucket,
-		"--parallel=10",
-		cfg.XRestoreFrom,
-		"--insecure",
+	if err := cfg.downloadCloudBackup(cfg.XRestoreFrom, utils.DataVolumeMountPath); err != nil {
+		return err
 	}
-	log.Info(fmt.Sprintf("run args %v", args))
-	xcloud := exec.Command(xcloudCommand, args...)                               //nolint
-	xbstream := exec.Command("xbstream", "-xv", "-C", utils.DataVolumeMountPath) //nolint
-	var err error
-	if xbstream.Stdin, err = xcloud.StdoutPipe(); err != nil {
-		return fmt.Errorf("failed to xbstream and xcloud piped")
-	}
-	xbstream.Stderr = os.Stderr
-	xcloud.Stderr = os.Stderr
-	if err := xcloud.Start(); err != nil {
-		return fmt.Errorf("failed to xcloud start : %s", err)
-	}
-	if err := xbstream.Start(); err != nil {
-		return fmt.Errorf("failed to xbstream start : %s", err)
-	}
-	// Make error channels.
-	errCh := make(chan error, 2)
-	go func() {
-		errCh <- xcloud.Wait()
-	}()
-	go func() {
-		errCh <- xbstream.Wait()
-	}()
-	// Wait for error.
-	for i := 0; i < 2; i++ {
-		if err = <-errCh; err != nil {
-			return err
+	// The backup is incremental, download the backups it based on and apply them in order.
+	if chain := readBackupChain(utils.DataVolumeMountPath); len(chain) > 1 {
+		if err := cfg.applyCloudBackupChain(chain); err != nil {
+			return fmt.Errorf("failed to apply the backup chain %v : %s", chain, err)
 		}
 	}
 	// Xtrabackup prepare and apply-log-only.
@@ -584,6 +630,109 @@ func (cfg *Config) executeS3Restore(path string) error {
 	return nil
 }
 
+// downloadCloudBackup downloads the backup from the cloud storage and extracts it to the directory.
+func (cfg *Config) downloadCloudBackup(name, dir string) error {
+	backend, err := cfg.storageBackend()
+	if err != nil {
+		return err
+	}
+	if err := backend.Download(name, dir); err != nil {
+		return err
+	}
+	return decodeBackup(dir, cfg.EncryptKey)
+}
+
+// applyCloudBackupChain downloads the backups in the chain, the incremental backup in the data
+// directory is moved aside and the full backup is downloaded in its place. Then the incremental
+// backups are applied in order.
+func (cfg *Config) applyCloudBackupChain(chain []string) error {
+	incrementalPath := path.Join(utils.DataVolumeMountPath, "incremental-backups")
+	last := path.Join(incrementalPath, chain[len(chain)-1])
+	if err := os.MkdirAll(last, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(utils.DataVolumeMountPath)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Name() == path.Base(incrementalPath) {
+			continue
+		}
+		if err := os.Rename(path.Join(utils.DataVolumeMountPath, f.Name()), path.Join(last, f.Name())); err != nil {
+			return err
+		}
+	}
+	defer os.RemoveAll(incrementalPath)
+
+	log.Info("download the full backup", "backup", chain[0])
+	if err := cfg.downloadCloudBackup(chain[0], utils.DataVolumeMountPath); err != nil {
+		return err
+	}
+	var incrementalDirs []string
+	for _, name := range chain[1:] {
+		dir := path.Join(incrementalPath, name)
+		if name != chain[len(chain)-1] {
+			log.Info("download the incremental backup", "backup", name)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err := cfg.downloadCloudBackup(name, dir); err != nil {
+				return err
+			}
+		}
+		incrementalDirs = append(incrementalDirs, dir)
+	}
+	return applyIncrementalBackups(utils.DataVolumeMountPath, incrementalDirs)
+}
+
+// applyIncrementalBackups prepares the full backup in the target directory with --apply-log-only,
+// then applies the incremental backups in order. The final prepare is left to the caller.
+func applyIncrementalBackups(targetDir string, incrementalDirs []string) error {
+	log.Info("xtrabackup prepare apply-log-only", "target", targetDir)
+	cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir="+targetDir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to xtrabackup prepare apply-log-only : %s", err)
+	}
+	for _, dir := range incrementalDirs {
+		log.Info("xtrabackup apply incremental backup", "incremental", dir)
+		cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir="+targetDir, "--incremental-dir="+dir)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to apply incremental backup %s : %s", dir, err)
+		}
+	}
+	return nil
+}
+
+// buildCloneSh build the clone.sh, it is sourced by the docker-entrypoint.sh in init-mysql
+// container. The clone plugin must be installed on the remote mysql. The donor and its
+// credentials are read from the REMOTE_* env of init-mysql, so that the password is never
+// written to the file.
+// After clone, mysqld shutdown by itself because it is not managed by supervisor process,
+// so wait for it and exit the entrypoint.
+func (cfg *Config) buildCloneSh() []byte {
+	str := `#!/bin/bash
+sql_escape() {
+	local s=${1//\\/\\\\}
+	printf '%s' "${s//\'/\'\'}"
+}
+donor="${REMOTE_HOST}:${REMOTE_PORT:-3306}"
+"${mysql[@]}" <<EOF
+INSTALL PLUGIN clone SONAME 'mysql_clone.so';
+SET GLOBAL clone_valid_donor_list='$(sql_escape "$donor")';
+CLONE INSTANCE FROM '$(sql_escape "$REMOTE_USER")'@'$(sql_escape "$REMOTE_HOST")':${REMOTE_PORT:-3306} IDENTIFIED BY '$(sql_escape "$REMOTE_PASSWORD")';
+EOF
+while "${mysql[@]}" -e 'SELECT 1' >/dev/null 2>&1; do
+	sleep 1
+done
+echo "clone from $donor finished"
+exit 0
+`
+	return utils.StringToBytes(str)
+}
+
 // Do Restore after clone.
 func (cfg *Config) executeCloneRestore() error {
 	// Check directory exist, create if not exist.
@@ -658,6 +807,22 @@ func GetXtrabackupGTIDPurged(backuppath string) (string, error) {
 	return strings.Replace(ss[2], "\n", "", -1), nil
 }
 
+// Parse the xtrabackup_checkpoints, get the to_lsn of the backup.
+// The format is key = value per line, such as to_lsn = 18153561.
+func GetXtrabackupToLSN(backuppath string) (string, error) {
+	byteStream, err := ioutil.ReadFile(fmt.Sprintf("%s/xtrabackup_checkpoints", backuppath))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(byteStream), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "to_lsn" {
+			return strings.TrimSpace(kv[1]), nil
+		}
+	}
+	return "", fmt.Errorf("to_lsn not found in %s/xtrabackup_checkpoints", backuppath)
+}
+
 /*
 `#!/bin/sh
 
@@ -676,13 +841,13 @@ func GetXtrabackupGTIDPurged(backuppath string) (string, error) {
 	    exit $exit_code
 */
 func (cfg *Config) ExecuteNFSRestore() error {
-	if len(cfg.XRestoreFromNFS) == 0 {
+	if cfg.RestoreStorage == NFS && len(cfg.XRestoreFromNFS) == 0 {
 		return fmt.Errorf("parameter XRestoreFromNFS empty, do next step")
 	}
 	if len(cfg.XRestoreFrom) == 0 {
 		return fmt.Errorf("xrestore from is empty, do next step")
 	}
-	// Restore from NFS
+	// Restore from NFS, PVC or the directory on the host, they are mounted at /backup.
 
 	// Check /var/lib/mysql exists or not.
 	if _, err := os.Stat(utils.DataVolumeMountPath); os.IsNotExist(err) {
@@ -703,6 +868,18 @@ func (cfg *Config) ExecuteNFSRestore() error {
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to rm -rf %s : %s", utils.DataVolumeMountPath, err)
 	}
+	// The backup is incremental, prepare the chain in the data directory.
+	if chain := readBackupChain("/backup/" + cfg.XRestoreFrom); len(chain) > 1 {
+		return cfg.executeNFSCopyRestore(chain)
+	}
+	// The encrypted or compressed backup is decoded in the data directory, keep it in NFS as is.
+	encrypted, compressed, err := getBackupEncoding("/backup/" + cfg.XRestoreFrom)
+	if err != nil {
+		return fmt.Errorf("failed to check the backup %s : %s", cfg.XRestoreFrom, err)
+	}
+	if encrypted || compressed {
+		return cfg.executeNFSCopyRestore([]string{cfg.XRestoreFrom})
+	}
 	// Prepare the append-only file
 	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir=/backup/"+cfg.XRestoreFrom)
 	cmd.Stderr = os.Stderr
@@ -731,3 +908,60 @@ func (cfg *Config) ExecuteNFSRestore() error {
 
 	return nil
 }
+
+// executeNFSCopyRestore copies the full backup of the chain to the data directory, decodes it
+// and applies the incremental backups in order, the backups in the volume are kept unprepared.
+// The chain of a full backup is the backup itself.
+func (cfg *Config) executeNFSCopyRestore(chain []string) error {
+	backend, err := cfg.storageBackend()
+	if err != nil {
+		return err
+	}
+	log.Info("copy the full backup", "backup", chain[0])
+	if err := backend.Download(chain[0], utils.DataVolumeMountPath); err != nil {
+		return err
+	}
+	if err := decodeBackup(utils.DataVolumeMountPath, cfg.EncryptKey); err != nil {
+		return err
+	}
+	// The encrypted or compressed incremental backups are copied aside and decoded.
+	incrementalPath := path.Join(utils.DataVolumeMountPath, "incremental-backups")
+	defer os.RemoveAll(incrementalPath)
+	var incrementalDirs []string
+	for _, name := range chain[1:] {
+		dir := "/backup/" + name
+		encrypted, compressed, err := getBackupEncoding(dir)
+		if err != nil {
+			return fmt.Errorf("failed to check the backup %s : %s", name, err)
+		}
+		if encrypted || compressed {
+			dir = path.Join(incrementalPath, name)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err := backend.Download(name, dir); err != nil {
+				return err
+			}
+			if err := decodeBackup(dir, cfg.EncryptKey); err != nil {
+				return err
+			}
+		}
+		incrementalDirs = append(incrementalDirs, dir)
+	}
+	if err := applyIncrementalBackups(utils.DataVolumeMountPath, incrementalDirs); err != nil {
+		return err
+	}
+	log.Info("xtrabackup prepare")
+	cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--target-dir="+utils.DataVolumeMountPath)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to xtrabackup prepare : %s", err)
+	}
+	log.Info(fmt.Sprintf("change owner of data directory %s", utils.DataVolumeMountPath))
+	cmd = exec.Command("chown", "-R", "mysql.mysql", utils.DataVolumeMountPath)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to chown -R mysql.mysql : %s", err)
+	}
+	return nil
+}
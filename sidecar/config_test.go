@@ -0,0 +1,50 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCloneSh(t *testing.T) {
+	cfg := &Config{}
+	script := cfg.buildCloneSh()
+	// The credentials are never written to the file.
+	assert.NotContains(t, string(script), "secret")
+
+	file := filepath.Join(t.TempDir(), "clone.sh")
+	assert.NoError(t, os.WriteFile(file, script, 0755))
+	// The entrypoint sources clone.sh with the mysql client in ${mysql[@]}.
+	cmd := exec.Command("bash", "-c", "mysql=(cat); source "+file)
+	cmd.Env = append(os.Environ(),
+		"REMOTE_HOST=192.168.0.10",
+		"REMOTE_PORT=3307",
+		"REMOTE_USER=migrate",
+		`REMOTE_PASSWORD=se'cret\`,
+	)
+	out, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSTALL PLUGIN clone SONAME 'mysql_clone.so';\n"+
+		"SET GLOBAL clone_valid_donor_list='192.168.0.10:3307';\n"+
+		`CLONE INSTANCE FROM 'migrate'@'192.168.0.10':3307 IDENTIFIED BY 'se''cret\\';`+"\n"+
+		"clone from 192.168.0.10:3307 finished\n", string(out))
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// compressZstd compresses the backup by zstd, it requires xtrabackup 8.0.30 or later.
+	compressZstd = "zstd"
+	// compressQpress compresses the backup by qpress, known as quicklz in xtrabackup.
+	compressQpress = "qpress"
+
+	// The suffixes of the encrypted and compressed files in the backup.
+	encryptedSuffix = ".xbcrypt"
+	zstdSuffix      = ".zst"
+	qpressSuffix    = ".qp"
+)
+
+// getEncryptAlgorithm returns the xtrabackup encryption algorithm by the length of the key.
+func getEncryptAlgorithm(key string) (string, error) {
+	switch len(key) {
+	case 16:
+		return "AES128", nil
+	case 24:
+		return "AES192", nil
+	case 32:
+		return "AES256", nil
+	}
+	return "", fmt.Errorf("invalid encryption key length %d, must be 16, 24 or 32", len(key))
+}
+
+// writeEncryptKeyFile writes the key to a temporary file, so that the key is not shown
+// in the command line. The caller should remove the file.
+func writeEncryptKeyFile(key string) (string, error) {
+	f, err := ioutil.TempFile("", "encrypt-key")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(key); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// xtrabackupStreamArgs returns the xtrabackup arguments to compress and encrypt the backup
+// stream, and a function to remove the encryption key file.
+func xtrabackupStreamArgs(compression, encryptKey string) ([]string, func(), error) {
+	var args []string
+	switch compression {
+	case "":
+	case compressZstd:
+		args = append(args, "--compress=zstd")
+	case compressQpress:
+		args = append(args, "--compress=quicklz")
+	default:
+		return nil, nil, fmt.Errorf("unknown compression: %s", compression)
+	}
+	if len(encryptKey) == 0 {
+		return args, func() {}, nil
+	}
+	algorithm, err := getEncryptAlgorithm(encryptKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyFile, err := writeEncryptKeyFile(encryptKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write encryption key file: %s", err)
+	}
+	args = append(args, "--encrypt="+algorithm, "--encrypt-key-file="+keyFile)
+	return args, func() { os.Remove(keyFile) }, nil
+}
+
+// getBackupEncoding checks whether the backup in the directory is encrypted or compressed.
+func getBackupEncoding(dir string) (bool, bool, error) {
+	var encrypted, compressed bool
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name := strings.TrimSuffix(info.Name(), encryptedSuffix)
+		if name != info.Name() {
+			encrypted = true
+		}
+		if strings.HasSuffix(name, zstdSuffix) || strings.HasSuffix(name, qpressSuffix) {
+			compressed = true
+		}
+		return nil
+	})
+	return encrypted, compressed, err
+}
+
+// decodeBackup decrypts and decompresses the backup in the directory in place, it does
+// nothing if the backup is neither encrypted nor compressed.
+func decodeBackup(dir, encryptKey string) error {
+	encrypted, compressed, err := getBackupEncoding(dir)
+	if err != nil {
+		return err
+	}
+	if !encrypted && !compressed {
+		return nil
+	}
+	args := []string{"--remove-original", "--target-dir=" + dir}
+	if compressed {
+		args = append(args, "--decompress")
+	}
+	if encrypted {
+		if len(encryptKey) == 0 {
+			return fmt.Errorf("the backup in %s is encrypted, but the encrypt-key is not set", dir)
+		}
+		algorithm, err := getEncryptAlgorithm(encryptKey)
+		if err != nil {
+			return err
+		}
+		keyFile, err := writeEncryptKeyFile(encryptKey)
+		if err != nil {
+			return fmt.Errorf("failed to write encryption key file: %s", err)
+		}
+		defer os.Remove(keyFile)
+		args = append(args, "--decrypt="+algorithm, "--encrypt-key-file="+keyFile)
+	}
+	log.Info("decrypt and decompress the backup", "dir", dir, "encrypted", encrypted, "compressed", compressed)
+	cmd := exec.Command(xtrabackupCommand, args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to decrypt and decompress the backup in %s : %s", dir, err)
+	}
+	return nil
+}
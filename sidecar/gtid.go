@@ -0,0 +1,145 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gtidInterval is the closed interval [start, end] of the transaction numbers.
+type gtidInterval struct {
+	start, end int64
+}
+
+// GTIDSet is the set of the gtids, the intervals of a source are sorted and merged.
+type GTIDSet map[string][]gtidInterval
+
+// ParseGTIDSet parses the gtid set, such as "uuid:1-5:7,uuid2:1-3".
+func ParseGTIDSet(str string) (GTIDSet, error) {
+	set := GTIDSet{}
+	str = strings.Replace(strings.TrimSpace(str), "\n", "", -1)
+	if len(str) == 0 {
+		return set, nil
+	}
+	for _, part := range strings.Split(str, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid gtid set %s", str)
+		}
+		sid := strings.ToLower(fields[0])
+		for _, field := range fields[1:] {
+			bounds := strings.SplitN(field, "-", 2)
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gtid set %s: %s", str, err)
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid gtid set %s: %s", str, err)
+				}
+			}
+			if start < 1 || end < start {
+				return nil, fmt.Errorf("invalid gtid set %s", str)
+			}
+			set.addInterval(sid, gtidInterval{start, end})
+		}
+	}
+	return set, nil
+}
+
+// Add adds the transaction of the source to the set.
+func (s GTIDSet) Add(sid string, gno int64) {
+	s.addInterval(sid, gtidInterval{gno, gno})
+}
+
+func (s GTIDSet) addInterval(sid string, in gtidInterval) {
+	intervals := append(s[sid], in)
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	merged := intervals[:1]
+	for _, next := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if next.start <= last.end+1 {
+			if next.end > last.end {
+				last.end = next.end
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	s[sid] = merged
+}
+
+// Union adds all the transactions of other to the set.
+func (s GTIDSet) Union(other GTIDSet) {
+	for sid, intervals := range other {
+		for _, in := range intervals {
+			s.addInterval(sid, in)
+		}
+	}
+}
+
+// Contains returns true if all the transactions of other are in the set.
+func (s GTIDSet) Contains(other GTIDSet) bool {
+	for sid, intervals := range other {
+		for _, in := range intervals {
+			found := false
+			for _, mine := range s[sid] {
+				if mine.start <= in.start && in.end <= mine.end {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the gtid set in the format of mysql.
+func (s GTIDSet) String() string {
+	sids := make([]string, 0, len(s))
+	for sid := range s {
+		sids = append(sids, sid)
+	}
+	sort.Strings(sids)
+	parts := make([]string, 0, len(sids))
+	for _, sid := range sids {
+		part := sid
+		for _, in := range s[sid] {
+			if in.start == in.end {
+				part += fmt.Sprintf(":%d", in.start)
+			} else {
+				part += fmt.Sprintf(":%d-%d", in.start, in.end)
+			}
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatSID formats the 16 bytes server uuid.
+func formatSID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32])
+}
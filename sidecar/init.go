@@ -19,7 +19,9 @@ package sidecar
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -59,17 +61,25 @@ func NewInitCommand(cfg *Config) *cobra.Command {
 	return cmd
 }
 
+// getServiceHost returns the address of the backup server behind the leader or follower service.
+func getServiceHost(cfg *Config, service string) string {
+	return fmt.Sprintf("%s-%s:%v", cfg.ClusterName, service, utils.XBackupPort)
+}
+
 // Check leader or follower backup status is ok.
 func CheckServiceExist(cfg *Config, service string) bool {
-	serviceURL := fmt.Sprintf("http://%s-%s:%v%s", cfg.ClusterName, service, utils.XBackupPort, "/health")
+	serviceURL := prepareURL(getServiceHost(cfg, service), serverProbeEndpoint)
 	req, err := http.NewRequest("GET", serviceURL, nil)
 	if err != nil {
 		log.Info("failed to check available service", "service", serviceURL, "error", err)
 		return false
 	}
 
-	client := &http.Client{}
-	client.Transport = transportWithTimeout(serverConnectTimeout)
+	client, err := newBackupClient()
+	if err != nil {
+		log.Info("failed to create the backup client", "service", serviceURL, "error", err)
+		return false
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Info("service was not available", "service", serviceURL, "error", err)
@@ -89,24 +99,24 @@ func CheckServiceExist(cfg *Config, service string) bool {
 // Clone from leader or follower.
 func runCloneAndInit(cfg *Config) (bool, error) {
 	//check follower is exists?
-	serviceURL := ""
+	host := ""
 	var hasInitialized = false
 	// Check the rebuildFrom exist?
 	if service, err := getPod(cfg); err == nil {
-		serviceURL = service
-		log.Info("found the rebuild-from pod", "service", serviceURL)
+		host = service
+		log.Info("found the rebuild-from pod", "service", host)
 	} else {
 		log.Info("found the rebuild-from pod", "error", err.Error())
 	}
-	if len(serviceURL) == 0 && CheckServiceExist(cfg, "follower") {
-		serviceURL = fmt.Sprintf("http://%s-%s:%v", cfg.ClusterName, "follower", utils.XBackupPort)
+	if len(host) == 0 && CheckServiceExist(cfg, "follower") {
+		host = getServiceHost(cfg, "follower")
 	}
 	//check leader is exist?
-	if len(serviceURL) == 0 && CheckServiceExist(cfg, "leader") {
-		serviceURL = fmt.Sprintf("http://%s-%s:%v", cfg.ClusterName, "leader", utils.XBackupPort)
+	if len(host) == 0 && CheckServiceExist(cfg, "leader") {
+		host = getServiceHost(cfg, "leader")
 	}
 
-	if len(serviceURL) != 0 {
+	if len(host) != 0 {
 		// Check has initialized. If so just return.
 		hasInitialized, _ = checkIfPathExists(path.Join(dataPath, "mysql"))
 		if hasInitialized {
@@ -114,19 +124,109 @@ func runCloneAndInit(cfg *Config) (bool, error) {
 			return hasInitialized, nil
 		}
 		// backup at first
-		Args := fmt.Sprintf("curl --user $BACKUP_USER:$BACKUP_PASSWORD %s/download|xbstream -x -C %s; exit ${PIPESTATUS[0]}",
-			serviceURL, utils.DataVolumeMountPath)
-		cmd := exec.Command("/bin/bash", "-c", "--", Args)
-		log.Info("runCloneAndInit", "cmd", Args)
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return hasInitialized, fmt.Errorf("failed to disable the run restore: %s", err)
+		log.Info("runCloneAndInit", "service", host)
+		if err := cloneFromServer(cfg, host, utils.DataVolumeMountPath); err != nil {
+			return hasInitialized, fmt.Errorf("failed to clone from %s: %s", host, err)
 		}
 		cfg.XRestoreFrom = utils.DataVolumeMountPath // just for init clone
 		cfg.CloneFlag = true
 		return hasInitialized, nil
 	}
 	log.Info("no leader or follower found")
+	if len(cfg.RemoteHost) != 0 {
+		return runRemoteClone(cfg)
+	}
+	return hasInitialized, nil
+}
+
+// cloneFromServer streams the backup from the backup server of host and extracts it to dir,
+// the client certificate is presented if the server serves over TLS.
+func cloneFromServer(cfg *Config, host, dir string) error {
+	client, err := newBackupClient()
+	if err != nil {
+		return err
+	}
+	return downloadBackup(client, prepareURL(host, serverBackupDownLoadEndpoint), cfg.BackupUser, cfg.BackupPassword, dir)
+}
+
+// cloneFromRemoteServer streams the backup from the backup server of the remote source and
+// extracts it to dir. The backup is only downloaded over TLS with the credentials.
+func cloneFromRemoteServer(cfg *Config, dir string) error {
+	if len(cfg.RemoteBackupUser) == 0 || len(cfg.RemoteBackupPassword) == 0 {
+		return fmt.Errorf("backup-user and backup-password must be set in the remote secret to clone MySQL 5.7")
+	}
+	for _, file := range []string{remoteTLSCAFile, remoteTLSCertFile, remoteTLSKeyFile} {
+		if exists, _ := checkIfPathExists(file); !exists {
+			return fmt.Errorf("%s must be set in the remote secret to clone MySQL 5.7", path.Base(file))
+		}
+	}
+	client, err := newTLSClient(remoteTLSCAFile, remoteTLSCertFile, remoteTLSKeyFile)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%s%s", net.JoinHostPort(cfg.RemoteHost, strconv.Itoa(cfg.RemoteBackupPort)), serverBackupDownLoadEndpoint)
+	return downloadBackup(client, url, cfg.RemoteBackupUser, cfg.RemoteBackupPassword, dir)
+}
+
+// downloadBackup gets the backup stream from the url and extracts it to dir by xbstream.
+func downloadBackup(client *http.Client, url, user, password, dir string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(user, password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	xbstream := exec.Command("xbstream", "-x", "-C", dir)
+	xbstream.Stdin = resp.Body
+	xbstream.Stderr = os.Stderr
+	if err := xbstream.Run(); err != nil {
+		return fmt.Errorf("failed to extract the backup: %s", err)
+	}
+	// The trailer is read after the body.
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return err
+	}
+	if status := resp.Trailer.Get(backupStatusTrailer); status != backupSuccessful {
+		return fmt.Errorf("the backup server failed, status: %q", status)
+	}
+	return nil
+}
+
+// Clone the first pod from the remote mysql.
+func runRemoteClone(cfg *Config) (bool, error) {
+	if ordinal, err := utils.GetOrdinal(cfg.HostName); err != nil || ordinal != 0 {
+		return false, err
+	}
+	// Check has initialized. If so just return.
+	hasInitialized, _ := checkIfPathExists(path.Join(dataPath, "mysql"))
+	if hasInitialized {
+		log.Info("MySQL data directory existing!")
+		return hasInitialized, nil
+	}
+	log.Info("clone from the remote mysql", "host", cfg.RemoteHost, "port", cfg.RemotePort)
+	if cfg.MySQLVersion.Major == 5 {
+		// MySQL 5.7 has no clone plugin, so download the xtrabackup stream from the
+		// backup server of the remote source.
+		if err := cloneFromRemoteServer(cfg, utils.DataVolumeMountPath); err != nil {
+			return hasInitialized, fmt.Errorf("failed to clone from the backup server of %s: %s", cfg.RemoteHost, err)
+		}
+		cfg.XRestoreFrom = utils.DataVolumeMountPath // just for init clone
+		cfg.CloneFlag = true
+		return hasInitialized, nil
+	}
+	// MySQL 8.0 clones by the clone plugin in the init-mysql container.
+	if err := ioutil.WriteFile(initFilePath+"/clone.sh", cfg.buildCloneSh(), 0755); err != nil {
+		return hasInitialized, fmt.Errorf("failed to write clone.sh: %s", err)
+	}
+	cfg.remoteClone = true
 	return hasInitialized, nil
 }
 
@@ -226,8 +326,11 @@ func runInitCommand(cfg *Config, hasInitialized bool) error {
 		return fmt.Errorf("failed to chown %s: %s", dataPath, err)
 	}
 	// Run reset master in init-mysql container.
-	if err = ioutil.WriteFile(initFilePath+"/reset.sql", []byte("reset master;"), 0644); err != nil {
-		return fmt.Errorf("failed to write reset.sql: %s", err)
+	// The gtid executed must be kept if clone from the remote mysql.
+	if !cfg.remoteClone {
+		if err = ioutil.WriteFile(initFilePath+"/reset.sql", []byte("reset master;"), 0644); err != nil {
+			return fmt.Errorf("failed to write reset.sql: %s", err)
+		}
 	}
 
 	// build init.sql.
@@ -281,10 +384,20 @@ func runInitCommand(cfg *Config, hasInitialized bool) error {
 					return fmt.Errorf("failed to execute Clone Restore : %s", err_f)
 				}
 			} else {
-				if err_f = cfg.ExecuteNFSRestore(); err_f != nil {
+				if isCloudStorage(cfg.RestoreStorage) {
+					err_f = cfg.executeCloudRestore()
+				} else if err_f = cfg.ExecuteNFSRestore(); err_f != nil && cfg.RestoreStorage == NFS {
 					// No nfs , do s3 restore.
-					if err_f = cfg.executeS3Restore(cfg.XRestoreFrom); err_f != nil {
-						return fmt.Errorf("failed to restore from %s: %s", cfg.XRestoreFrom, err_f)
+					cfg.RestoreStorage = S3
+					err_f = cfg.executeCloudRestore()
+				}
+				if err_f != nil {
+					return fmt.Errorf("failed to restore from %s: %s", cfg.XRestoreFrom, err_f)
+				}
+				// Replay the archived binlogs to the restore target.
+				if len(cfg.RestoreBinlogCluster) != 0 {
+					if err_f = cfg.prepareBinlogReplay(); err_f != nil {
+						return fmt.Errorf("failed to prepare binlog replay: %s", err_f)
 					}
 				}
 			}
@@ -308,16 +421,20 @@ func runInitCommand(cfg *Config, hasInitialized bool) error {
 // start the backup http server.
 func RunHttpServer(cfg *Config, stop <-chan struct{}) error {
 	srv := newServer(cfg, stop)
+	if srv.useTLS {
+		return srv.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
+	}
 	return srv.ListenAndServe()
 }
 
 // request a backup command.
 func RunRequestBackup(cfg *BackupClientConfig, host string) error {
-	if cfg.BackupType == S3 {
+	cfg.HostName = host
+	switch cfg.BackupType {
+	case S3, Azure, GCS:
 		_, err := requestS3Backup(cfg, host, serverBackupEndpoint)
 		return err
-	}
-	if cfg.BackupType == NFS {
+	case NFS, PVC, Local:
 		err := requestNFSBackup(cfg, host, serverBackupDownLoadEndpoint)
 		return err
 	}
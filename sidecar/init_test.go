@@ -0,0 +1,145 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// useTestCerts writes the certificates signed by a new CA to a temporary directory,
+// and uses them as the mounted certificates of the backup server and the clients.
+func useTestCerts(t *testing.T) {
+	caCert, caKey, err := utils.NewCA("test-ca", utils.CAValidity)
+	assert.NoError(t, err)
+	cert, key, err := utils.NewSignedCert(caCert, caKey, "test", []string{"localhost", "127.0.0.1"}, utils.CertValidity)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	oldCA, oldCert, oldKey := tlsCAFile, tlsCertFile, tlsKeyFile
+	tlsCAFile, tlsCertFile, tlsKeyFile = filepath.Join(dir, "ca.crt"), filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	t.Cleanup(func() { tlsCAFile, tlsCertFile, tlsKeyFile = oldCA, oldCert, oldKey })
+	assert.NoError(t, os.WriteFile(tlsCAFile, caCert, 0600))
+	assert.NoError(t, os.WriteFile(tlsCertFile, cert, 0600))
+	assert.NoError(t, os.WriteFile(tlsKeyFile, key, 0600))
+}
+
+// useFakeCommands puts the fake xtrabackup and xbstream in the PATH, xtrabackup streams
+// a fixed content and xbstream saves the stream to the stream file in the target dir.
+func useFakeCommands(t *testing.T) {
+	bin := t.TempDir()
+	fakes := map[string]string{
+		"xtrabackup": "echo backup-stream",
+		"xbstream":   `cat > "$3/stream"`,
+	}
+	for name, script := range fakes {
+		assert.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/bash\n"+script+"\n"), 0755))
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+}
+
+func TestCloneFromTLSServer(t *testing.T) {
+	useTestCerts(t)
+	useFakeCommands(t)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	srv := newServer(&Config{BackupUser: "backup", BackupPassword: "secret"}, stop)
+	assert.True(t, srv.useTLS)
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.TLS = srv.TLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+
+	dir := t.TempDir()
+	cfg := &Config{BackupUser: "backup", BackupPassword: "secret"}
+	assert.NoError(t, cloneFromServer(cfg, host, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "stream"))
+	assert.NoError(t, err)
+	assert.Equal(t, "backup-stream\n", string(data))
+
+	// The client without the certificate is rejected.
+	pool, err := loadCAPool(tlsCAFile)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	req, err := http.NewRequest("GET", prepareURL(host, serverBackupDownLoadEndpoint), nil)
+	assert.NoError(t, err)
+	req.SetBasicAuth(cfg.BackupUser, cfg.BackupPassword)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	cfg.BackupPassword = "wrong"
+	assert.Error(t, cloneFromServer(cfg, host, t.TempDir()))
+}
+
+func TestCloneFromRemoteServer(t *testing.T) {
+	useTestCerts(t)
+	useFakeCommands(t)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	srv := newServer(&Config{BackupUser: "backup", BackupPassword: "secret"}, stop)
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.TLS = srv.TLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	assert.NoError(t, err)
+	cfg := &Config{RemoteHost: host}
+	cfg.RemoteBackupPort, err = strconv.Atoi(port)
+	assert.NoError(t, err)
+
+	// The credentials are required.
+	assert.Error(t, cloneFromRemoteServer(cfg, t.TempDir()))
+	cfg.RemoteBackupUser, cfg.RemoteBackupPassword = "backup", "secret"
+
+	// The certificates in the remote secret are required.
+	oldCA, oldCert, oldKey := remoteTLSCAFile, remoteTLSCertFile, remoteTLSKeyFile
+	remoteDir := t.TempDir()
+	remoteTLSCAFile = filepath.Join(remoteDir, "ca.crt")
+	remoteTLSCertFile = filepath.Join(remoteDir, "tls.crt")
+	remoteTLSKeyFile = filepath.Join(remoteDir, "tls.key")
+	defer func() { remoteTLSCAFile, remoteTLSCertFile, remoteTLSKeyFile = oldCA, oldCert, oldKey }()
+	assert.Error(t, cloneFromRemoteServer(cfg, t.TempDir()))
+
+	for src, dst := range map[string]string{tlsCAFile: remoteTLSCAFile, tlsCertFile: remoteTLSCertFile, tlsKeyFile: remoteTLSKeyFile} {
+		data, err := os.ReadFile(src)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dst, data, 0600))
+	}
+	dir := t.TempDir()
+	assert.NoError(t, cloneFromRemoteServer(cfg, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "stream"))
+	assert.NoError(t, err)
+	assert.Equal(t, "backup-stream\n", string(data))
+
+	cfg.RemoteBackupPassword = "wrong"
+	assert.Error(t, cloneFromRemoteServer(cfg, t.TempDir()))
+}
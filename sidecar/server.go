@@ -18,9 +18,12 @@ package sidecar
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
@@ -34,6 +37,8 @@ import (
 const (
 	// backupStatus http trailer
 	backupStatusTrailer = "X-Backup-Status"
+	// backupToLSN http trailer, the xtrabackup_checkpoints in the stream may be encrypted.
+	backupToLSNTrailer = "X-Backup-To-LSN"
 
 	// success string
 	backupSuccessful = "Success"
@@ -50,8 +55,22 @@ const (
 	serverBackupDownLoadEndpoint = "/download"
 )
 
+var (
+	// The certificates of the backup server, the backup jobs and the init-sidecar.
+	tlsCAFile   = utils.XBackupTlsMountPath + "/ca.crt"
+	tlsCertFile = utils.XBackupTlsMountPath + "/tls.crt"
+	tlsKeyFile  = utils.XBackupTlsMountPath + "/tls.key"
+
+	// The certificates to download the backup from the backup server of the remote source.
+	remoteTLSCAFile   = utils.RemoteSourceTlsMountPath + "/ca.crt"
+	remoteTLSCertFile = utils.RemoteSourceTlsMountPath + "/tls.crt"
+	remoteTLSKeyFile  = utils.RemoteSourceTlsMountPath + "/tls.key"
+)
+
 type server struct {
 	cfg *Config
+	// useTLS serves over TLS and requires the client certificates.
+	useTLS bool
 	http.Server
 }
 
@@ -59,12 +78,16 @@ type server struct {
 func newServer(cfg *Config, stop <-chan struct{}) *server {
 	mux := http.NewServeMux()
 	srv := &server{
-		cfg: cfg,
+		cfg:    cfg,
+		useTLS: tlsEnabled(),
 		Server: http.Server{
 			Addr:    fmt.Sprintf(":%d", serverPort),
 			Handler: mux,
 		},
 	}
+	if srv.useTLS {
+		srv.TLSConfig = serverTLSConfig()
+	}
 
 	// Add handle functions.
 	// Health check
@@ -111,15 +134,18 @@ func (s *server) backupHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Not authenticated!", http.StatusForbidden)
 		return
 	}
-	// /backup only handle S3 backup
-	if requestBody.BackupType == S3 {
-
-		backName, Datetime, backupSize, err := RunTakeS3BackupCommand(&requestBody)
+	// /backup only handle the backups uploaded by xbcloud
+	if isCloudStorage(requestBody.BackupType) {
+		if requestBody.EncryptKey, err = s.encryptKey(requestBody.Encrypt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		backName, Datetime, toLSN, backupSize, err := RunTakeS3BackupCommand(&requestBody)
 		log.Info("get backup result", "backName", backName)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		} else {
-			msg, _ := json.Marshal(utils.JsonResult{Status: backupSuccessful, BackupName: backName, Date: Datetime, BackupSize: backupSize})
+			msg, _ := json.Marshal(utils.JsonResult{Status: backupSuccessful, BackupName: backName, Date: Datetime, BackupSize: backupSize, ToLSN: toLSN})
 			w.Write(msg)
 		}
 	}
@@ -138,12 +164,47 @@ func (s *server) backupDownloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The request body asks for the compression and encryption, the key is the server's own.
+	var requestBody BackupClientConfig
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	encryptKey, err := s.encryptKey(requestBody.Encrypt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	streamArgs, cleanup, err := xtrabackupStreamArgs(requestBody.Compression, encryptKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cleanup()
+
+	lsnDir, err := ioutil.TempDir("", "backup-lsn")
+	if err != nil {
+		log.Error(err, "failed to create the extra lsn dir")
+		http.Error(w, "xtrabackup failed", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(lsnDir)
+
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Trailer", backupStatusTrailer)
+	w.Header().Add("Trailer", backupToLSNTrailer)
 
+	args := append(s.cfg.XtrabackupArgs(), streamArgs...)
+	args = append(args, "--extra-lsndir="+lsnDir)
+	// Take an incremental backup if the lsn is specified.
+	if lsn := r.URL.Query().Get("incremental-lsn"); len(lsn) != 0 {
+		args = append(args, fmt.Sprintf("--incremental-lsn=%s", lsn))
+	}
 	// nolint: gosec
-	xtrabackup := exec.Command(xtrabackupCommand, s.cfg.XtrabackupArgs()...)
+	xtrabackup := exec.Command(xtrabackupCommand, args...)
 	xtrabackup.Stderr = os.Stderr
 
 	stdout, err := xtrabackup.StdoutPipe()
@@ -178,11 +239,32 @@ func (s *server) backupDownloadHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// success
+	if toLSN, err := GetXtrabackupToLSN(lsnDir); err != nil {
+		log.Error(err, "failed to get the to_lsn of backup")
+	} else {
+		w.Header().Set(backupToLSNTrailer, toLSN)
+	}
 	w.Header().Set(backupStatusTrailer, backupSuccessful)
 	flusher.Flush()
 }
 
+// encryptKey returns the key to encrypt the backup, it is read from the backup secret of the
+// cluster which the restore decrypts with, so that the key never goes over the network.
+func (s *server) encryptKey(encrypt bool) (string, error) {
+	if !encrypt {
+		return "", nil
+	}
+	if len(s.cfg.EncryptKey) == 0 {
+		return "", fmt.Errorf("the encryption is required, but the encrypt-key is not set in the backup secret of the cluster")
+	}
+	return s.cfg.EncryptKey, nil
+}
+
 func (s *server) isAuthenticated(r *http.Request) bool {
+	// The client must present a certificate signed by the ca when serving over TLS.
+	if s.useTLS && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+		return false
+	}
 	user, pass, ok := r.BasicAuth()
 	return ok && user == s.cfg.BackupUser && pass == s.cfg.BackupPassword
 }
@@ -204,11 +286,88 @@ func prepareURL(svc string, endpoint string) string {
 	if !strings.Contains(svc, ":") {
 		svc = fmt.Sprintf("%s:%d", svc, serverPort)
 	}
-	return fmt.Sprintf("http://%s%s", svc, endpoint)
+	scheme := "http"
+	if tlsEnabled() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, svc, endpoint)
+}
+
+// tlsEnabled returns true if the certificates are mounted.
+func tlsEnabled() bool {
+	exists, _ := checkIfPathExists(tlsCertFile)
+	return exists
+}
+
+// loadCAPool returns the cert pool of the ca.
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse the ca in %s", caFile)
+	}
+	return pool, nil
+}
+
+// serverTLSConfig loads the certificates on every handshake, so that the rotated certificates
+// take effect without restarting the server.
+func serverTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, err := tls.LoadX509KeyPair(tlsCertFile, tlsKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := loadCAPool(tlsCAFile)
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{cert},
+				ClientCAs:    pool,
+				// The probes do not present certificates, so the client certificate is
+				// required by the backup handlers instead of the handshake.
+				ClientAuth: tls.VerifyClientCertIfGiven,
+			}, nil
+		},
+	}
+}
+
+// newBackupClient returns the client of the backup server, it presents the client
+// certificate and verifies the server by the ca if the certificates are mounted.
+func newBackupClient() (*http.Client, error) {
+	if !tlsEnabled() {
+		return &http.Client{Transport: transportWithTimeout(serverConnectTimeout)}, nil
+	}
+	return newTLSClient(tlsCAFile, tlsCertFile, tlsKeyFile)
+}
+
+// newTLSClient returns the client that presents the certificate and trusts the ca.
+func newTLSClient(caFile, certFile, keyFile string) (*http.Client, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate: %s", err)
+	}
+	pool, err := loadCAPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	transport := transportWithTimeout(serverConnectTimeout)
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}
+	return &http.Client{Transport: transport}, nil
 }
 
 // Set the timeout for HTTP.
-func transportWithTimeout(connectTimeout time.Duration) http.RoundTripper {
+func transportWithTimeout(connectTimeout time.Duration) *http.Transport {
 	return &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
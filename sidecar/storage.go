@@ -0,0 +1,209 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// StorageBackend stores the backups, a backup is uploaded as the xbstream stream of
+// xtrabackup and downloaded as the extracted files.
+type StorageBackend interface {
+	// Upload stores the xbstream stream read from r as the backup.
+	Upload(name string, r io.Reader) error
+	// Download extracts the backup to the directory.
+	Download(name, dir string) error
+	// Delete removes the backup from the storage.
+	Delete(name string) error
+}
+
+// CloudStorageConfig is the credentials of azure blob storage and google cloud storage.
+type CloudStorageConfig struct {
+	AzureStorageAccount string `json:"azure_storage_account"`
+	AzureAccessKey      string `json:"azure_access_key"`
+	AzureContainerName  string `json:"azure_container_name"`
+	AzureEndPoint       string `json:"azure_endpoint"`
+	GCSEndPoint         string `json:"gcs_endpoint"`
+	GCSAccessKey        string `json:"gcs_access_key"`
+	GCSSecretKey        string `json:"gcs_secret_key"`
+	GCSBucket           string `json:"gcs_bucket"`
+}
+
+// newCloudStorageConfig returns the credentials obtained from the environment variables.
+func newCloudStorageConfig() CloudStorageConfig {
+	return CloudStorageConfig{
+		AzureStorageAccount: getEnvValue("AZURE_STORAGE_ACCOUNT"),
+		AzureAccessKey:      getEnvValue("AZURE_ACCESS_KEY"),
+		AzureContainerName:  getEnvValue("AZURE_CONTAINER_NAME"),
+		AzureEndPoint:       getEnvValue("AZURE_ENDPOINT"),
+		GCSEndPoint:         getEnvValue("GCS_ENDPOINT"),
+		GCSAccessKey:        getEnvValue("GCS_ACCESSKEY"),
+		GCSSecretKey:        getEnvValue("GCS_SECRETKEY"),
+		GCSBucket:           getEnvValue("GCS_BUCKET"),
+	}
+}
+
+// s3Credentials is the credentials of S3.
+type s3Credentials struct {
+	endpoint  string
+	accessKey string
+	secretKey string
+	bucket    string
+}
+
+// isCloudStorage returns true if the backups are stored by xbcloud, otherwise the
+// backups are stored in the volume mounted at /backup.
+func isCloudStorage(storage BkType) bool {
+	return storage == S3 || storage == Azure || storage == GCS
+}
+
+// newStorageBackend returns the backend of the storage.
+func newStorageBackend(storage BkType, s3 s3Credentials, cloud CloudStorageConfig) (StorageBackend, error) {
+	var args []string
+	switch storage {
+	case NFS, PVC, Local:
+		return &fsBackend{root: utils.XtrabckupLocal}, nil
+	case S3:
+		if len(s3.endpoint) == 0 || len(s3.accessKey) == 0 ||
+			len(s3.secretKey) == 0 || len(s3.bucket) == 0 {
+			return nil, fmt.Errorf("do not have S3 information")
+		}
+		args = []string{
+			"--storage=S3",
+			"--s3-endpoint=" + s3.endpoint,
+			"--s3-access-key=" + s3.accessKey,
+			"--s3-secret-key=" + s3.secretKey,
+			"--s3-bucket=" + s3.bucket,
+		}
+	case Azure:
+		if len(cloud.AzureStorageAccount) == 0 || len(cloud.AzureAccessKey) == 0 ||
+			len(cloud.AzureContainerName) == 0 {
+			return nil, fmt.Errorf("do not have azure information")
+		}
+		args = []string{
+			"--storage=azure",
+			"--azure-storage-account=" + cloud.AzureStorageAccount,
+			"--azure-access-key=" + cloud.AzureAccessKey,
+			"--azure-container-name=" + cloud.AzureContainerName,
+		}
+		if len(cloud.AzureEndPoint) != 0 {
+			args = append(args, "--azure-endpoint="+cloud.AzureEndPoint)
+		}
+	case GCS:
+		if len(cloud.GCSAccessKey) == 0 || len(cloud.GCSSecretKey) == 0 ||
+			len(cloud.GCSBucket) == 0 {
+			return nil, fmt.Errorf("do not have gcs information")
+		}
+		args = []string{
+			"--storage=google",
+			"--google-access-key=" + cloud.GCSAccessKey,
+			"--google-secret-key=" + cloud.GCSSecretKey,
+			"--google-bucket=" + cloud.GCSBucket,
+		}
+		if len(cloud.GCSEndPoint) != 0 {
+			args = append(args, "--google-endpoint="+cloud.GCSEndPoint)
+		}
+	default:
+		return nil, fmt.Errorf("unknown backup type: %s", storage)
+	}
+	return &xbcloudBackend{storageArgs: args}, nil
+}
+
+// fsBackend stores the backups as directories in the volume, such as NFS, PVC or
+// the directory on the host.
+type fsBackend struct {
+	root string
+}
+
+var _ StorageBackend = &fsBackend{}
+
+func (b *fsBackend) Upload(name string, r io.Reader) error {
+	dir := path.Join(b.root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup dir: %w", err)
+	}
+	xbstream := exec.Command("xbstream", "-x", "-C", dir)
+	xbstream.Stdin = r
+	xbstream.Stderr = os.Stderr
+	if err := xbstream.Run(); err != nil {
+		return fmt.Errorf("xbstream command failed: %w", err)
+	}
+	return nil
+}
+
+func (b *fsBackend) Download(name, dir string) error {
+	cmd := exec.Command("cp", "-a", path.Join(b.root, name)+"/.", dir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy the backup %s : %s", name, err)
+	}
+	return nil
+}
+
+func (b *fsBackend) Delete(name string) error {
+	if err := os.RemoveAll(path.Join(b.root, name)); err != nil {
+		return fmt.Errorf("failed to delete backup %s: %s", name, err)
+	}
+	return nil
+}
+
+// xbcloudBackend stores the backups in S3, azure blob storage or google cloud storage by
+// xbcloud. xbcloud cannot list the objects, the backups are recorded in the status of the Backup.
+type xbcloudBackend struct {
+	storageArgs []string
+}
+
+var _ StorageBackend = &xbcloudBackend{}
+
+// xcloudArgs build the xbcloud arguments of the action on the object.
+func (b *xbcloudBackend) xcloudArgs(action, name string) []string {
+	args := append([]string{action}, b.storageArgs...)
+	return append(args, "--parallel=10", name, "--insecure")
+}
+
+func (b *xbcloudBackend) Upload(name string, r io.Reader) error {
+	xcloud := exec.Command(xcloudCommand, b.xcloudArgs("put", name)...)
+	xcloud.Stdin = r
+	xcloud.Stderr = os.Stderr
+	if err := xcloud.Run(); err != nil {
+		return fmt.Errorf("failed to upload backup %s: %s", name, err)
+	}
+	return nil
+}
+
+func (b *xbcloudBackend) Download(name, dir string) error {
+	args := b.xcloudArgs("get", name)
+	log.Info("download the backup", "backup", name, "storage", b.storageArgs[0])
+	xcloud := exec.Command(xcloudCommand, args...)         //nolint
+	xbstream := exec.Command("xbstream", "-xv", "-C", dir) //nolint
+	return runPiped(xcloud, xbstream)
+}
+
+func (b *xbcloudBackend) Delete(name string) error {
+	xcloud := exec.Command(xcloudCommand, b.xcloudArgs("delete", name)...)
+	xcloud.Stderr = os.Stderr
+	if err := xcloud.Run(); err != nil {
+		return fmt.Errorf("failed to delete backup %s: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"time"
+)
+
+const (
+	// CAValidity is the validity of the CA generated by the operator.
+	CAValidity = 10 * 365 * 24 * time.Hour
+	// CertValidity is the validity of the certificate signed by the CA.
+	CertValidity = 365 * 24 * time.Hour
+	// CertRenewBefore is how long before the expiry the certificate is renewed.
+	CertRenewBefore = 30 * 24 * time.Hour
+
+	// The keys of the certificates in the tls secret.
+	TLSCAKey   = "ca.crt"
+	TLSCAPKey  = "ca.key"
+	TLSCertKey = "tls.crt"
+	TLSPKey    = "tls.key"
+
+	rsaKeySize = 2048
+)
+
+// NewCA generates a self-signed CA, returns the certificate and the private key in PEM.
+func NewCA(commonName string, validity time.Duration) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := newCertTemplate(commonName, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCert(der), encodeKey(key), nil
+}
+
+// NewSignedCert generates a certificate signed by the CA, which can be used by both the
+// server and the client. The names are added as the DNS or IP SANs. MySQL 5.7 only reads
+// the PKCS#1 private key, so the key is encoded in PKCS#1.
+func NewSignedCert(caCertPEM, caKeyPEM []byte, commonName string, names []string,
+	validity time.Duration) ([]byte, []byte, error) {
+	caCert, err := ParseCert(caCertPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	caKey, err := parseKey(caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := newCertTemplate(commonName, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, name)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCert(der), encodeKey(key), nil
+}
+
+// CertNeedsRenewal returns true if the certificate cannot be parsed, expires within
+// renewBefore, or its SANs are different from the names.
+func CertNeedsRenewal(certPEM []byte, names []string, renewBefore time.Duration) bool {
+	cert, err := ParseCert(certPEM)
+	if err != nil {
+		return true
+	}
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return true
+	}
+	if names == nil {
+		return false
+	}
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	want := append([]string{}, names...)
+	sort.Strings(sans)
+	sort.Strings(want)
+	if len(sans) != len(want) {
+		return true
+	}
+	for i := range sans {
+		if sans[i] != want[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCert parses the first certificate in PEM.
+func ParseCert(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode the certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, fmt.Errorf("failed to decode the private key")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func newCertTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"radondb"}},
+		// Tolerate the clock skew between the nodes.
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedCert(t *testing.T) {
+	caCert, caKey, err := NewCA("sample-ca", CAValidity)
+	assert.NoError(t, err)
+	names := []string{"sample-leader", "sample-mysql-0.sample-mysql.default", "127.0.0.1"}
+	cert, _, err := NewSignedCert(caCert, caKey, "sample", names, CertValidity)
+	assert.NoError(t, err)
+
+	// verified by the CA.
+	{
+		ca, err := ParseCert(caCert)
+		assert.NoError(t, err)
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+		c, err := ParseCert(cert)
+		assert.NoError(t, err)
+		_, err = c.Verify(x509.VerifyOptions{
+			DNSName:   "sample-leader",
+			Roots:     pool,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		assert.NoError(t, err)
+	}
+	// same names.
+	{
+		assert.Equal(t, false, CertNeedsRenewal(cert, []string{"127.0.0.1", "sample-mysql-0.sample-mysql.default", "sample-leader"}, CertRenewBefore))
+	}
+	// names changed.
+	{
+		assert.Equal(t, true, CertNeedsRenewal(cert, []string{"sample-leader"}, CertRenewBefore))
+	}
+	// about to expire.
+	{
+		assert.Equal(t, true, CertNeedsRenewal(cert, names, CertValidity+time.Hour))
+	}
+	// invalid certificate.
+	{
+		assert.Equal(t, true, CertNeedsRenewal([]byte("invalid"), nil, CertRenewBefore))
+	}
+}
@@ -49,6 +49,7 @@ const (
 	ContainerErrorLogName  = "errorlog"
 	ContainerBackupName    = "backup"
 	ContainerBackupJobName = "backup-job"
+	ContainerBinlogName    = "binlog-archiver"
 
 	// xtrabackup
 	XBackupPortName = "xtrabackup"
@@ -56,6 +57,9 @@ const (
 	XtrabackupPV    = "backup"
 	XtrabckupLocal  = "/backup"
 
+	// binlog archive
+	BinlogArchivePV = "binlog-archive"
+
 	// MySQL port.
 	MysqlPortName = "mysql"
 	MysqlPort     = 3306
@@ -122,6 +126,12 @@ const (
 	// preUpdate file
 	FileIndicateUpdate = "PreUpdating"
 
+	// XenonDrainedFile marks the leader drained by a switchover, it is shared by xenon and mysql.
+	XenonDrainedFile = XenonMetaVolumeMountPath + "/drained"
+
+	// XenonFencedFile marks the leader fenced for losing the quorum, it is shared by xenon and mysql.
+	XenonFencedFile = XenonMetaVolumeMountPath + "/fenced"
+
 	// LeaderHost is the alias for leader`s host.
 	LeaderHost = "leader-host"
 
@@ -131,6 +141,12 @@ const (
 	TlsVolumeName = "tls"
 	// TlsMountPath is the volume mount path for tls
 	TlsMountPath = "/etc/mysql-ssl"
+	// XBackupTlsMountPath is the volume mount path for the tls of the sidecar backup server and the backup jobs
+	XBackupTlsMountPath = "/etc/xbackup-ssl"
+	// RemoteSourceVolumeName is the volume name for the tls of the remote source backup server
+	RemoteSourceVolumeName = "remote-source-ssl"
+	// RemoteSourceTlsMountPath is the volume mount path for the tls of the remote source backup server
+	RemoteSourceTlsMountPath = "/etc/remote-source-ssl"
 
 	//extra env for readonly
 	ROIbPool = "IB_POOL"
@@ -172,6 +188,8 @@ const (
 	PodDisruptionBudget ResourceName = "pdb"
 	// XenonMetaData is the name of the configmap that contains xenon metadata.
 	XenonMetaData ResourceName = "xenon-metadata"
+	// TLSSecret is the name of the secret that contains the certificates generated by the operator.
+	TLSSecret ResourceName = "tls-secret"
 	// Job Annonations name
 	JobAnonationName = "backupName"
 	// Job Annonations date
@@ -180,6 +198,12 @@ const (
 	JobAnonationType = "backupType"
 	// Job Annonations size
 	JobAnonationSize = "backupSize"
+	// Job Annonations host
+	JobAnonationHost = "backupHost"
+	// Job Annonations to lsn
+	JobAnonationToLSN = "backupToLSN"
+	// Job Annonations chain
+	JobAnonationChain = "backupChain"
 )
 
 // JobType
@@ -199,6 +223,24 @@ const LableRebuild = "rebuild"
 const LabelRebuildFrom = "rebuild-from"
 const LabelMaintain = "maintain"
 
+// AnnotationManagedConfigs is the annotation of the mysql configmap, records the keys from the
+// mysqlConf/pluginConf with their original values.
+const AnnotationManagedConfigs = "mysql.radondb.com/managed-configs"
+
+// AnnotationConfigRev is the annotation of the mysql configmap, records the config_rev of the
+// pods, and AnnotationStaticConfigsHash records the hash of the static configs it stands for.
+const (
+	AnnotationConfigRev         = "mysql.radondb.com/config-rev"
+	AnnotationStaticConfigsHash = "mysql.radondb.com/static-configs-hash"
+)
+
+// AnnotationXenonSemiCheck is the annotation of the mysql pod, records whether the semi-sync
+// check of xenon has been enabled.
+const AnnotationXenonSemiCheck = "mysql.radondb.com/xenon-semi-check"
+
+// SemiSyncNeverTimeout is the rpl_semi_sync_master_timeout that never degrades to async.
+const SemiSyncNeverTimeout = "1000000000000000000"
+
 // XenonHttpUrl is a http url corresponding to the xenon instruction.
 type XenonHttpUrl string
 
@@ -215,6 +257,7 @@ type JsonResult struct {
 	BackupName string `json:"backupName"`
 	Date       string `json:"date"`
 	BackupSize int64  `json:"backupSize"`
+	ToLSN      string `json:"toLSN"`
 }
 
 // MySQLDefaultVersionMap is a map of supported mysql version and their image
@@ -93,6 +93,90 @@ func PatchRoleLabelTo(n MySQLNode) error {
 	return nil
 }
 
+// PatchLeaderEndpointsTo points the endpoints of the leader service to the node,
+// so that the clients need not wait for the endpoints controller after a failover.
+func PatchLeaderEndpointsTo(n MySQLNode) error {
+	clientset, err := GetClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %v", err)
+	}
+	pod, err := clientset.CoreV1().Pods(n.Namespace).Get(context.TODO(), n.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %v", err)
+	}
+	if pod.Status.PodIP == "" {
+		return fmt.Errorf("pod %s has no ip", n.PodName)
+	}
+	cluster, ok := pod.Labels["mysql.radondb.com/cluster"]
+	if !ok {
+		return fmt.Errorf("pod %s has no cluster label", n.PodName)
+	}
+	nodeName := pod.Spec.NodeName
+	subsets := []corev1.EndpointSubset{
+		{
+			Addresses: []corev1.EndpointAddress{
+				{
+					IP:       pod.Status.PodIP,
+					NodeName: &nodeName,
+					TargetRef: &corev1.ObjectReference{
+						Kind:      "Pod",
+						Namespace: pod.Namespace,
+						Name:      pod.Name,
+						UID:       pod.UID,
+					},
+				},
+			},
+			Ports: []corev1.EndpointPort{
+				{Name: MysqlPortName, Port: MysqlPort, Protocol: corev1.ProtocolTCP},
+				{Name: XBackupPortName, Port: XBackupPort, Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+	patch, err := json.Marshal(map[string]interface{}{"subsets": subsets})
+	if err != nil {
+		return fmt.Errorf("failed to marshal endpoints patch: %v", err)
+	}
+	// The same name as the leader service, see MysqlCluster.GetNameForResource.
+	name := fmt.Sprintf("%s-leader", cluster)
+	_, err = clientset.CoreV1().Endpoints(n.Namespace).Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch leader endpoints: %v", err)
+	}
+	return nil
+}
+
+// RecordEventTo records an event of the node's pod.
+func RecordEventTo(n MySQLNode, eventType, reason, message string) error {
+	clientset, err := GetClientSet()
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %v", err)
+	}
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: n.PodName + ".",
+			Namespace:    n.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Namespace:  n.Namespace,
+			Name:       n.PodName,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Source:         corev1.EventSource{Component: "xenonchecker"},
+	}
+	if _, err = clientset.CoreV1().Events(n.Namespace).Create(context.TODO(), event, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create event: %v", err)
+	}
+	return nil
+}
+
 func XenonPingMyself() error {
 	args := []string{"xenon", "ping"}
 	cmd := exec.Command("xenoncli", args...)